@@ -1,19 +1,47 @@
 package main
 
 import (
+	"expvar"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"education/internal/db"
 	"education/internal/handlers" // This should include our schedule_month.go
+	"education/internal/health"
+	"education/internal/ratelimit"
+	"education/internal/scheduling"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 const workerCount = 10 // число воркеров
 
+// queueReadyLimit — при большей очереди апдейтов /readyz сообщает, что бот не успевает.
+const queueReadyLimit = 80
+
 func main() {
-	db.InitDB("education.db")
+	db.Open("education.db")
+
+	// Создаем канал для обновлений
+	updateChan := make(chan tgbotapi.Update, 100)
+
+	monitor := health.NewMonitor(db.DB, func() int { return len(updateChan) }, queueReadyLimit)
+	startHTTPServer(monitor)
+	monitor.SetMigrated()
+
+	db.SeedData() // Вызов функции генерации тестовых данных
+	if err := scheduling.ReloadCalendar(); err != nil {
+		log.Printf("Ошибка загрузки учебного календаря: %v", err)
+	}
+	if err := handlers.WarmUpCache(); err != nil {
+		log.Printf("Ошибка прогрева кэша: %v", err)
+	} else {
+		monitor.SetCacheWarm()
+	}
 
 	bot, err := tgbotapi.NewBotAPI(os.Getenv("TELEGRAM_BOT_TOKEN"))
 	if err != nil {
@@ -22,6 +50,30 @@ func main() {
 	bot.Debug = true
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
+	// Чат для отчётов об ошибках (необязательно)
+	if adminChat := os.Getenv("ADMIN_CHAT_ID"); adminChat != "" {
+		adminChatID, err := strconv.ParseInt(adminChat, 10, 64)
+		if err != nil {
+			log.Printf("Некорректный ADMIN_CHAT_ID: %v", err)
+		} else {
+			handlers.SetAdminChatID(adminChatID)
+		}
+	}
+
+	// Пользователи, которым разрешён импорт расписания: Telegram ID через запятую
+	if ids := os.Getenv("IMPORT_ADMIN_IDS"); ids != "" {
+		var admins []int64
+		for _, f := range strings.Split(ids, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(f), 10, 64)
+			if err != nil {
+				log.Printf("Некорректный IMPORT_ADMIN_IDS: %v", err)
+				continue
+			}
+			admins = append(admins, id)
+		}
+		handlers.SetImportAdmins(admins)
+	}
+
 	/*
 		// Установка команд (если нужно)
 		commands := []tgbotapi.BotCommand{
@@ -47,27 +99,94 @@ func main() {
 	u.Timeout = 60
 	updates := bot.GetUpdatesChan(u)
 
-	// Создаем канал для обновлений
-	updateChan := make(chan tgbotapi.Update, 100)
+	handlers.SetRateLimiter(ratelimit.New(rateLimitConfig()))
+
+	// Рабочее время для поиска общих свободных окон, WORKING_HOURS="08:00-20:00"
+	if v := os.Getenv("WORKING_HOURS"); v != "" {
+		if h, err := scheduling.ParseWorkingHours(v); err != nil {
+			log.Printf("Некорректный WORKING_HOURS: %v", err)
+		} else {
+			handlers.SetWorkingHours(h)
+		}
+	}
 
 	// Запускаем пул воркеров
+	messenger := handlers.NewRetryingMessenger(handlers.NewBotMessenger(bot))
+	handlers.StartChangeNotifier(messenger, notifyWindow())
+	handlers.StartReminderScheduler(messenger, handlers.DefaultReminderInterval)
+	handlers.StartDigestScheduler(messenger, handlers.DefaultReminderInterval)
+	handlers.StartLiveCardUpdater(messenger, handlers.DefaultLiveCardInterval)
 	for i := 0; i < workerCount; i++ {
-		go worker(i, bot, updateChan)
+		go worker(i, messenger, updateChan)
 	}
 
 	// Передаем обновления в канал
 	for update := range updates {
+		monitor.MarkUpdate()
 		updateChan <- update
 	}
 }
 
-func worker(id int, bot *tgbotapi.BotAPI, updateChan <-chan tgbotapi.Update) {
-	for update := range updateChan {
-		if update.CallbackQuery != nil {
-			handlers.ProcessCallback(update.CallbackQuery, bot)
+// rateLimitConfig собирает настройки лимитов из окружения поверх значений по умолчанию:
+// RATE_LIMITS="schedule=1/5,materials=1/5", RATE_LIMIT_BAN_AFTER=20, RATE_LIMIT_BAN_MINUTES=10.
+func rateLimitConfig() ratelimit.Config {
+	cfg := ratelimit.DefaultConfig()
+	if v := os.Getenv("RATE_LIMITS"); v != "" {
+		limits, err := ratelimit.ParseLimits(v)
+		if err != nil {
+			log.Printf("Некорректный RATE_LIMITS: %v", err)
+		}
+		for class, limit := range limits {
+			cfg.Limits[class] = limit
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_BAN_AFTER"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.BanAfter = n
+		}
+	}
+	if v := os.Getenv("RATE_LIMIT_BAN_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.BanDuration = time.Duration(n) * time.Minute
+		}
+	}
+	return cfg
+}
+
+// startHTTPServer поднимает HTTP-сервер (/healthz, /readyz, /metrics и ленты календаря /ical/),
+// если задан HTTP_ADDR. Ссылки на ленты бот выдаёт только при заданном ICAL_BASE_URL.
+func startHTTPServer(monitor *health.Monitor) {
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		return
+	}
+	handlers.SetICalBaseURL(os.Getenv("ICAL_BASE_URL"))
+	mux := http.NewServeMux()
+	monitor.Register(mux)
+	mux.Handle("/metrics", expvar.Handler())
+	mux.Handle("/ical/", handlers.ICalFeedHandler())
+	go func() {
+		log.Printf("HTTP-сервер слушает %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Ошибка HTTP-сервера: %v", err)
 		}
-		if update.Message != nil {
-			handlers.ProcessMessage(&update, bot)
+	}()
+}
+
+func worker(id int, bot handlers.Messenger, updateChan <-chan tgbotapi.Update) {
+	for update := range updateChan {
+		// Паника в одном апдейте не должна ронять воркер и весь процесс
+		handlers.HandleUpdate(update, bot)
+	}
+}
+
+// notifyWindow — окно объединения уведомлений об изменениях расписания, NOTIFY_BATCH_SECONDS.
+func notifyWindow() time.Duration {
+	if v := os.Getenv("NOTIFY_BATCH_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return time.Duration(n) * time.Second
 		}
+		log.Printf("Некорректный NOTIFY_BATCH_SECONDS: %q", v)
 	}
+	return handlers.DefaultNotifyWindow
 }
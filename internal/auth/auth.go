@@ -82,12 +82,12 @@ func FindUnregisteredTeacher(pass string) (*models.User, error) {
 // GetUserByTelegramID проверяет, есть ли пользователь с данным telegram_id
 func GetUserByTelegramID(telegramID int64) (*models.User, error) {
 	row := db.DB.QueryRow(`
-		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code
+		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code, COALESCE(subgroup_id, 0)
 		FROM users
 		WHERE telegram_id = ?
 	`, telegramID)
 	var u models.User
-	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode)
+	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode, &u.SubgroupID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -106,12 +106,12 @@ func DeleteUserByTelegramID(telegramID int64) error {
 // GetUserByRegCode ищет пользователя (telegram_id != 0 или 0) по registration_code
 func GetUserByRegCode(regCode string) (*models.User, error) {
 	row := db.DB.QueryRow(`
-		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code
+		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code, COALESCE(subgroup_id, 0)
 		FROM users
 		WHERE registration_code = ?
 	`, regCode)
 	var u models.User
-	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode)
+	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode, &u.SubgroupID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -124,12 +124,12 @@ func GetUserByRegCode(regCode string) (*models.User, error) {
 // GetUserByID обновлена аналогичным образом
 func GetUserByID(id int64) (*models.User, error) {
 	row := db.DB.QueryRow(`
-		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code
+		SELECT id, telegram_id, role, name, faculty, group_name, password, registration_code, COALESCE(subgroup_id, 0)
 		FROM users
 		WHERE id = ?
 	`, id)
 	var u models.User
-	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode)
+	err := row.Scan(&u.ID, &u.TelegramID, &u.Role, &u.Name, &u.Faculty, &u.Group, &u.Password, &u.RegistrationCode, &u.SubgroupID)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -138,3 +138,12 @@ func GetUserByID(id int64) (*models.User, error) {
 	}
 	return &u, nil
 }
+
+// SetUserSubgroup закрепляет пользователя за подгруппой; 0 — снять с подгруппы.
+func SetUserSubgroup(userID, subgroupID int64) error {
+	_, err := db.DB.Exec(`UPDATE users SET subgroup_id = NULLIF(?, 0) WHERE id = ?`, subgroupID, userID)
+	if err != nil {
+		return fmt.Errorf("SetUserSubgroup: %w", err)
+	}
+	return nil
+}
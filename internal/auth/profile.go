@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// GetTeacherProfile возвращает профиль преподавателя по регистрационному коду.
+// Если преподаватель не найден, возвращает nil, nil; если профиль ещё не заполнен — только ФИО.
+func GetTeacherProfile(regCode string) (*models.TeacherProfile, error) {
+	row := db.DB.QueryRow(`
+		SELECT u.registration_code, u.name,
+			COALESCE(p.display_name, ''), COALESCE(p.department, ''), COALESCE(p.email, ''),
+			COALESCE(p.telegram, ''), COALESCE(p.office_room, ''), COALESCE(p.office_hours, '')
+		FROM users u
+		LEFT JOIN teacher_profiles p ON p.registration_code = u.registration_code
+		WHERE u.registration_code = ? AND u.role = 'teacher'
+	`, regCode)
+	var p models.TeacherProfile
+	err := row.Scan(&p.RegistrationCode, &p.Name, &p.DisplayName, &p.Department, &p.Email,
+		&p.Telegram, &p.OfficeRoom, &p.OfficeHours)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetTeacherProfile: %w", err)
+	}
+	return &p, nil
+}
+
+// SaveTeacherProfile записывает / обновляет профиль (по регистрационному коду). ФИО в users не меняется.
+func SaveTeacherProfile(p *models.TeacherProfile) error {
+	_, err := db.DB.Exec(`
+		INSERT INTO teacher_profiles (registration_code, display_name, department, email, telegram, office_room, office_hours)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(registration_code) DO UPDATE SET
+			display_name = excluded.display_name,
+			department = excluded.department,
+			email = excluded.email,
+			telegram = excluded.telegram,
+			office_room = excluded.office_room,
+			office_hours = excluded.office_hours
+	`,
+		p.RegistrationCode,
+		p.DisplayName,
+		p.Department,
+		p.Email,
+		p.Telegram,
+		p.OfficeRoom,
+		p.OfficeHours,
+	)
+	if err != nil {
+		return fmt.Errorf("SaveTeacherProfile: %w", err)
+	}
+	return nil
+}
+
+// TeacherNames возвращает имена для отображения всех преподавателей: registration_code -> имя.
+func TeacherNames() (map[string]string, error) {
+	rows, err := db.DB.Query(`
+		SELECT u.registration_code, COALESCE(NULLIF(p.display_name, ''), u.name)
+		FROM users u
+		LEFT JOIN teacher_profiles p ON p.registration_code = u.registration_code
+		WHERE u.role = 'teacher'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("TeacherNames: %w", err)
+	}
+	defer rows.Close()
+	names := make(map[string]string)
+	for rows.Next() {
+		var code, name string
+		if err := rows.Scan(&code, &name); err != nil {
+			return nil, fmt.Errorf("TeacherNames: %w", err)
+		}
+		names[code] = name
+	}
+	return names, rows.Err()
+}
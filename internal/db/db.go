@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"time"
 
@@ -12,8 +13,18 @@ import (
 // DB - глобальная переменная для доступа к базе данных.
 var DB *sql.DB
 
+// SchemaVersion — текущая версия схемы БД. Увеличивается при каждом изменении createTables
+// и записывается в PRAGMA user_version после успешного создания таблиц.
+const SchemaVersion = 12
+
 // InitDB инициализирует базу данных, создает таблицы и заполняет их тестовыми данными.
 func InitDB(dbFile string) {
+	Open(dbFile)
+	SeedData() // Вызов функции генерации тестовых данных
+}
+
+// Open открывает базу данных и применяет миграции без генерации тестовых данных.
+func Open(dbFile string) {
 	var err error
 	DB, err = sql.Open("sqlite3", dbFile)
 	if err != nil {
@@ -22,7 +33,22 @@ func InitDB(dbFile string) {
 	DB.SetMaxOpenConns(10)
 	DB.SetMaxIdleConns(5)
 	createTables()
-	SeedData() // Вызов функции генерации тестовых данных
+	setSchemaVersion()
+}
+
+// setSchemaVersion фиксирует версию схемы после применения миграций.
+func setSchemaVersion() {
+	// PRAGMA не поддерживает плейсхолдеры, поэтому версия подставляется напрямую
+	if _, err := DB.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		log.Panicf("Ошибка записи версии схемы: %v", err)
+	}
+}
+
+// MigrationVersion возвращает версию схемы, записанную в базе данных.
+func MigrationVersion(ctx context.Context) (int, error) {
+	var version int
+	err := DB.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	return version, err
 }
 
 // createTables создает все необходимые таблицы в базе данных.
@@ -120,4 +146,295 @@ func createTables() {
 	if err != nil {
 		log.Panicf("Ошибка создания таблицы materials: %v", err)
 	}
+
+	// 7) Чаты, куда доставка невозможна (бот заблокирован, чат удалён)
+	_, err = DB.ExecContext(ctx, `
+	    CREATE TABLE IF NOT EXISTS undeliverable_chats (
+	        chat_id INTEGER PRIMARY KEY,
+	        reason TEXT NOT NULL,
+	        failed_at DATETIME NOT NULL
+	    );
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы undeliverable_chats: %v", err)
+	}
+
+	// 8) Повторяющиеся занятия (weekday: 0 — воскресенье, как time.Weekday;
+	//    week_parity: 0 — каждую неделю, 1 — нечётные, 2 — чётные)
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schedule_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			course_id INTEGER NOT NULL,
+			group_name TEXT NOT NULL,
+			teacher_reg_code TEXT NOT NULL,
+			weekday INTEGER NOT NULL,
+			start_time TEXT NOT NULL,
+			duration INT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			auditory TEXT NOT NULL DEFAULT '',
+			lesson_type TEXT NOT NULL DEFAULT '',
+			valid_from TEXT NOT NULL, -- YYYY-MM-DD
+			valid_to TEXT NOT NULL,
+			week_parity INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY(course_id) REFERENCES courses(id)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы schedule_rules: %v", err)
+	}
+
+	// 9) Даты, в которые занятие по правилу не проводится
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schedule_rule_exceptions (
+			rule_id INTEGER NOT NULL,
+			exception_date TEXT NOT NULL,
+			PRIMARY KEY(rule_id, exception_date),
+			FOREIGN KEY(rule_id) REFERENCES schedule_rules(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы schedule_rule_exceptions: %v", err)
+	}
+
+	// Разовые изменения поверх правил: строка schedules с rule_id и rule_date заменяет
+	// занятие по правилу в этот день, а cancelled = 1 — отменяет его.
+	ensureColumn(ctx, "schedules", "rule_id", "INTEGER")
+	ensureColumn(ctx, "schedules", "rule_date", "TEXT")
+	ensureColumn(ctx, "schedules", "cancelled", "INTEGER NOT NULL DEFAULT 0")
+
+	// 10) За сколько минут до занятия напоминать пользователю (по регистрационному коду,
+	//     чтобы настройки переживали выход и повторный вход)
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS reminder_offsets (
+			registration_code TEXT NOT NULL,
+			minutes INTEGER NOT NULL,
+			PRIMARY KEY(registration_code, minutes)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы reminder_offsets: %v", err)
+	}
+
+	// 11) Отправленные напоминания: защита от повторов после перезапуска.
+	//     lesson_time входит в ключ, чтобы о перенесённом занятии напомнить заново.
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS sent_reminders (
+			registration_code TEXT NOT NULL,
+			lesson_key TEXT NOT NULL,
+			lesson_time TEXT NOT NULL,
+			minutes INTEGER NOT NULL,
+			sent_at TEXT NOT NULL,
+			PRIMARY KEY(registration_code, lesson_key, lesson_time, minutes)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы sent_reminders: %v", err)
+	}
+
+	// 12) Ежедневная и недельная сводки расписания (daily_time = '' — ежедневная выключена)
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS digest_settings (
+			registration_code TEXT PRIMARY KEY,
+			daily_time TEXT NOT NULL DEFAULT '', -- HH:MM
+			weekly INTEGER NOT NULL DEFAULT 0
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы digest_settings: %v", err)
+	}
+
+	// 13) Отправленные сводки: kind — daily/weekly, period — день или понедельник недели
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS sent_digests (
+			registration_code TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			period TEXT NOT NULL,
+			sent_at TEXT NOT NULL,
+			PRIMARY KEY(registration_code, kind, period)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы sent_digests: %v", err)
+	}
+
+	// 14) Секретные ссылки на календарь-подписку. etag и modified_at — состояние ленты
+	//     на момент последнего запроса, чтобы клиенты получали 304, пока расписание не менялось.
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS ical_tokens (
+			token TEXT PRIMARY KEY,
+			registration_code TEXT NOT NULL UNIQUE,
+			created_at TEXT NOT NULL,
+			etag TEXT NOT NULL DEFAULT '',
+			modified_at TEXT NOT NULL DEFAULT ''
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы ical_tokens: %v", err)
+	}
+
+	// 15) Справочник аудиторий. schedules.auditory остаётся названием для отображения,
+	//     а room_id ссылается на запись справочника.
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS rooms (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			building TEXT NOT NULL DEFAULT '',
+			floor INTEGER NOT NULL DEFAULT 0,
+			capacity INTEGER NOT NULL DEFAULT 0,
+			equipment TEXT NOT NULL DEFAULT '' -- через запятую: проектор, компьютеры…
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы rooms: %v", err)
+	}
+	ensureColumn(ctx, "schedules", "room_id", "INTEGER REFERENCES rooms(id)")
+
+	// Аудитории, которые уже встречаются в расписании, переносим в справочник и связываем с занятиями
+	_, err = DB.ExecContext(ctx, `
+		INSERT OR IGNORE INTO rooms (name)
+		SELECT auditory FROM schedules WHERE auditory <> ''
+		UNION
+		SELECT auditory FROM schedule_rules WHERE auditory <> '';
+	`)
+	if err != nil {
+		log.Panicf("Ошибка заполнения справочника аудиторий: %v", err)
+	}
+	_, err = DB.ExecContext(ctx, `
+		UPDATE schedules SET room_id = (SELECT id FROM rooms WHERE rooms.name = schedules.auditory)
+		WHERE room_id IS NULL AND auditory <> '';
+	`)
+	if err != nil {
+		log.Panicf("Ошибка связывания занятий с аудиториями: %v", err)
+	}
+
+	// 16) Профили преподавателей: отображаемое имя и контакты для студентов.
+	//     Пустое display_name означает, что показывается users.name.
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS teacher_profiles (
+			registration_code TEXT PRIMARY KEY,
+			display_name TEXT NOT NULL DEFAULT '',
+			department TEXT NOT NULL DEFAULT '',
+			email TEXT NOT NULL DEFAULT '',
+			telegram TEXT NOT NULL DEFAULT '', -- без @
+			office_room TEXT NOT NULL DEFAULT '',
+			office_hours TEXT NOT NULL DEFAULT ''
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы teacher_profiles: %v", err)
+	}
+
+	// 17) Учебный календарь: семестры, праздники и сессии (даты YYYY-MM-DD, включительно)
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS academic_calendar (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL CHECK (kind IN ('semester', 'holiday', 'exams')),
+			name TEXT NOT NULL,
+			start_date TEXT NOT NULL,
+			end_date TEXT NOT NULL
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы academic_calendar: %v", err)
+	}
+
+	// 18) Подгруппы для лабораторных и практик. Занятие без subgroup_id проводится для всей группы,
+	//     студент без подгруппы видит занятия всех подгрупп.
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS subgroups (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_name TEXT NOT NULL,
+			name TEXT NOT NULL,
+			UNIQUE (group_name, name)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы subgroups: %v", err)
+	}
+	ensureColumn(ctx, "users", "subgroup_id", "INTEGER REFERENCES subgroups(id)")
+	ensureColumn(ctx, "schedules", "subgroup_id", "INTEGER REFERENCES subgroups(id)")
+	ensureColumn(ctx, "schedule_rules", "subgroup_id", "INTEGER REFERENCES subgroups(id)")
+
+	// 19) История изменений расписания: состояние занятия до и после (JSON, пусто — занятия не было)
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schedule_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			changed_at TEXT NOT NULL, -- RFC3339, UTC
+			changed_by TEXT NOT NULL DEFAULT '',
+			before_json TEXT NOT NULL DEFAULT '',
+			after_json TEXT NOT NULL DEFAULT '',
+			first_day TEXT NOT NULL, -- YYYY-MM-DD: самый ранний день из «до» и «после»
+			last_day TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_schedule_revisions_days ON schedule_revisions(first_day, last_day);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы schedule_revisions: %v", err)
+	}
+
+	// 20) Последняя версия расписания недели, которую видел пользователь
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schedule_views (
+			telegram_id INTEGER NOT NULL,
+			week_start TEXT NOT NULL, -- YYYY-MM-DD, понедельник
+			revision_id INTEGER NOT NULL,
+			PRIMARY KEY (telegram_id, week_start)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы schedule_views: %v", err)
+	}
+
+	// 21) Консультации преподавателей и записи студентов на них
+	_, err = DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS consultations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			teacher_reg_code TEXT NOT NULL,
+			starts_at TEXT NOT NULL, -- RFC3339, UTC
+			duration INTEGER NOT NULL,
+			place TEXT NOT NULL, -- аудитория или ссылка на онлайн-встречу
+			capacity INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_consultations_teacher ON consultations(teacher_reg_code, starts_at);
+		CREATE TABLE IF NOT EXISTS consultation_bookings (
+			consultation_id INTEGER NOT NULL REFERENCES consultations(id),
+			student_reg_code TEXT NOT NULL,
+			booked_at TEXT NOT NULL, -- RFC3339, UTC
+			PRIMARY KEY (consultation_id, student_reg_code)
+		);
+	`)
+	if err != nil {
+		log.Panicf("Ошибка создания таблицы consultations: %v", err)
+	}
+}
+
+// ensureColumn добавляет колонку в существующую таблицу, если её ещё нет.
+// CREATE TABLE IF NOT EXISTS не меняет уже созданные таблицы, поэтому новые колонки добавляются отдельно.
+func ensureColumn(ctx context.Context, table, column, decl string) {
+	rows, err := DB.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		log.Panicf("Ошибка чтения структуры таблицы %s: %v", table, err)
+	}
+	exists := false
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             interface{}
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			log.Panicf("Ошибка чтения структуры таблицы %s: %v", table, err)
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	rows.Close()
+	if exists {
+		return
+	}
+	if _, err := DB.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		log.Panicf("Ошибка добавления колонки %s.%s: %v", table, column, err)
+	}
 }
@@ -35,6 +35,7 @@ func SeedData() {
 	seedStudents()
 	seedTeachers()
 	seedTeacherCourseGroups()
+	seedRooms()
 	seedSchedules()
 	seedMaterials() // Генерация материалов
 }
@@ -291,6 +292,31 @@ func seedTeacherCourseGroups() {
 	}
 }
 
+// seedRooms добавляет в справочник учебные аудитории. Уже существующие записи не меняются.
+func seedRooms() {
+	rooms := []struct {
+		name, building  string
+		floor, capacity int
+		equipment       string
+	}{
+		{"101", "Главный корпус", 1, 120, "проектор, микрофон"},
+		{"102", "Главный корпус", 1, 60, "проектор"},
+		{"103", "Главный корпус", 1, 30, "компьютеры"},
+		{"104", "Главный корпус", 1, 30, "компьютеры, проектор"},
+		{"201", "Главный корпус", 2, 40, "интерактивная доска"},
+		{"202", "Главный корпус", 2, 25, ""},
+	}
+	for _, r := range rooms {
+		_, err := DB.Exec(`
+			INSERT OR IGNORE INTO rooms (name, building, floor, capacity, equipment)
+			VALUES (?, ?, ?, ?, ?)
+		`, r.name, r.building, r.floor, r.capacity, r.equipment)
+		if err != nil {
+			log.Panicf("Ошибка вставки аудитории: %v", err)
+		}
+	}
+}
+
 // Генерация расписания для преподавателей на 3 месяца.
 // Для каждого преподавателя в рабочие дни (понедельник-пятница) генерируется от 3 до 7 пар.
 // При этом выбранные временные слоты сортируются, и для каждой пары длительность подбирается так,
@@ -347,7 +373,22 @@ func seedSchedules() {
 	endDate := startDate.AddDate(0, 3, 0)
 
 	// Справочные данные
-	auditoryOptions := []string{"101", "102", "103", "104", "201", "202"}
+	var auditoryOptions []string
+	roomRows, err := DB.Query(`SELECT name FROM rooms ORDER BY name`)
+	if err != nil {
+		log.Panicf("Ошибка получения аудиторий: %v", err)
+	}
+	for roomRows.Next() {
+		var name string
+		if err := roomRows.Scan(&name); err != nil {
+			log.Panicf("Ошибка сканирования аудиторий: %v", err)
+		}
+		auditoryOptions = append(auditoryOptions, name)
+	}
+	roomRows.Close()
+	if len(auditoryOptions) == 0 {
+		log.Panicf("Справочник аудиторий пуст")
+	}
 	lessonTypes := []string{"Лекция", "Практика", "Лабораторная", "Семинар"}
 
 	// Карты для отслеживания занятости:
@@ -389,8 +430,8 @@ func seedSchedules() {
 						lessonType := lessonTypes[rand.Intn(len(lessonTypes))]
 
 						_, err := DB.Exec(`
-							INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration)
-							VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+							INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration, room_id)
+							VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT id FROM rooms WHERE name = ?))
 						`,
 							assignment.courseID,
 							assignment.groupName,
@@ -400,6 +441,7 @@ func seedSchedules() {
 							auditory,
 							lessonType,
 							slot.duration,
+							auditory,
 						)
 						if err != nil {
 							log.Panicf("Ошибка вставки расписания: %v", err)
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// periodKinds — названия видов периодов в команде /calendar.
+var periodKinds = map[string]models.CalendarPeriodKind{
+	"семестр":  models.PeriodSemester,
+	"semester": models.PeriodSemester,
+	"праздник": models.PeriodHoliday,
+	"каникулы": models.PeriodHoliday,
+	"holiday":  models.PeriodHoliday,
+	"сессия":   models.PeriodExams,
+	"exams":    models.PeriodExams,
+}
+
+var periodKindNames = map[models.CalendarPeriodKind]string{
+	models.PeriodSemester: "🎓 Семестр",
+	models.PeriodHoliday:  "🎉 Праздник",
+	models.PeriodExams:    "📝 Сессия",
+}
+
+const calendarHelp = "Команды:\n" +
+	"/calendar add семестр 03.02.2025 15.06.2025 Весенний семестр\n" +
+	"/calendar add праздник 08.03.2025 Международный женский день\n" +
+	"/calendar add сессия 02.06.2025 15.06.2025 Летняя сессия\n" +
+	"/calendar del 3 — удалить период по номеру\n\n" +
+	"Семестр указывается вместе с сессией: от его начала считаются учебные недели. " +
+	"В праздники занятия по правилам не проводятся, а разовые занятия помечаются."
+
+// academicWeekNote — строка о семестре, учебной неделе и сессии для диапазона [from, to] (HTML).
+func academicWeekNote(from, to time.Time) string {
+	cal := scheduling.CurrentCalendar()
+	var lines []string
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if week, total, ok := cal.AcademicWeek(d); ok {
+			sem, _ := cal.Semester(d)
+			parity := "чётная"
+			if scheduling.ParityOf(d) == models.ParityOdd {
+				parity = "нечётная"
+			}
+			lines = append(lines, fmt.Sprintf("🎓 %s · неделя %d из %d (%s)", html.EscapeString(sem.Name), week, total, parity))
+			break
+		}
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if exams, ok := cal.Exams(d); ok {
+			lines = append(lines, fmt.Sprintf("📝 %s: %s – %s", html.EscapeString(exams.Name),
+				exams.Start.Format("02.01"), exams.End.Format("02.01")))
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// holidayNote — отметка праздничного дня (HTML) или пустая строка.
+func holidayNote(day time.Time) string {
+	if p, ok := scheduling.CurrentCalendar().Holiday(day); ok {
+		return "🎉 Праздник: <b>" + html.EscapeString(p.Name) + "</b>\n"
+	}
+	return ""
+}
+
+// semesterNavigation сообщает, можно ли листать назад и вперёд от диапазона [from, to],
+// не выходя за границы семестра, в который он попадает. Вне семестров листать можно свободно.
+func semesterNavigation(from, to time.Time) (prev, next bool) {
+	sem, ok := scheduling.SemesterAround(from, to)
+	if !ok {
+		return true, true
+	}
+	return !from.AddDate(0, 0, -1).Before(sem.Start), !to.AddDate(0, 0, 1).After(sem.End)
+}
+
+// navButton — кнопка листания или заглушка на границе семестра.
+func navButton(label, data string, allowed bool) tgbotapi.InlineKeyboardButton {
+	if !allowed {
+		return tgbotapi.NewInlineKeyboardButtonData("⛔", calendarNoop)
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, data)
+}
+
+// parseCalendarDate разбирает дату в формате ДД.ММ.ГГГГ.
+func parseCalendarDate(s string) (time.Time, error) {
+	return time.Parse("02.01.2006", s)
+}
+
+// parseCalendarPeriod разбирает аргументы "вид начало [конец] название". Без даты конца период однодневный.
+func parseCalendarPeriod(fields []string) (*models.CalendarPeriod, error) {
+	if len(fields) < 3 {
+		return nil, errors.New("укажите вид, даты и название периода")
+	}
+	kind, ok := periodKinds[strings.ToLower(fields[0])]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный вид периода «%s»: семестр, праздник или сессия", fields[0])
+	}
+	start, err := parseCalendarDate(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать дату «%s», нужен формат ДД.ММ.ГГГГ", fields[1])
+	}
+	end, rest := start, fields[2:]
+	if d, err := parseCalendarDate(fields[2]); err == nil {
+		end, rest = d, fields[3:]
+	}
+	return &models.CalendarPeriod{Kind: kind, Name: strings.Join(rest, " "), Start: start, End: end}, nil
+}
+
+// ShowAcademicCalendar показывает администратору учебный календарь или меняет его.
+// args: пусто — список; "add вид начало [конец] название"; "del номер".
+func ShowAcademicCalendar(chatID int64, bot Messenger, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) > 0 {
+		var err error
+		switch strings.ToLower(fields[0]) {
+		case "add":
+			var p *models.CalendarPeriod
+			if p, err = parseCalendarPeriod(fields[1:]); err == nil {
+				err = scheduling.AddCalendarPeriod(p)
+			}
+		case "del":
+			var id int64
+			if len(fields) != 2 {
+				err = errors.New("укажите номер периода")
+			} else if id, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+				err = errors.New("номер периода — число")
+			} else {
+				err = scheduling.DeleteCalendarPeriod(id)
+			}
+		default:
+			err = errors.New("неизвестная команда")
+		}
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+"\n\n"+calendarHelp)
+			return sendAndTrackMessage(bot, msg)
+		}
+		// Праздники и границы семестра меняют развёрнутое расписание
+		ClearScheduleCache()
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📅 <b>Учебный календарь</b>\n\n")
+	periods := scheduling.CurrentCalendar().Periods
+	if len(periods) == 0 {
+		sb.WriteString("Календарь пуст.\n")
+	}
+	for _, p := range periods {
+		dates := p.Start.Format("02.01.2006")
+		if !p.End.Equal(p.Start) {
+			dates += " – " + p.End.Format("02.01.2006")
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s «%s»: %s\n", p.ID, periodKindNames[p.Kind], html.EscapeString(p.Name), dates))
+	}
+	sb.WriteString("\n" + html.EscapeString(calendarHelp))
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	return sendAndTrackMessage(bot, msg)
+}
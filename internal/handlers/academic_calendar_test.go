@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAcademicCalendarInScheduleViews(t *testing.T) {
+	admin := newConversation(t, 9002)
+	SetAdminChatID(9002)
+	t.Cleanup(func() { SetAdminChatID(0) })
+
+	admin.send("/calendar add семестр 03.03.2025 23.03.2025 Весенний модуль")
+	admin.send("/calendar add праздник 18.03.2025 День открытых дверей")
+	admin.send("/calendar add сессия 21.03.2025 23.03.2025 Зачётная неделя")
+	admin.send("/calendar add семестр 20.03.2025 30.03.2025 Пересекается")
+	admin.send("/calendar del 99")
+	admin.golden("academic_calendar_admin")
+
+	student := &conversation{t: t, bot: admin.bot, srv: admin.srv, chatID: 1007}
+	student.loggedIn("ST-0002", "secret12")
+	student.press("week_next_2025-03-17")
+	student.press("day_2025-03-18")
+	student.press("day_2025-03-23")
+	student.golden("academic_calendar_student")
+
+	// Вне административного чата команда просто показывает меню
+	student.send("/calendar del 1")
+	if got := student.transcript(); strings.Contains(got, "Учебный календарь") {
+		t.Errorf("календарь доступен вне административного чата:\n%s", got)
+	}
+}
+
+func TestParseCalendarPeriod(t *testing.T) {
+	p, err := parseCalendarPeriod(strings.Fields("праздник 08.03.2025 Международный женский день"))
+	if err != nil || p.Name != "Международный женский день" || !p.End.Equal(p.Start) {
+		t.Errorf("однодневный праздник: %+v %v", p, err)
+	}
+	p, err = parseCalendarPeriod(strings.Fields("Сессия 02.06.2025 15.06.2025 Летняя сессия"))
+	if err != nil || p.Kind != "exams" || p.End.Format("02.01") != "15.06" {
+		t.Errorf("сессия: %+v %v", p, err)
+	}
+	for _, args := range []string{"отпуск 01.07.2025 Лето", "семестр 2025-02-01 Весна", "семестр 01.02.2025"} {
+		if _, err := parseCalendarPeriod(strings.Fields(args)); err == nil {
+			t.Errorf("%q: ожидалась ошибка", args)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"education/internal/models"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -89,3 +90,28 @@ func InvalidateScheduleCache(key string) {
 	defer ScheduleCache.Unlock()
 	delete(ScheduleCache.entries, key)
 }
+
+// ClearScheduleCache сбрасывает кеш расписания целиком.
+func ClearScheduleCache() {
+	ScheduleCache.Lock()
+	defer ScheduleCache.Unlock()
+	ScheduleCache.entries = make(map[string]CacheEntry)
+}
+
+// WarmUpCache заранее загружает факультеты и группы в кэш,
+// чтобы первые пользователи не ждали запросов к БД.
+func WarmUpCache() error {
+	facs, err := GetAllFaculties()
+	if err != nil {
+		return fmt.Errorf("WarmUpCache: %w", err)
+	}
+	for _, f := range facs {
+		groups, err := GetGroupsByFaculty(f)
+		if err != nil {
+			return fmt.Errorf("WarmUpCache: %w", err)
+		}
+		SetGroups(f, groups)
+	}
+	SetFaculties(facs)
+	return nil
+}
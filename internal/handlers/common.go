@@ -207,3 +207,38 @@ func GetAllCourses() ([]models.Course, error) {
 	}
 	return courses, rows.Err()
 }
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// wallClock переводит момент t в местное время и помечает его как UTC. Времена занятий хранятся
+// как «настенные» с меткой UTC (seed.go, ввод преподавателей), поэтому сравнивать с ними нужно так.
+func wallClock(t time.Time) time.Time {
+	l := t.Local()
+	return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), 0, time.UTC)
+}
+
+// wallClockNow — текущее время в том же виде, что и времена занятий.
+func wallClockNow() time.Time {
+	return wallClock(time.Now())
+}
+
+// runPeriodically вызывает fn сразу и затем каждые interval, пока не вызвана функция остановки.
+// now — реальный момент вызова; задачи, сравнивающие его с расписанием, переводят его через wallClock.
+func runPeriodically(interval time.Duration, fn func(now time.Time)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			fn(time.Now())
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxReportLen — запас до лимита Telegram в 4096 символов.
+const maxReportLen = 3800
+
+// isAdminChat сообщает, является ли чат административным (ADMIN_CHAT_ID).
+func isAdminChat(chatID int64) bool {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	return reporter.adminChatID != 0 && reporter.adminChatID == chatID
+}
+
+// ShowConflictReport отправляет администратору отчёт о пересечениях занятий.
+// args — необязательный диапазон "YYYY-MM-DD YYYY-MM-DD", по умолчанию 30 дней от сегодня.
+func ShowConflictReport(chatID int64, bot Messenger, args string) error {
+	start := truncateToDay(wallClockNow())
+	end := start.AddDate(0, 0, 30)
+	if fields := strings.Fields(args); len(fields) == 2 {
+		from, err1 := time.Parse("2006-01-02", fields[0])
+		to, err2 := time.Parse("2006-01-02", fields[1])
+		if err1 != nil || err2 != nil || to.Before(from) {
+			msg := tgbotapi.NewMessage(chatID, "Формат: /conflicts ГГГГ-ММ-ДД ГГГГ-ММ-ДД")
+			return sendAndTrackMessage(bot, msg)
+		}
+		start, end = from, to
+	}
+
+	overlaps, err := scheduling.FindOverlaps(start, end)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка проверки расписания: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	period := fmt.Sprintf("%s – %s", start.Format("02.01.2006"), end.Format("02.01.2006"))
+	if len(overlaps) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "✅ Пересечений в расписании нет ("+period+").")
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ Найдено пересечений: %d (%s)\n\n", len(overlaps), period))
+	for i, o := range overlaps {
+		line := o.String() + "\n\n"
+		if sb.Len()+len(line) > maxReportLen {
+			sb.WriteString(fmt.Sprintf("…и ещё %d", len(overlaps)-i))
+			break
+		}
+		sb.WriteString(line)
+	}
+	return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, sb.String()))
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	consultationDays      = 28 // на сколько дней вперёд показываются консультации
+	maxConsultationsShown = 20
+)
+
+var (
+	// Чаты преподавателей, от которых ждём параметры новой консультации
+	consultationInput   = make(map[int64]bool)
+	consultationInputMu sync.Mutex
+)
+
+func setConsultationInput(chatID int64, waiting bool) {
+	consultationInputMu.Lock()
+	defer consultationInputMu.Unlock()
+	if waiting {
+		consultationInput[chatID] = true
+	} else {
+		delete(consultationInput, chatID)
+	}
+}
+
+func isConsultationInput(chatID int64) bool {
+	consultationInputMu.Lock()
+	defer consultationInputMu.Unlock()
+	return consultationInput[chatID]
+}
+
+// consultationTime — дата и время консультации: "18.03 (Вторник) 15:00–16:00".
+func consultationTime(c models.Consultation) string {
+	return fmt.Sprintf("%s (%s) %s–%s", c.StartsAt.Format("02.01"), weekdayName(c.StartsAt.Weekday()),
+		c.StartsAt.Format("15:04"), c.End().Format("15:04"))
+}
+
+// ShowConsultations показывает преподавателю его предстоящие консультации,
+// студенту — консультации всех преподавателей, на которые можно записаться.
+func ShowConsultations(chatID int64, bot Messenger, user *models.User, now time.Time) error {
+	until := truncateToDay(now).AddDate(0, 0, consultationDays)
+	var list []models.Consultation
+	var err error
+	if user.Role == "teacher" {
+		list, err = scheduling.TeacherConsultations(user.RegistrationCode, now, until)
+	} else {
+		list, err = scheduling.UpcomingConsultations(now, until)
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения консультаций: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	booked := make(map[int64]bool)
+	if user.Role != "teacher" {
+		mine, err := scheduling.StudentConsultations(user.RegistrationCode, now, until)
+		if err != nil {
+			fmt.Println("Ошибка получения записей на консультации:", err)
+		}
+		for _, c := range mine {
+			booked[c.ID] = true
+		}
+	}
+	if len(list) > maxConsultationsShown {
+		list = list[:maxConsultationsShown]
+	}
+
+	var sb strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if user.Role == "teacher" {
+		sb.WriteString("🎓 <b>Мои консультации</b>\n\n")
+		if len(list) == 0 {
+			sb.WriteString("Предстоящих консультаций нет. Добавьте время, когда студенты могут прийти с вопросами.")
+		}
+		for _, c := range list {
+			sb.WriteString(fmt.Sprintf("📅 %s, %s — записано %d из %d\n",
+				consultationTime(c), html.EscapeString(c.Place), c.Booked, c.Capacity))
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%s (%d/%d)", c.StartsAt.Format("02.01 15:04"), c.Booked, c.Capacity), fmt.Sprintf("cons_%d", c.ID))))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ Добавить консультацию", "cons_add"),
+		))
+	} else {
+		sb.WriteString("🎓 <b>Консультации</b>\n\n")
+		if len(list) == 0 {
+			sb.WriteString(fmt.Sprintf("В ближайшие %d дней консультаций нет.", consultationDays))
+		}
+		for _, c := range list {
+			mark := "▫️"
+			if booked[c.ID] {
+				mark = "✅"
+			}
+			sb.WriteString(fmt.Sprintf("%s %s — %s, %s, свободно мест: %d\n", mark, consultationTime(c),
+				html.EscapeString(c.TeacherName), html.EscapeString(c.Place), c.FreePlaces()))
+			label := c.StartsAt.Format("02.01 15:04") + " " + c.TeacherName
+			if booked[c.ID] {
+				label = "✅ " + label
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("cons_%d", c.ID))))
+		}
+		if len(list) > 0 {
+			sb.WriteString("\n✅ — вы записаны. Откройте консультацию, чтобы записаться или отменить запись.")
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSuffix(sb.String(), "\n"))
+	msg.ParseMode = "HTML"
+	if len(rows) > 0 {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ShowConsultation показывает карточку консультации: преподавателю — кто записался,
+// студенту — кнопки записи и отмены.
+func ShowConsultation(chatID int64, bot Messenger, user *models.User, id int64, now time.Time) error {
+	c, err := scheduling.GetConsultation(id)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Консультация не найдена.")
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🎓 <b>Консультация</b>\n\n")
+	sb.WriteString(fmt.Sprintf("👨‍🏫 %s\n", html.EscapeString(c.TeacherName)))
+	sb.WriteString(fmt.Sprintf("📅 %s, %s–%s\n", c.StartsAt.Format("02.01.2006")+" ("+weekdayName(c.StartsAt.Weekday())+")",
+		c.StartsAt.Format("15:04"), c.End().Format("15:04")))
+	sb.WriteString(fmt.Sprintf("📍 %s\n", html.EscapeString(c.Place)))
+	sb.WriteString(fmt.Sprintf("👥 Записано: %d из %d\n", c.Booked, c.Capacity))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	switch {
+	case user.Role == "teacher" && user.RegistrationCode == c.TeacherRegCode:
+		bookings, err := scheduling.ConsultationBookings(id)
+		if err != nil {
+			fmt.Println("Ошибка получения записей на консультацию:", err)
+		}
+		if len(bookings) > 0 {
+			sb.WriteString("\n<b>Записались:</b>\n")
+		}
+		for i, b := range bookings {
+			sb.WriteString(fmt.Sprintf("%d. %s", i+1, html.EscapeString(b.StudentName)))
+			if b.GroupName != "" {
+				sb.WriteString(", " + html.EscapeString(b.GroupName))
+			}
+			sb.WriteString("\n")
+		}
+		if now.Before(c.StartsAt) {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🗑 Отменить консультацию", fmt.Sprintf("cons_del_%d", c.ID)),
+			))
+		}
+	case user.Role != "teacher":
+		booked, err := scheduling.IsBooked(id, user.RegistrationCode)
+		if err != nil {
+			fmt.Println("Ошибка проверки записи на консультацию:", err)
+		}
+		closes := c.StartsAt.Add(-scheduling.BookingCutoff)
+		switch {
+		case booked:
+			sb.WriteString("\n✅ Вы записаны.\n")
+			if now.Before(c.StartsAt) {
+				rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+					tgbotapi.NewInlineKeyboardButtonData("❌ Отменить запись", fmt.Sprintf("cons_unbook_%d", c.ID)),
+				))
+			}
+		case now.After(closes):
+			sb.WriteString("\nЗапись закрыта.\n")
+		case c.FreePlaces() == 0:
+			sb.WriteString("\nСвободных мест нет.\n")
+		default:
+			sb.WriteString(fmt.Sprintf("\nЗапись открыта до %s.\n", closes.Format("02.01 15:04")))
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📝 Записаться", fmt.Sprintf("cons_book_%d", c.ID)),
+			))
+		}
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ К консультациям", "menu_consult"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSuffix(sb.String(), "\n"))
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// consultationsDayNote — консультации дня для дневного расписания: преподавателю — его
+// консультации, студенту — те, на которые он записан. Пустая строка, если консультаций нет.
+func consultationsDayNote(user *models.User, day time.Time) string {
+	dayStart := truncateToDay(day)
+	var list []models.Consultation
+	var err error
+	if user.Role == "teacher" {
+		list, err = scheduling.TeacherConsultations(user.RegistrationCode, dayStart, dayStart.AddDate(0, 0, 1))
+	} else {
+		list, err = scheduling.StudentConsultations(user.RegistrationCode, dayStart, dayStart.AddDate(0, 0, 1))
+	}
+	if err != nil {
+		fmt.Println("Ошибка получения консультаций дня:", err)
+		return ""
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n🎓 <b>Консультации</b>\n")
+	for _, c := range list {
+		sb.WriteString(fmt.Sprintf("⏰ <b>%s - %s</b> ", c.StartsAt.Format("15:04"), c.End().Format("15:04")))
+		if user.Role == "teacher" {
+			sb.WriteString(fmt.Sprintf("📍 %s — записано %d из %d\n", html.EscapeString(c.Place), c.Booked, c.Capacity))
+		} else {
+			sb.WriteString(fmt.Sprintf("👨‍🏫 %s, 📍 %s\n", html.EscapeString(c.TeacherName), html.EscapeString(c.Place)))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// parseConsultationInput разбирает "ДД.ММ.ГГГГ ЧЧ:ММ минуты мест место".
+func parseConsultationInput(text string) (models.Consultation, error) {
+	var c models.Consultation
+	fields := strings.Fields(text)
+	if len(fields) < 5 {
+		return c, errors.New("нужны дата, время, длительность, число мест и место")
+	}
+	start, err := time.Parse("02.01.2006 15:04", fields[0]+" "+fields[1])
+	if err != nil {
+		return c, errors.New("не удалось разобрать дату и время")
+	}
+	minutes, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return c, errors.New("длительность — число минут")
+	}
+	capacity, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return c, errors.New("число мест — целое число")
+	}
+	c.StartsAt = start
+	c.Duration = minutes
+	c.Capacity = capacity
+	c.Place = strings.Join(fields[4:], " ")
+	return c, nil
+}
+
+// processConsultationMessage принимает параметры новой консультации от преподавателя.
+func processConsultationMessage(chatID int64, bot Messenger, user *models.User, text string, now time.Time) {
+	c, err := parseConsultationInput(text)
+	if err == nil {
+		c.TeacherRegCode = user.RegistrationCode
+		err = scheduling.AddConsultation(&c, now)
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+". Пример: 21.03.2025 15:00 60 5 ауд. 305")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	setConsultationInput(chatID, false)
+	sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "✅ Консультация добавлена."))
+	ShowConsultations(chatID, bot, user, now)
+}
+
+// notifyConsultationCancelled предупреждает записавшихся, что консультацию отменили.
+func notifyConsultationCancelled(bot Messenger, c models.Consultation, bookings []models.ConsultationBooking) {
+	text := fmt.Sprintf("⚠️ Консультация %s (%s) отменена преподавателем.",
+		consultationTime(c), html.EscapeString(c.TeacherName))
+	for _, b := range bookings {
+		if b.TelegramID == 0 {
+			continue
+		}
+		msg := tgbotapi.NewMessage(b.TelegramID, text)
+		msg.ParseMode = "HTML"
+		if _, err := bot.SendMessage(msg); err != nil {
+			log.Printf("Не удалось отправить уведомление в чат %d: %v", b.TelegramID, err)
+		}
+	}
+}
+
+// bookingError — ответ на ошибку записи или отмены записи.
+func bookingError(err error) string {
+	switch {
+	case errors.Is(err, scheduling.ErrConsultationNotFound), errors.Is(err, scheduling.ErrConsultationFull),
+		errors.Is(err, scheduling.ErrAlreadyBooked), errors.Is(err, scheduling.ErrNotBooked),
+		errors.Is(err, scheduling.ErrBookingClosed):
+		return "⚠️ " + err.Error()
+	}
+	fmt.Println("Ошибка записи на консультацию:", err)
+	return "Ошибка записи на консультацию"
+}
+
+// ProcessConsultationCallback обрабатывает кнопки консультаций (menu_consult, cons_…).
+// Возвращает true, если callback обработан.
+func ProcessConsultationCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	if callback.Data != "menu_consult" && !strings.HasPrefix(callback.Data, "cons_") {
+		return false
+	}
+	handleConsultationCallback(callback, bot, user, wallClockNow())
+	return true
+}
+
+func handleConsultationCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User, now time.Time) {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	if data == "menu_consult" {
+		bot.AnswerCallback(callback.ID, "🎓 Консультации")
+		ShowConsultations(chatID, bot, user, now)
+		return
+	}
+	if data == "cons_add" {
+		if user.Role != "teacher" {
+			bot.AnswerCallback(callback.ID, "Доступно только преподавателям")
+			return
+		}
+		setConsultationInput(chatID, true)
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, "➕ Введите дату, время, длительность в минутах, число мест и место "+
+			"(аудиторию или ссылку на онлайн-встречу), например 21.03.2025 15:00 60 5 ауд. 305 (или /cancel):")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+
+	action, rest := "", strings.TrimPrefix(data, "cons_")
+	if i := strings.LastIndex(rest, "_"); i >= 0 {
+		action, rest = rest[:i], rest[i+1:]
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		bot.AnswerCallback(callback.ID, "Неизвестная консультация")
+		return
+	}
+
+	switch action {
+	case "":
+		bot.AnswerCallback(callback.ID, "")
+	case "book", "unbook":
+		if user.Role == "teacher" {
+			bot.AnswerCallback(callback.ID, "Записываются студенты")
+			return
+		}
+		if action == "book" {
+			err = scheduling.BookConsultation(id, user.RegistrationCode, now)
+		} else {
+			err = scheduling.CancelBooking(id, user.RegistrationCode, now)
+		}
+		switch {
+		case err != nil:
+			bot.AnswerCallback(callback.ID, bookingError(err))
+		case action == "book":
+			bot.AnswerCallback(callback.ID, "✅ Вы записаны")
+		default:
+			bot.AnswerCallback(callback.ID, "Запись отменена")
+		}
+	case "del":
+		if user.Role != "teacher" {
+			bot.AnswerCallback(callback.ID, "Доступно только преподавателям")
+			return
+		}
+		c, err := scheduling.GetConsultation(id)
+		if err == nil {
+			var bookings []models.ConsultationBooking
+			if bookings, err = scheduling.DeleteConsultation(id, user.RegistrationCode); err == nil {
+				bot.AnswerCallback(callback.ID, "🗑 Консультация отменена")
+				notifyConsultationCancelled(bot, c, bookings)
+				ShowConsultations(chatID, bot, user, now)
+				return
+			}
+		}
+		bot.AnswerCallback(callback.ID, bookingError(err))
+		return
+	default:
+		bot.AnswerCallback(callback.ID, "Неизвестное действие")
+		return
+	}
+	ShowConsultation(chatID, bot, user, id, now)
+}
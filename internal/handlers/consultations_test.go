@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/auth"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pressAt нажимает кнопку консультаций так, будто сейчас now: запись зависит от времени.
+func (c *conversation) pressAt(data string, now time.Time) {
+	c.t.Helper()
+	user, err := auth.GetUserByTelegramID(c.chatID)
+	if err != nil || user == nil {
+		c.t.Fatalf("пользователь чата %d не вошёл: %v", c.chatID, err)
+	}
+	cb := &tgbotapi.CallbackQuery{
+		ID:      "cb-" + data,
+		From:    &tgbotapi.User{ID: c.chatID},
+		Data:    data,
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: c.chatID, Type: "private"}},
+	}
+	handleConsultationCallback(cb, c.bot, user, now)
+}
+
+func TestConsultationBooking(t *testing.T) {
+	now := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	teacher := newConversation(t, 2004)
+	teacher.loggedIn("TH-0002", "teach123")
+
+	// Консультацию в прошлом не добавить; время ввода подменяем, чтобы 18.03.2025 было впереди
+	teacher.press("cons_add")
+	teacher.send("18.03.2025 15:00 60 2 ауд. 305")
+	user, _ := auth.GetUserByTelegramID(teacher.chatID)
+	processConsultationMessage(teacher.chatID, teacher.bot, user, "18.03.2025 15:00 60 2 ауд. 305", now)
+	teacher.golden("consultations_teacher")
+
+	student := &conversation{t: t, bot: teacher.bot, srv: teacher.srv, chatID: 1005}
+	student.loggedIn("ST-0002", "secret12")
+	student.pressAt("menu_consult", now)
+	student.pressAt("cons_1", now)
+	student.pressAt("cons_book_1", now)
+	student.pressAt("cons_book_1", now)
+	// Консультация видна в дневном расписании рядом с занятиями
+	student.press("day_2025-03-18")
+	student.golden("consultations_student")
+
+	teacher.pressAt("cons_1", now)
+	teacher.press("day_2025-03-18")
+	// Отмена консультации предупреждает записавшихся
+	teacher.pressAt("cons_del_1", now)
+	teacher.golden("consultations_cancel")
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"expvar"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/db"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrChatUndeliverable возвращается без обращения к Telegram, если чат помечен как недоступный.
+var ErrChatUndeliverable = errors.New("чат недоступен для доставки")
+
+const (
+	deliveryAttempts = 4 // всего попыток, включая первую
+	deliveryBaseWait = 500 * time.Millisecond
+	deliveryMaxWait  = 10 * time.Second // потолок экспоненциальной задержки
+	maxRetryAfter    = 60 * time.Second // дольше ждать не имеет смысла — воркер занят
+)
+
+var deliveryMetrics = expvar.NewMap("delivery")
+
+// errorKind — класс ошибки Telegram с точки зрения доставки.
+type errorKind int
+
+const (
+	errTransient errorKind = iota // стоит повторить: 429, 5xx, сетевые ошибки
+	errPermanent                  // чат больше недоступен: бот заблокирован, чат удалён
+	errRejected                   // запрос некорректен, повтор не поможет
+)
+
+// classifyError определяет класс ошибки и сколько ждать перед повтором (0 — на усмотрение вызывающего).
+func classifyError(err error) (errorKind, time.Duration) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// Сетевые ошибки и обрывы соединения
+		return errTransient, 0
+	}
+	desc := strings.ToLower(apiErr.Message)
+	switch {
+	case apiErr.Code == 429:
+		return errTransient, time.Duration(apiErr.RetryAfter) * time.Second
+	case apiErr.Code >= 500:
+		return errTransient, 0
+	case apiErr.Code == 403:
+		return errPermanent, 0
+	case apiErr.Code == 400 && (strings.Contains(desc, "chat not found") ||
+		strings.Contains(desc, "user is deactivated")):
+		return errPermanent, 0
+	}
+	return errRejected, 0
+}
+
+// retryMessenger повторяет временные сбои с джиттером и перестаёт писать в недоступные чаты.
+type retryMessenger struct {
+	next  Messenger
+	sleep func(time.Duration)
+}
+
+// NewRetryingMessenger оборачивает Messenger слоем повторов и учёта недоступных чатов.
+func NewRetryingMessenger(next Messenger) Messenger {
+	return &retryMessenger{next: next, sleep: time.Sleep}
+}
+
+// do выполняет вызов с повторами. chatID == 0 означает, что чат неизвестен и не учитывается.
+func (r *retryMessenger) do(chatID int64, call func() error) error {
+	if chatID != 0 && isChatUndeliverable(chatID) {
+		deliveryMetrics.Add("skipped", 1)
+		return ErrChatUndeliverable
+	}
+
+	var err error
+	for attempt := 0; attempt < deliveryAttempts; attempt++ {
+		if err = call(); err == nil {
+			return nil
+		}
+		kind, wait := classifyError(err)
+		switch kind {
+		case errPermanent:
+			if chatID != 0 {
+				markChatUndeliverable(chatID, err.Error())
+			}
+			return err
+		case errRejected:
+			return err
+		}
+		if attempt+1 == deliveryAttempts {
+			break
+		}
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		if wait > maxRetryAfter {
+			break
+		}
+		deliveryMetrics.Add("retries", 1)
+		r.sleep(wait)
+	}
+	deliveryMetrics.Add("failed", 1)
+	log.Printf("Не удалось доставить в чат %d после повторов: %v", chatID, err)
+	return err
+}
+
+// backoff возвращает экспоненциальную задержку с полным джиттером.
+func backoff(attempt int) time.Duration {
+	ceiling := deliveryBaseWait << uint(attempt)
+	if ceiling > deliveryMaxWait {
+		ceiling = deliveryMaxWait
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + time.Millisecond
+}
+
+func (r *retryMessenger) SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	var sent tgbotapi.Message
+	err := r.do(msg.ChatID, func() error {
+		var err error
+		sent, err = r.next.SendMessage(msg)
+		return err
+	})
+	return sent, err
+}
+
+func (r *retryMessenger) EditMessage(edit tgbotapi.Chattable) error {
+	return r.do(editChatID(edit), func() error { return r.next.EditMessage(edit) })
+}
+
+func (r *retryMessenger) DeleteMessage(chatID int64, messageID int) error {
+	return r.do(chatID, func() error { return r.next.DeleteMessage(chatID, messageID) })
+}
+
+func (r *retryMessenger) AnswerCallback(callbackID, text string) error {
+	return r.do(0, func() error { return r.next.AnswerCallback(callbackID, text) })
+}
+
+func (r *retryMessenger) AnswerInlineQuery(answer tgbotapi.InlineConfig) error {
+	return r.do(0, func() error { return r.next.AnswerInlineQuery(answer) })
+}
+
+func (r *retryMessenger) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	var sent tgbotapi.Message
+	err := r.do(doc.ChatID, func() error {
+		var err error
+		sent, err = r.next.SendDocument(doc)
+		return err
+	})
+	return sent, err
+}
+
+func (r *retryMessenger) DownloadFile(fileID string) ([]byte, error) {
+	var data []byte
+	err := r.do(0, func() error {
+		var err error
+		data, err = r.next.DownloadFile(fileID)
+		return err
+	})
+	return data, err
+}
+
+// editChatID достаёт чат из конфигурации редактирования (0 для inline-сообщений).
+func editChatID(edit tgbotapi.Chattable) int64 {
+	switch e := edit.(type) {
+	case tgbotapi.EditMessageTextConfig:
+		return e.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return e.ChatID
+	case tgbotapi.EditMessageCaptionConfig:
+		return e.ChatID
+	}
+	return 0
+}
+
+// undeliverable — кэш таблицы undeliverable_chats, загружается при первом обращении.
+var undeliverable = struct {
+	sync.Mutex
+	loaded bool
+	chats  map[int64]bool
+}{chats: make(map[int64]bool)}
+
+func loadUndeliverableLocked() {
+	if undeliverable.loaded || db.DB == nil {
+		return
+	}
+	rows, err := db.DB.Query(`SELECT chat_id FROM undeliverable_chats`)
+	if err != nil {
+		log.Printf("Ошибка загрузки недоступных чатов: %v", err)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			undeliverable.chats[id] = true
+		}
+	}
+	undeliverable.loaded = true
+}
+
+// isChatUndeliverable сообщает, помечен ли чат как недоступный.
+func isChatUndeliverable(chatID int64) bool {
+	undeliverable.Lock()
+	defer undeliverable.Unlock()
+	loadUndeliverableLocked()
+	return undeliverable.chats[chatID]
+}
+
+// markChatUndeliverable запоминает, что в чат больше нельзя писать.
+func markChatUndeliverable(chatID int64, reason string) {
+	undeliverable.Lock()
+	defer undeliverable.Unlock()
+	loadUndeliverableLocked()
+	undeliverable.chats[chatID] = true
+	deliveryMetrics.Add("undeliverable", 1)
+
+	_, err := db.DB.Exec(`
+		INSERT INTO undeliverable_chats (chat_id, reason, failed_at) VALUES (?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET reason = excluded.reason, failed_at = excluded.failed_at`,
+		chatID, reason, time.Now().UTC())
+	if err != nil {
+		log.Printf("Ошибка сохранения недоступного чата %d: %v", chatID, err)
+	}
+}
+
+// clearUndeliverable снимает отметку: пользователь снова написал боту, значит разблокировал его.
+func clearUndeliverable(chatID int64) {
+	undeliverable.Lock()
+	defer undeliverable.Unlock()
+	loadUndeliverableLocked()
+	if !undeliverable.chats[chatID] {
+		return
+	}
+	delete(undeliverable.chats, chatID)
+	if _, err := db.DB.Exec(`DELETE FROM undeliverable_chats WHERE chat_id = ?`, chatID); err != nil {
+		log.Printf("Ошибка снятия отметки недоступности чата %d: %v", chatID, err)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/tgtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newRetryingTestMessenger возвращает retryMessenger, который не спит, а записывает паузы.
+func newRetryingTestMessenger(t *testing.T) (*retryMessenger, *tgtest.Server, *[]time.Duration) {
+	t.Helper()
+	openTestDB(t)
+	resetHandlerState()
+	bot, srv := newTestMessenger(t)
+	var waits []time.Duration
+	return &retryMessenger{next: bot, sleep: func(d time.Duration) { waits = append(waits, d) }}, srv, &waits
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	bot, srv, waits := newRetryingTestMessenger(t)
+	srv.Fail("sendMessage", tgtest.Failure{Code: 429, Description: "Too Many Requests: retry after 3", RetryAfter: 3})
+	srv.Fail("sendMessage", tgtest.Failure{Code: 502, Description: "Bad Gateway"})
+
+	if _, err := bot.SendMessage(tgbotapi.NewMessage(5, "привет")); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if got := len(srv.CallsTo("sendMessage")); got != 3 {
+		t.Errorf("ожидалось 3 попытки, получено %d", got)
+	}
+	if len(*waits) != 2 || (*waits)[0] != 3*time.Second {
+		t.Errorf("паузы: %v", *waits)
+	}
+	if (*waits)[1] <= 0 || (*waits)[1] > 2*deliveryBaseWait {
+		t.Errorf("задержка после 5xx вне диапазона: %v", (*waits)[1])
+	}
+}
+
+func TestBlockedChatIsRecordedAndSkipped(t *testing.T) {
+	bot, srv, waits := newRetryingTestMessenger(t)
+	srv.Fail("sendMessage", tgtest.Failure{Code: 403, Description: "Forbidden: bot was blocked by the user"})
+
+	if _, err := bot.SendMessage(tgbotapi.NewMessage(6, "раз")); err == nil {
+		t.Fatal("ожидалась ошибка 403")
+	}
+	if _, err := bot.SendMessage(tgbotapi.NewMessage(6, "два")); err != ErrChatUndeliverable {
+		t.Fatalf("ожидалась ErrChatUndeliverable, получено %v", err)
+	}
+	if got := len(srv.CallsTo("sendMessage")); got != 1 || len(*waits) != 0 {
+		t.Errorf("постоянная ошибка не должна повторяться: вызовов %d, пауз %d", got, len(*waits))
+	}
+
+	var reason string
+	if err := db.DB.QueryRow(`SELECT reason FROM undeliverable_chats WHERE chat_id = 6`).Scan(&reason); err != nil {
+		t.Fatalf("чат не записан как недоступный: %v", err)
+	}
+
+	// Пользователь снова написал боту — отметка снимается
+	clearUndeliverable(6)
+	if _, err := bot.SendMessage(tgbotapi.NewMessage(6, "три")); err != nil {
+		t.Errorf("после разблокировки доставка должна работать: %v", err)
+	}
+}
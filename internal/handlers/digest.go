@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	weeklyDigestTime = "19:00"       // недельная сводка уходит в воскресенье вечером
+	digestGrace      = 3 * time.Hour // после простоя сводку ещё стоит отправить, позже — уже нет
+)
+
+// digestTimePresets — варианты времени ежедневной сводки; своё время можно ввести текстом.
+var digestTimePresets = []string{"06:30", "07:00", "07:30", "08:00", "09:00"}
+
+var (
+	// Чаты, от которых ждём ввода своего времени сводки
+	digestTimeInput   = make(map[int64]bool)
+	digestTimeInputMu sync.Mutex
+)
+
+func setDigestTimeInput(chatID int64, waiting bool) {
+	digestTimeInputMu.Lock()
+	defer digestTimeInputMu.Unlock()
+	if waiting {
+		digestTimeInput[chatID] = true
+	} else {
+		delete(digestTimeInput, chatID)
+	}
+}
+
+func isDigestTimeInput(chatID int64) bool {
+	digestTimeInputMu.Lock()
+	defer digestTimeInputMu.Unlock()
+	return digestTimeInput[chatID]
+}
+
+// DigestSettings — настройки сводок пользователя.
+type DigestSettings struct {
+	DailyTime string // HH:MM, пусто — ежедневная сводка выключена
+	Weekly    bool
+}
+
+// digestRecipient — вошедший пользователь с включённой сводкой.
+type digestRecipient struct {
+	User models.User
+	DigestSettings
+}
+
+// StartDigestScheduler запускает рассылку ежедневных и недельных сводок. Возвращает функцию остановки.
+func StartDigestScheduler(bot Messenger, interval time.Duration) (stop func()) {
+	// Время сводки пользователь задаёт по местным часам
+	return runPeriodically(interval, func(now time.Time) { sendDueDigests(bot, wallClock(now)) })
+}
+
+// sendDueDigests отправляет сводки, время которых наступило к моменту now.
+func sendDueDigests(bot Messenger, now time.Time) {
+	recipients, err := digestRecipients()
+	if err != nil {
+		log.Printf("Ошибка загрузки настроек сводок: %v", err)
+		return
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for _, r := range recipients {
+		if r.DailyTime != "" && digestDue(today, r.DailyTime, now) &&
+			claimDigest(r.User.RegistrationCode, "daily", today, now) {
+			if text, ok := dailyDigestText(r.User, today); ok {
+				sendDigest(bot, r.User.TelegramID, text, "mode_day")
+			}
+		}
+		if r.Weekly && now.Weekday() == time.Sunday && digestDue(today, weeklyDigestTime, now) {
+			weekStart := today.AddDate(0, 0, 1)
+			if claimDigest(r.User.RegistrationCode, "weekly", weekStart, now) {
+				if text, ok := weeklyDigestText(r.User, weekStart); ok {
+					sendDigest(bot, r.User.TelegramID, text, "week_next_"+weekStart.Format("2006-01-02"))
+				}
+			}
+		}
+	}
+}
+
+// digestDue сообщает, попадает ли now в окно отправки сводки, назначенной на hhmm дня day.
+func digestDue(day time.Time, hhmm string, now time.Time) bool {
+	hour, minute, err := scheduling.ParseStartTime(hhmm)
+	if err != nil {
+		return false
+	}
+	at := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	return !now.Before(at) && now.Before(at.Add(digestGrace))
+}
+
+// claimDigest отмечает сводку за период отправленной. false — её уже отправляли (в том числе до перезапуска).
+func claimDigest(regCode, kind string, period, now time.Time) bool {
+	res, err := db.DB.Exec(`
+		INSERT OR IGNORE INTO sent_digests (registration_code, kind, period, sent_at) VALUES (?, ?, ?, ?)`,
+		regCode, kind, period.Format("2006-01-02"), now.Format(time.RFC3339))
+	if err != nil {
+		log.Printf("Ошибка записи сводки: %v", err)
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// digestSchedules загружает занятия пользователя за период и применяет его фильтр расписания.
+func digestSchedules(user models.User, start, end time.Time) ([]models.Schedule, *ScheduleFilter, error) {
+	var schedules []models.Schedule
+	var err error
+	if user.Role == "teacher" {
+		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, start, end)
+	} else {
+		schedules, err = GetSchedulesForStudentByDateRange(&user, start, end)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := GetUserFilter(user.TelegramID)
+	return ApplyFilters(schedules, filter), filter, nil
+}
+
+// dailyDigestText формирует сводку на день. false — занятий нет, сводку не отправляем.
+func dailyDigestText(user models.User, day time.Time) (string, bool) {
+	schedules, filter, err := digestSchedules(user, day, day)
+	if err != nil {
+		log.Printf("Ошибка загрузки расписания для сводки (%s): %v", user.RegistrationCode, err)
+		return "", false
+	}
+	if len(schedules) == 0 {
+		return "", false
+	}
+	text := "📬 <b>Ваши занятия на сегодня</b>\n\n" + FormatEnhancedDaySchedule(schedules, day, user.Role)
+	return text + filterSummary(filter), true
+}
+
+// weeklyDigestText формирует сводку на неделю, начинающуюся с weekStart.
+func weeklyDigestText(user models.User, weekStart time.Time) (string, bool) {
+	weekEnd := weekStart.AddDate(0, 0, 6)
+	schedules, filter, err := digestSchedules(user, weekStart, weekEnd)
+	if err != nil {
+		log.Printf("Ошибка загрузки расписания для сводки (%s): %v", user.RegistrationCode, err)
+		return "", false
+	}
+	if len(schedules) == 0 {
+		return "", false
+	}
+	text := "🗓 <b>Расписание на предстоящую неделю</b>\n\n" +
+		FormatSchedulesByWeek(schedules, weekStart, weekEnd, user.Role, &user)
+	return text + filterSummary(filter), true
+}
+
+// filterSummary описывает активные фильтры расписания (пусто, если фильтров нет).
+func filterSummary(filter *ScheduleFilter) string {
+	if filter == nil || (filter.CourseName == "" && filter.LessonType == "") {
+		return ""
+	}
+	text := "\n\n<b>📌 Активные фильтры:</b>\n"
+	if filter.CourseName != "" {
+		text += fmt.Sprintf("• Курс: <b>%s</b>\n", filter.CourseName)
+	}
+	if filter.LessonType != "" {
+		text += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", filter.LessonType)
+	}
+	return text
+}
+
+func sendDigest(bot Messenger, chatID int64, text, openData string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗓 Открыть расписание", openData),
+	))
+	if _, err := bot.SendMessage(msg); err != nil {
+		log.Printf("Не удалось отправить сводку в чат %d: %v", chatID, err)
+	}
+}
+
+// digestRecipients загружает вошедших пользователей, у которых включена хотя бы одна сводка.
+func digestRecipients() ([]digestRecipient, error) {
+	rows, err := db.DB.Query(`
+		SELECT u.telegram_id, u.role, u.name, COALESCE(u.group_name, ''), COALESCE(u.subgroup_id, 0), u.registration_code, d.daily_time, d.weekly
+		FROM digest_settings d
+		JOIN users u ON u.registration_code = d.registration_code
+		WHERE u.telegram_id <> 0 AND u.password <> '' AND (d.daily_time <> '' OR d.weekly = 1)
+		ORDER BY u.registration_code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []digestRecipient
+	for rows.Next() {
+		var r digestRecipient
+		if err := rows.Scan(&r.User.TelegramID, &r.User.Role, &r.User.Name, &r.User.Group, &r.User.SubgroupID,
+			&r.User.RegistrationCode, &r.DailyTime, &r.Weekly); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// GetDigestSettings возвращает настройки сводок пользователя (по умолчанию всё выключено).
+func GetDigestSettings(regCode string) (DigestSettings, error) {
+	var s DigestSettings
+	err := db.DB.QueryRow(`SELECT daily_time, weekly FROM digest_settings WHERE registration_code = ?`, regCode).
+		Scan(&s.DailyTime, &s.Weekly)
+	if err != nil && err != sql.ErrNoRows {
+		return s, err
+	}
+	return s, nil
+}
+
+// SaveDigestSettings сохраняет настройки сводок пользователя.
+func SaveDigestSettings(regCode string, s DigestSettings) error {
+	_, err := db.DB.Exec(`
+		INSERT INTO digest_settings (registration_code, daily_time, weekly) VALUES (?, ?, ?)
+		ON CONFLICT(registration_code) DO UPDATE SET daily_time = excluded.daily_time, weekly = excluded.weekly`,
+		regCode, s.DailyTime, s.Weekly)
+	return err
+}
+
+// ShowDigestSettings показывает настройки сводок и кнопки их изменения.
+func ShowDigestSettings(chatID int64, bot Messenger, user *models.User) error {
+	settings, err := GetDigestSettings(user.RegistrationCode)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка загрузки настроек сводки: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📬 <b>Сводка расписания</b>\n\n")
+	if settings.DailyTime == "" {
+		sb.WriteString("☀️ Ежедневная: <b>выключена</b>\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("☀️ Ежедневная: <b>в %s</b>\n", settings.DailyTime))
+	}
+	if settings.Weekly {
+		sb.WriteString(fmt.Sprintf("🗓 Недельная: <b>по воскресеньям в %s</b>\n", weeklyDigestTime))
+	} else {
+		sb.WriteString("🗓 Недельная: <b>выключена</b>\n")
+	}
+	sb.WriteString("\n<i>В дни без занятий сводка не приходит. Учитываются ваши фильтры расписания.</i>\n")
+	sb.WriteString("\nВо сколько присылать занятия на сегодня?")
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, t := range digestTimePresets {
+		label := t
+		if t == settings.DailyTime {
+			label = "✅ " + t
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "dig_time_"+strings.Replace(t, ":", "", 1)))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{row}
+
+	custom := "✏️ Своё время"
+	if settings.DailyTime != "" && !containsString(digestTimePresets, settings.DailyTime) {
+		custom = "✅ " + settings.DailyTime + " (своё)"
+	}
+	controls := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(custom, "dig_custom"))
+	if settings.DailyTime != "" {
+		controls = append(controls, tgbotapi.NewInlineKeyboardButtonData("🔕 Выключить", "dig_off"))
+	}
+	rows = append(rows, controls)
+
+	weekly := "🗓 Недельная сводка: выкл"
+	if settings.Weekly {
+		weekly = "✅ Недельная сводка: вкл"
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(weekly, "dig_weekly")))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessDigestCallback обрабатывает кнопки настроек сводки. Возвращает true, если callback обработан.
+func ProcessDigestCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	if data != "menu_digest" && !strings.HasPrefix(data, "dig_") {
+		return false
+	}
+
+	settings, err := GetDigestSettings(user.RegistrationCode)
+	if err != nil {
+		bot.AnswerCallback(callback.ID, "Ошибка загрузки настроек")
+		return true
+	}
+	answer := "Настройки сохранены"
+	switch {
+	case data == "menu_digest":
+		answer = "📬 Сводка"
+	case data == "dig_custom":
+		setDigestTimeInput(chatID, true)
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, "Введите время сводки в формате ЧЧ:ММ, например 07:45 (или /cancel):")
+		sendAndTrackMessage(bot, msg)
+		return true
+	case data == "dig_off":
+		settings.DailyTime = ""
+		answer = "Ежедневная сводка выключена"
+	case data == "dig_weekly":
+		settings.Weekly = !settings.Weekly
+	case strings.HasPrefix(data, "dig_time_"):
+		raw := strings.TrimPrefix(data, "dig_time_")
+		if len(raw) != 4 || !containsString(digestTimePresets, raw[:2]+":"+raw[2:]) {
+			bot.AnswerCallback(callback.ID, "Некорректное время")
+			return true
+		}
+		settings.DailyTime = raw[:2] + ":" + raw[2:]
+	default:
+		bot.AnswerCallback(callback.ID, "")
+		return true
+	}
+
+	if data != "menu_digest" {
+		if err := SaveDigestSettings(user.RegistrationCode, settings); err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка сохранения настроек")
+			return true
+		}
+	}
+	bot.AnswerCallback(callback.ID, answer)
+	if err := ShowDigestSettings(chatID, bot, user); err != nil {
+		fmt.Println("Ошибка отображения настроек сводки:", err)
+	}
+	return true
+}
+
+// processDigestTimeMessage принимает своё время ежедневной сводки.
+func processDigestTimeMessage(chatID int64, bot Messenger, user *models.User, text string) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Не удалось распознать время. Введите в формате ЧЧ:ММ, например 07:45:")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	settings, err := GetDigestSettings(user.RegistrationCode)
+	if err == nil {
+		settings.DailyTime = t.Format("15:04")
+		err = SaveDigestSettings(user.RegistrationCode, settings)
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка сохранения настроек: "+err.Error())
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	setDigestTimeInput(chatID, false)
+	ShowDigestSettings(chatID, bot, user)
+}
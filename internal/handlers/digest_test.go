@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestSettingsAndDelivery(t *testing.T) {
+	c := newConversation(t, 1008)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("menu_digest")
+	c.press("dig_time_0700")
+	c.press("dig_weekly")
+	c.press("dig_custom")
+	c.send("7:45")
+	c.golden("digest_settings")
+
+	at := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", s)
+		return t
+	}
+	sent := func() []string {
+		var texts []string
+		for _, call := range c.srv.CallsTo("sendMessage") {
+			texts = append(texts, call.Text())
+		}
+		c.srv.Reset()
+		return texts
+	}
+
+	sendDueDigests(c.bot, at("2025-03-17 07:40"))
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("сводка раньше времени: %q", got)
+	}
+	sendDueDigests(c.bot, at("2025-03-17 07:50"))
+	got := sent()
+	if len(got) != 1 || !strings.Contains(got[0], "Ваши занятия на сегодня") || !strings.Contains(got[0], "Пределы") {
+		t.Fatalf("ожидалась сводка на 17.03: %q", got)
+	}
+	sendDueDigests(c.bot, at("2025-03-17 08:30"))
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("сводка продублирована: %q", got)
+	}
+
+	// В день без занятий (с учётом фильтра) сводка не приходит
+	SetUserFilter(1008, &ScheduleFilter{LessonType: "Лекция"})
+	sendDueDigests(c.bot, at("2025-03-18 07:50"))
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("сводка в день без подходящих занятий: %q", got)
+	}
+
+	// Воскресным вечером — сводка на предстоящую неделю
+	sendDueDigests(c.bot, at("2025-03-23 19:05"))
+	got = sent()
+	if len(got) != 1 || !strings.Contains(got[0], "Неделя 24.03.2025 – 30.03.2025") ||
+		!strings.Contains(got[0], "Интегралы") || !strings.Contains(got[0], "Тип занятия: <b>Лекция</b>") {
+		t.Fatalf("ожидалась недельная сводка с фильтром: %q", got)
+	}
+}
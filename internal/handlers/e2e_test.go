@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"education/internal/db"
+	"education/internal/ratelimit"
+	"education/internal/scheduling"
+	"education/internal/tgtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var updateGolden = flag.Bool("update", false, "перезаписать golden-файлы в testdata")
+
+const (
+	testFaculty = "Факультет Информатики"
+	testGroup   = "АА-23-01"
+)
+
+// conversation — сценарий общения одного чата с ботом через фейковый Bot API.
+type conversation struct {
+	t      *testing.T
+	bot    Messenger
+	srv    *tgtest.Server
+	chatID int64
+}
+
+// newConversation поднимает чистую in-memory БД с фикстурами и фейковый Telegram.
+func newConversation(t *testing.T, chatID int64) *conversation {
+	t.Helper()
+	openTestDB(t)
+	resetHandlerState()
+	bot, srv := newTestMessenger(t)
+	return &conversation{t: t, bot: bot, srv: srv, chatID: chatID}
+}
+
+// openTestDB открывает отдельную in-memory БД на тест и заполняет её фикстурами.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db.Open(dsn)
+	t.Cleanup(func() { db.DB.Close() })
+	for _, q := range fixtures {
+		if _, err := db.DB.Exec(q); err != nil {
+			t.Fatalf("fixture %q: %v", q, err)
+		}
+	}
+	if err := scheduling.ReloadCalendar(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resetHandlerState очищает глобальные состояния пакета между тестами.
+func resetHandlerState() {
+	userStates = make(map[int64]string)
+	userTempDataMap = make(map[int64]*tempUserData)
+	loginStates = make(map[int64]string)
+	loginTempDataMap = make(map[int64]*loginData)
+	greetedUsers = make(map[int64]bool)
+	chatMessages = make(map[int64][]int)
+	userFilters = make(map[int64]*ScheduleFilter)
+	materialPageState = make(map[int64]int)
+	materialFilterState = make(map[int64]string)
+	globalCache = &Cache{Groups: make(map[string][]string)}
+	ScheduleCache.entries = make(map[string]CacheEntry)
+	limiter = ratelimit.New(ratelimit.DefaultConfig())
+	undeliverable.loaded = false
+	undeliverable.chats = make(map[int64]bool)
+	lessonEdits = make(map[int64]*lessonEdit)
+	digestTimeInput = make(map[int64]bool)
+	exportRangeInput = make(map[int64]bool)
+	pendingImports = make(map[int64]*pendingImport)
+	roomSearchInput = make(map[int64]bool)
+	profileEditInput = make(map[int64]string)
+	consultationInput = make(map[int64]bool)
+	liveCards = make(map[int64]*liveCard)
+}
+
+// send имитирует текстовое сообщение (команды начинаются с "/").
+func (c *conversation) send(text string) {
+	msg := &tgbotapi.Message{
+		MessageID: 1,
+		Text:      text,
+		Chat:      &tgbotapi.Chat{ID: c.chatID, Type: "private"},
+		From:      &tgbotapi.User{ID: c.chatID},
+	}
+	if strings.HasPrefix(text, "/") {
+		cmdLen := len(strings.SplitN(text, " ", 2)[0])
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: cmdLen}}
+	}
+	ProcessMessage(&tgbotapi.Update{Message: msg}, c.bot)
+}
+
+// press имитирует нажатие inline-кнопки с указанными callback_data.
+func (c *conversation) press(data string) {
+	cb := &tgbotapi.CallbackQuery{
+		ID:      "cb-" + data,
+		From:    &tgbotapi.User{ID: c.chatID},
+		Data:    data,
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: c.chatID, Type: "private"}},
+	}
+	ProcessCallback(cb, c.bot)
+}
+
+// transcript выводит все исходящие вызовы в читаемом виде и очищает журнал.
+func (c *conversation) transcript() string {
+	var sb strings.Builder
+	for _, call := range c.srv.Calls() {
+		sb.WriteString("=== " + call.Method + "\n")
+		if text := call.Text(); text != "" {
+			sb.WriteString(text + "\n")
+		}
+		for _, row := range call.InlineKeyboard() {
+			var cells []string
+			for _, b := range row {
+				data := ""
+				if b.CallbackData != nil {
+					data = *b.CallbackData
+				}
+				cells = append(cells, fmt.Sprintf("[%s | %s]", b.Text, data))
+			}
+			sb.WriteString(strings.Join(cells, " ") + "\n")
+		}
+	}
+	c.srv.Reset()
+	return sb.String()
+}
+
+// golden сверяет накопленный транскрипт с testdata/<name>.golden.
+func (c *conversation) golden(name string) {
+	c.t.Helper()
+	c.goldenText(name, c.transcript())
+}
+
+// goldenText сравнивает произвольный текст с golden-файлом.
+func (c *conversation) goldenText(name, got string) {
+	c.t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			c.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			c.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		c.t.Fatalf("чтение %s: %v (запустите go test -update)", path, err)
+	}
+	if got != string(want) {
+		c.t.Errorf("транскрипт %s не совпадает с golden-файлом.\n--- получено ---\n%s\n--- ожидалось ---\n%s", name, got, want)
+	}
+}
+
+// fixtures — небольшой детерминированный набор данных вместо случайного SeedData.
+var fixtures = []string{
+	`INSERT INTO faculty_groups (faculty, group_name) VALUES ('Факультет Информатики', 'АА-23-01')`,
+	`INSERT INTO courses (id, name) VALUES (1, 'Матем'), (2, 'Прог')`,
+	`INSERT INTO users (telegram_id, role, name, faculty, group_name, password, registration_code) VALUES
+		(0, 'student', 'Иван Петров', 'Факультет Информатики', 'АА-23-01', '', 'ST-0001'),
+		(0, 'student', 'Анна Смирнова', 'Факультет Информатики', 'АА-23-01', 'secret12', 'ST-0002'),
+		(0, 'teacher', 'Ольга Волкова', 'Факультет Информатики', '', '', 'TH-0001'),
+		(0, 'teacher', 'Павел Козлов', 'Факультет Информатики', '', 'teach123', 'TH-0002')`,
+	`INSERT INTO teacher_course_groups (teacher_reg_code, course_id, group_name) VALUES
+		('TH-0001', 1, 'АА-23-01'),
+		('TH-0002', 2, 'АА-23-01')`,
+	`INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration) VALUES
+		(1, 'АА-23-01', 'TH-0001', '2025-03-17T08:00:00Z', 'Пределы', '101', 'Лекция', 90),
+		(2, 'АА-23-01', 'TH-0002', '2025-03-17T09:45:00Z', 'Циклы', '201', 'Практика', 90),
+		(1, 'АА-23-01', 'TH-0001', '2025-03-18T11:45:00Z', 'Производные', '102', 'Семинар', 90),
+		(2, 'АА-23-01', 'TH-0002', '2025-03-19T08:00:00Z', 'Рекурсия', '202', 'Лекция', 90),
+		(1, 'АА-23-01', 'TH-0001', '2025-03-24T08:00:00Z', 'Интегралы', '101', 'Лекция', 90)`,
+	`INSERT INTO materials (course_id, group_name, teacher_reg_code, title, file_url, description) VALUES
+		(1, 'АА-23-01', 'TH-0001', 'Конспект 1', 'https://example.com/1', 'Введение'),
+		(1, 'АА-23-01', 'TH-0001', 'Конспект 2', 'https://example.com/2', 'Пределы'),
+		(2, 'АА-23-01', 'TH-0002', 'Задачи 1', 'https://example.com/3', 'Циклы'),
+		(2, 'АА-23-01', 'TH-0002', 'Задачи 2', 'https://example.com/4', 'Рекурсия'),
+		(1, 'АА-23-01', 'TH-0001', 'Конспект 3', 'https://example.com/5', 'Производные'),
+		(2, 'АА-23-01', 'TH-0002', 'Задачи 3', 'https://example.com/6', 'Сортировки'),
+		(1, 'АА-23-01', 'TH-0001', 'Конспект 4', 'https://example.com/7', 'Интегралы')`,
+}
+
+func TestStudentRegistration(t *testing.T) {
+	c := newConversation(t, 1001)
+	c.send("/start")
+	c.press("menu_register")
+	c.press("role_student")
+	c.press(testFaculty)
+	c.press(testGroup)
+	c.send("ST-001")
+	c.send("ST-0001")
+	c.send("123")
+	c.send("secret12")
+	c.golden("student_registration")
+}
+
+func TestTeacherRegistration(t *testing.T) {
+	c := newConversation(t, 2001)
+	c.send("/start")
+	c.press("menu_register")
+	c.press("role_teacher")
+	c.press(testFaculty)
+	c.send("TH-0002")
+	c.send("TH-0001")
+	c.send("password1")
+	c.golden("teacher_registration")
+}
+
+func TestLoginFailures(t *testing.T) {
+	c := newConversation(t, 1002)
+	c.press("menu_login")
+	c.send("XX-1")
+	c.send("ST-9999")
+	c.send("whatever")
+	c.send("/cancel")
+	c.press("menu_login")
+	c.send("ST-0002")
+	c.send("wrongpass")
+	c.send("secret12")
+	c.golden("login_failures")
+}
+
+// loggedIn выполняет вход и очищает транскрипт, чтобы сценарий начинался с чистого листа.
+func (c *conversation) loggedIn(regCode, password string) {
+	c.press("menu_login")
+	c.send(regCode)
+	c.send(password)
+	c.srv.Reset()
+}
+
+func TestStudentWeekAndDayNavigation(t *testing.T) {
+	c := newConversation(t, 1003)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("week_next_2025-03-17")
+	c.press("week_next_2025-03-24")
+	c.press("week_prev_2025-03-17")
+	c.press("day_2025-03-17")
+	c.press("day_2025-03-18")
+	c.press("day_2025-03-22")
+	c.golden("student_navigation")
+}
+
+func TestTeacherWeekView(t *testing.T) {
+	c := newConversation(t, 2002)
+	c.loggedIn("TH-0002", "teach123")
+	c.press("week_prev_2025-03-17")
+	c.press("day_2025-03-19")
+	c.golden("teacher_week")
+}
+
+func TestScheduleFilters(t *testing.T) {
+	c := newConversation(t, 1004)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("filter_menu")
+	c.press("filter_course_menu")
+	c.press("filter_course_1_Матем")
+	c.press("week_next_2025-03-17")
+	c.press("filter_course_reset")
+	c.press("filter_lesson_type_menu")
+	c.press("filter_lesson_type_Лекция")
+	c.press("week_next_2025-03-17")
+	c.press("filter_reset_all")
+	c.press("day_2025-03-17")
+	c.golden("schedule_filters")
+}
+
+func TestMaterialPagination(t *testing.T) {
+	c := newConversation(t, 1005)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("menu_materials")
+	c.press("mat_page_2")
+	c.press("mat_page_3")
+	c.press("mat_filter")
+	c.press("mat_filter_set_2")
+	c.golden("material_pagination")
+}
+
+func TestMonthCalendar(t *testing.T) {
+	c := newConversation(t, 1006)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("month_2025-03")
+	c.press("month_2025-04")
+	c.press("filter_lesson_type_Лекция")
+	c.press("month_2025-03")
+	c.press("day_2025-03-19")
+	c.golden("month_calendar")
+}
+
+func TestTeacherEditsLessons(t *testing.T) {
+	c := newConversation(t, 2003)
+	c.loggedIn("TH-0002", "teach123")
+	SetCachedSchedule(testGroup, nil)
+	SetCachedSchedule("TH-0002", nil)
+
+	// Чужое занятие редактировать нельзя
+	c.press("edit_l_s1")
+
+	// Перенос: сначала на занятое время, затем на свободное, плюс новая аудитория и тип
+	c.press("edit_l_s4")
+	c.press("edit_time")
+	c.send("18.03.2025 11:45")
+	c.press("edit_save")
+	c.press("edit_card")
+	c.press("edit_time")
+	c.send("18.03.2025 14:00")
+	c.press("edit_room")
+	c.send("305")
+	c.press("edit_type")
+	c.press("edit_settype_Семинар")
+	c.press("edit_save")
+
+	// Отмена занятия
+	c.press("edit_l_s2")
+	c.press("edit_cancel")
+	c.press("edit_cancel_yes")
+
+	// Новое занятие
+	c.press("edit_add_2025-03-20")
+	c.press("edit_pair_0")
+	c.send("10:00")
+	c.press("edit_save")
+	c.golden("teacher_edit")
+
+	if _, ok := GetCachedSchedule(testGroup); ok {
+		t.Error("кеш расписания группы должен быть сброшен")
+	}
+	if _, ok := GetCachedSchedule("TH-0002"); ok {
+		t.Error("кеш расписания преподавателя должен быть сброшен")
+	}
+}
+
+func TestLessonFreeTextIsEscaped(t *testing.T) {
+	teacher := newConversation(t, 2008)
+	teacher.loggedIn("TH-0002", "teach123")
+	teacher.press("edit_l_s2")
+	teacher.press("edit_desc")
+	teacher.send("Циклы <for> & <while>")
+	teacher.press("edit_room")
+	teacher.send("Лаб. <3>")
+	teacher.press("edit_save")
+	teacher.transcript()
+
+	// День и неделя студента уходят в HTML-режиме: разметка из описания не должна их ломать
+	student := &conversation{t: t, bot: teacher.bot, srv: teacher.srv, chatID: 1012}
+	student.loggedIn("ST-0002", "secret12")
+	student.press("day_2025-03-17")
+	student.press("week_next_2025-03-17")
+	student.golden("lesson_free_text_escaped")
+}
+
+func TestStudentCannotEditLessons(t *testing.T) {
+	c := newConversation(t, 1007)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("edit_l_s4")
+	calls := c.srv.CallsTo("answerCallbackQuery")
+	if len(calls) != 1 || !strings.Contains(calls[0].Params.Get("text"), "только преподавателям") {
+		t.Fatalf("ожидался отказ, получено %+v", calls)
+	}
+}
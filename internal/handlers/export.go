@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/ical"
+	"education/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	exportDateLayout = "20060102"
+	maxExportDays    = 366 // больше года за раз не выгружаем
+)
+
+var (
+	// Чаты, от которых ждём ввода своего периода выгрузки
+	exportRangeInput   = make(map[int64]bool)
+	exportRangeInputMu sync.Mutex
+)
+
+func setExportRangeInput(chatID int64, waiting bool) {
+	exportRangeInputMu.Lock()
+	defer exportRangeInputMu.Unlock()
+	if waiting {
+		exportRangeInput[chatID] = true
+	} else {
+		delete(exportRangeInput, chatID)
+	}
+}
+
+func isExportRangeInput(chatID int64) bool {
+	exportRangeInputMu.Lock()
+	defer exportRangeInputMu.Unlock()
+	return exportRangeInput[chatID]
+}
+
+// lessonUID возвращает постоянный UID события. Занятие по правилу и его разовая замена
+// получают один и тот же UID, чтобы перенос обновлял событие в календаре, а не дублировал его.
+func lessonUID(s models.Schedule) string {
+	if s.RuleID != 0 {
+		day := s.RuleDate
+		if day.IsZero() {
+			day = s.ScheduleTime
+		}
+		return fmt.Sprintf("rule-%d-%s@education-bot", s.RuleID, day.Format(exportDateLayout))
+	}
+	return fmt.Sprintf("lesson-%d@education-bot", s.ID)
+}
+
+// BuildCalendar переводит занятия в календарь: одно событие на занятие.
+func BuildCalendar(name string, schedules []models.Schedule, courseNames map[int64]string, role string) ical.Calendar {
+	cal := ical.Calendar{Name: name}
+	for _, s := range schedules {
+		course := courseNames[s.CourseID]
+		if course == "" {
+			course = "Занятие"
+		}
+		summary := course
+		if s.LessonType != "" {
+			summary += " — " + s.LessonType
+		}
+
+		// Описание уже содержит название курса в начале — оно есть в SUMMARY
+		var details []string
+		if d := strings.TrimPrefix(s.Description, course+": "); d != "" {
+			details = append(details, d)
+		}
+		if role == "teacher" {
+			details = append(details, "Группа: "+s.Audience())
+		} else if s.TeacherRegCode != "" {
+			details = append(details, "Преподаватель: "+teacherName(s))
+		}
+		if role != "teacher" && s.SubgroupName != "" {
+			details = append(details, "Подгруппа: "+s.SubgroupName)
+		}
+
+		location := s.Auditory
+		if location != "" {
+			location = "Ауд. " + location
+		}
+		cal.Events = append(cal.Events, ical.Event{
+			UID:         lessonUID(s),
+			Start:       s.ScheduleTime,
+			End:         s.ScheduleTime.Add(time.Duration(s.Duration) * time.Minute),
+			Summary:     summary,
+			Location:    location,
+			Description: strings.Join(details, "\n"),
+		})
+	}
+	return cal
+}
+
+// exportRangeButton — кнопка выгрузки периода [start, end].
+func exportRangeButton(label string, start, end time.Time) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(label,
+		fmt.Sprintf("ics_%s_%s", start.Format(exportDateLayout), end.Format(exportDateLayout)))
+}
+
+// ShowExportMenu предлагает выбрать период для выгрузки расписания в календарь.
+func ShowExportMenu(chatID int64, bot Messenger, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	first := monthStart(today)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			exportRangeButton("Эта неделя", weekStart, weekStart.AddDate(0, 0, 6)),
+			exportRangeButton("Следующая неделя", weekStart.AddDate(0, 0, 7), weekStart.AddDate(0, 0, 13)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			exportRangeButton(monthNames[first.Month()-1], first, first.AddDate(0, 1, -1)),
+			exportRangeButton("Ближайшие 4 месяца", today, today.AddDate(0, 4, 0)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Свой период", "ics_custom"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔗 Подписка на календарь", "ical_feed"),
+		),
+	)
+	msg := tgbotapi.NewMessage(chatID, "📤 <b>Экспорт в календарь</b>\n\n"+
+		"Пришлю файл .ics — откройте его на телефоне или импортируйте в Google/Apple Календарь. "+
+		"Повторный импорт обновит занятия, а не создаст копии.\n\nЗа какой период выгрузить?")
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	return sendAndTrackMessage(bot, msg)
+}
+
+// SendScheduleExport отправляет файл .ics с занятиями пользователя за период [start, end].
+func SendScheduleExport(chatID int64, bot Messenger, user *models.User, start, end time.Time) error {
+	var schedules []models.Schedule
+	var err error
+	if user.Role == "teacher" {
+		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, start, end)
+	} else {
+		schedules, err = GetSchedulesForStudentByDateRange(user, start, end)
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка выгрузки расписания: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	if len(schedules) == 0 {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔍 С %s по %s занятий нет — выгружать нечего.",
+			start.Format("02.01.2006"), end.Format("02.01.2006")))
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	courseNames := make(map[int64]string)
+	if courses, err := GetAllCourses(); err == nil {
+		for _, c := range courses {
+			courseNames[c.ID] = c.Name
+		}
+	}
+	name := "Расписание " + user.Group
+	if user.Role == "teacher" {
+		name = "Расписание " + user.Name
+	}
+	data := BuildCalendar(name, schedules, courseNames, user.Role).Marshal(time.Now())
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("schedule_%s_%s.ics", start.Format(exportDateLayout), end.Format(exportDateLayout)),
+		Bytes: data,
+	})
+	doc.Caption = fmt.Sprintf("🗓 Занятий: %d (%s – %s)", len(schedules),
+		start.Format("02.01.2006"), end.Format("02.01.2006"))
+	_, err = bot.SendDocument(doc)
+	return err
+}
+
+// parseExportRange разбирает период: "20250317_20250323" из кнопки или "17.03.2025 23.03.2025" из сообщения.
+func parseExportRange(text string) (time.Time, time.Time, error) {
+	var parts []string
+	layout := exportDateLayout
+	if strings.Contains(text, "_") {
+		parts = strings.Split(text, "_")
+	} else {
+		parts = strings.Fields(strings.ReplaceAll(text, "-", " "))
+		layout = "02.01.2006"
+	}
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("нужны две даты")
+	}
+	start, err := time.Parse(layout, parts[0])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := time.Parse(layout, parts[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("конец периода раньше начала")
+	}
+	if end.Sub(start) > maxExportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("период больше %d дней", maxExportDays)
+	}
+	return start, end, nil
+}
+
+// ProcessExportCallback обрабатывает кнопки экспорта. Возвращает true, если callback обработан.
+func ProcessExportCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+
+	switch {
+	case data == "menu_export":
+		bot.AnswerCallback(callback.ID, "📤 Экспорт")
+		ShowExportMenu(chatID, bot, time.Now())
+	case data == "ics_custom":
+		setExportRangeInput(chatID, true)
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, "Введите период в формате ДД.ММ.ГГГГ ДД.ММ.ГГГГ, например 01.09.2025 31.12.2025 (или /cancel):")
+		sendAndTrackMessage(bot, msg)
+	case strings.HasPrefix(data, "ics_"):
+		start, end, err := parseExportRange(strings.TrimPrefix(data, "ics_"))
+		if err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "Готовлю файл…")
+		if err := SendScheduleExport(chatID, bot, user, start, end); err != nil {
+			fmt.Println("Ошибка отправки файла календаря:", err)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// processExportRangeMessage принимает свой период выгрузки.
+func processExportRangeMessage(chatID int64, bot Messenger, user *models.User, text string) {
+	start, end, err := parseExportRange(strings.TrimSpace(text))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Не удалось разобрать период ("+err.Error()+"). Пример: 01.09.2025 31.12.2025")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	setExportRangeInput(chatID, false)
+	if err := SendScheduleExport(chatID, bot, user, start, end); err != nil {
+		fmt.Println("Ошибка отправки файла календаря:", err)
+	}
+}
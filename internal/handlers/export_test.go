@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestExportCalendarUsesStableUIDs(t *testing.T) {
+	c := newConversation(t, 1009)
+	c.loggedIn("ST-0002", "secret12")
+
+	rule := &models.ScheduleRule{
+		CourseID: 2, GroupName: testGroup, TeacherRegCode: "TH-0002",
+		Weekday: time.Thursday, StartTime: "11:45", Duration: 90,
+		Description: "Структуры данных", Auditory: "301", LessonType: "Практика",
+		ValidFrom: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:   time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	if err := scheduling.CreateRule(rule, false); err != nil {
+		t.Fatal(err)
+	}
+
+	export := func() string {
+		t.Helper()
+		c.srv.Reset()
+		c.press("ics_20250317_20250323")
+		docs := c.srv.CallsTo("sendDocument")
+		if len(docs) != 1 {
+			t.Fatalf("ожидался один файл, отправлено %d", len(docs))
+		}
+		// Склеиваем перенесённые строки (RFC 5545, 3.1)
+		return strings.ReplaceAll(string(docs[0].Files["document"]), "\r\n ", "")
+	}
+
+	before := export()
+	if n := strings.Count(before, "BEGIN:VEVENT"); n != 5 {
+		t.Fatalf("ожидалось 5 событий (4 разовых + 1 по правилу), получено %d:\n%s", n, before)
+	}
+	for _, want := range []string{
+		"UID:lesson-1@education-bot",
+		"DTSTART:20250317T080000\r\nDTEND:20250317T093000",
+		"SUMMARY:Матем — Лекция",
+		"LOCATION:Ауд. 101",
+		`DESCRIPTION:Пределы\nПреподаватель: Ольга Волкова`,
+		"UID:rule-1-20250320@education-bot",
+	} {
+		if !strings.Contains(before, want) {
+			t.Errorf("в файле нет %q:\n%s", want, before)
+		}
+	}
+
+	// Перенос занятия по правилу сохраняет UID — календарь обновит событие
+	occ, err := scheduling.GetRuleOccurrence(rule.ID, time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	occ.ScheduleTime = time.Date(2025, 3, 21, 14, 0, 0, 0, time.UTC)
+	if err := scheduling.OverrideOccurrence(&occ, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	after := export()
+	if strings.Count(after, "UID:rule-1-20250320@education-bot") != 1 || !strings.Contains(after, "DTSTART:20250321T140000") {
+		t.Errorf("перенесённое занятие должно сохранить UID:\n%s", after)
+	}
+}
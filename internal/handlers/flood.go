@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"education/internal/ratelimit"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	limiter   = ratelimit.New(ratelimit.DefaultConfig())
+	limiterMu sync.RWMutex
+)
+
+// SetRateLimiter заменяет лимитер (например, настройками из окружения).
+func SetRateLimiter(l *ratelimit.Limiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	limiter = l
+}
+
+// actionClass относит апдейт к классу действий, у каждого класса свой лимит.
+func actionClass(update tgbotapi.Update) string {
+	if update.InlineQuery != nil {
+		return "inline"
+	}
+	if update.CallbackQuery == nil {
+		return "messages"
+	}
+	data := update.CallbackQuery.Data
+	switch {
+	case strings.HasPrefix(data, "mat_"):
+		return "materials"
+	case strings.HasPrefix(data, "filter_"):
+		return "filters"
+	case strings.HasPrefix(data, "week_"), strings.HasPrefix(data, "day_"),
+		strings.HasPrefix(data, "month_"), strings.HasPrefix(data, "mode_"), data == "show_timeline",
+		strings.HasPrefix(data, "ics_"):
+		return "schedule"
+	}
+	return "callbacks"
+}
+
+// allowUpdate проверяет лимиты до диспетчеризации. Возвращает false, если апдейт надо отбросить.
+func allowUpdate(update tgbotapi.Update, bot Messenger) bool {
+	chatID := updateChatID(update)
+	if chatID == 0 {
+		return true
+	}
+
+	limiterMu.RLock()
+	l := limiter
+	limiterMu.RUnlock()
+
+	decision := l.Allow(chatID, actionClass(update))
+	switch decision {
+	case ratelimit.Allowed:
+		return true
+	case ratelimit.BanStarted:
+		minutes := int(l.BanRemaining(chatID).Minutes() + 0.5)
+		msg := tgbotapi.NewMessage(chatID,
+			fmt.Sprintf("🚫 Слишком много запросов. Бот не будет отвечать вам %d мин.", minutes))
+		sendAndTrackMessage(bot, msg)
+	}
+
+	// Колбэк нужно закрыть, иначе у пользователя будет крутиться индикатор загрузки
+	if update.CallbackQuery != nil {
+		text := "⏳ Слишком быстро, подождите немного"
+		if decision != ratelimit.Limited {
+			text = "🚫 Временная блокировка за флуд"
+		}
+		bot.AnswerCallback(update.CallbackQuery.ID, text)
+	}
+	return false
+}
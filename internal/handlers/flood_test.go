@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestHandleUpdateThrottlesScheduleSpam(t *testing.T) {
+	c := newConversation(t, 1011)
+	for i := 0; i < 8; i++ {
+		HandleUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:      "cb",
+			From:    &tgbotapi.User{ID: c.chatID},
+			Data:    "week_today",
+			Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: c.chatID}},
+		}}, c.bot)
+	}
+
+	// Лимит расписания по умолчанию — 5 запросов подряд, остальные получают только всплывашку
+	if got := len(c.srv.CallsTo("sendMessage")); got != 5 {
+		t.Errorf("ожидалось 5 обработанных запросов, получено %d", got)
+	}
+	var throttled int
+	for _, call := range c.srv.CallsTo("answerCallbackQuery") {
+		if call.Params.Get("text") == "⏳ Слишком быстро, подождите немного" {
+			throttled++
+		}
+	}
+	if throttled != 3 {
+		t.Errorf("ожидалось 3 отказа по лимиту, получено %d", throttled)
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/auth"
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxFreeSlotDays — самый длинный период поиска общих окон, дней.
+const maxFreeSlotDays = 31
+
+const freeSlotsHelp = "Общие свободные окна преподавателя и групп:\n" +
+	"/free АА-23-01 — на неделю вперёд, окна от 90 минут\n" +
+	"/free АА-23-01 ББ-23-01 17.03.2025 21.03.2025 60 — несколько групп, период и длина окна в минутах\n" +
+	"/free TH-0001 АА-23-01 17.03.2025 — для другого преподавателя (в админ-чате преподаватель обязателен)"
+
+var (
+	// Рабочее время, в пределах которого ищутся свободные окна
+	workingHours   = scheduling.DefaultWorkingHours
+	workingHoursMu sync.RWMutex
+)
+
+// SetWorkingHours задаёт рабочее время для поиска общих свободных окон.
+func SetWorkingHours(h scheduling.WorkingHours) {
+	workingHoursMu.Lock()
+	defer workingHoursMu.Unlock()
+	workingHours = h
+}
+
+func getWorkingHours() scheduling.WorkingHours {
+	workingHoursMu.RLock()
+	defer workingHoursMu.RUnlock()
+	return workingHours
+}
+
+// freeSlotsQuery — разобранные аргументы команды /free.
+type freeSlotsQuery struct {
+	Teacher  string
+	Groups   []string
+	From, To time.Time
+	Minutes  int
+}
+
+// groupExists сообщает, есть ли группа в справочнике факультетов.
+func groupExists(group string) bool {
+	var n int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM faculty_groups WHERE group_name = ?`, group).Scan(&n); err != nil {
+		fmt.Println("Ошибка проверки группы:", err)
+		return false
+	}
+	return n > 0
+}
+
+// parseFreeSlotsArgs разбирает "[преподаватель] группа… [ДД.ММ.ГГГГ [ДД.ММ.ГГГГ]] [минуты]".
+// Преподаватель узнаётся по коду из teachers, по умолчанию — defaultTeacher.
+func parseFreeSlotsArgs(args, defaultTeacher string, teachers map[string]string, now time.Time) (freeSlotsQuery, error) {
+	q := freeSlotsQuery{Teacher: defaultTeacher, Minutes: defaultLessonDuration}
+	var dates []time.Time
+	for _, f := range strings.Fields(args) {
+		if d, err := time.Parse("02.01.2006", f); err == nil {
+			if len(dates) == 2 {
+				return q, errors.New("укажите не больше двух дат")
+			}
+			dates = append(dates, d)
+			continue
+		}
+		if m, err := strconv.Atoi(f); err == nil {
+			if m <= 0 || m > maxRoomSlotLen {
+				return q, fmt.Errorf("длина окна должна быть от 1 до %d минут", maxRoomSlotLen)
+			}
+			q.Minutes = m
+			continue
+		}
+		if _, ok := teachers[f]; ok {
+			q.Teacher = f
+			continue
+		}
+		if !groupExists(f) {
+			return q, fmt.Errorf("группа «%s» не найдена", f)
+		}
+		q.Groups = append(q.Groups, f)
+	}
+
+	switch {
+	case q.Teacher == "":
+		return q, errors.New("укажите код преподавателя")
+	case len(q.Groups) == 0:
+		return q, errors.New("укажите хотя бы одну группу")
+	}
+	switch len(dates) {
+	case 0:
+		q.From = truncateToDay(now)
+		q.To = q.From.AddDate(0, 0, 6)
+	case 1:
+		q.From, q.To = dates[0], dates[0]
+	default:
+		q.From, q.To = dates[0], dates[1]
+	}
+	if q.To.Before(q.From) {
+		return q, errors.New("конец периода раньше начала")
+	}
+	if q.To.Sub(q.From) >= maxFreeSlotDays*24*time.Hour {
+		return q, fmt.Errorf("период больше %d дней", maxFreeSlotDays)
+	}
+	return q, nil
+}
+
+// commonFreeSlots ищет окна, свободные и у преподавателя, и у всех групп запроса.
+// Занятие любой подгруппы занимает всю группу.
+func commonFreeSlots(q freeSlotsQuery) ([]scheduling.Slot, error) {
+	busy, err := GetSchedulesForTeacherByDateRange(q.Teacher, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range q.Groups {
+		lessons, err := GetSchedulesForGroupByDateRange(g, q.From, q.To)
+		if err != nil {
+			return nil, err
+		}
+		busy = append(busy, lessons...)
+	}
+	return scheduling.FreeSlots(busy, q.From, q.To, getWorkingHours(), time.Duration(q.Minutes)*time.Minute), nil
+}
+
+// ShowCommonFreeSlots отвечает на /free: общие свободные окна преподавателя и групп.
+// user — вошедший преподаватель (в админ-чате может быть nil).
+func ShowCommonFreeSlots(chatID int64, bot Messenger, user *models.User, args string, now time.Time) error {
+	names, err := auth.TeacherNames()
+	if err != nil {
+		fmt.Println("Ошибка получения имён преподавателей:", err)
+	}
+	var self string
+	if user != nil && user.Role == "teacher" {
+		self = user.RegistrationCode
+	}
+	q, err := parseFreeSlotsArgs(args, self, names, now)
+	if err != nil {
+		if strings.TrimSpace(args) == "" {
+			return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, freeSlotsHelp))
+		}
+		return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+"\n\n"+freeSlotsHelp))
+	}
+	slots, err := commonFreeSlots(q)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка поиска свободных окон: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	teacher := names[q.Teacher]
+	if teacher == "" {
+		teacher = q.Teacher
+	}
+	var sb strings.Builder
+	sb.WriteString("🕒 <b>Общие свободные окна</b>\n")
+	sb.WriteString("👤 " + html.EscapeString(teacher) + "\n")
+	sb.WriteString("👥 " + html.EscapeString(strings.Join(q.Groups, ", ")) + "\n")
+	if q.From.Equal(q.To) {
+		sb.WriteString("📅 " + q.From.Format("02.01.2006"))
+	} else {
+		sb.WriteString(fmt.Sprintf("📅 %s – %s", q.From.Format("02.01.2006"), q.To.Format("02.01.2006")))
+	}
+	sb.WriteString(fmt.Sprintf(", окна от %d мин, рабочее время %s\n\n", q.Minutes, getWorkingHours()))
+	if len(slots) == 0 {
+		sb.WriteString("Общих свободных окон нет.")
+	}
+
+	// Окна одного дня — одной строкой
+	for i := 0; i < len(slots); {
+		day := truncateToDay(slots[i].Start)
+		var windows []string
+		for ; i < len(slots) && truncateToDay(slots[i].Start).Equal(day); i++ {
+			windows = append(windows, slots[i].Start.Format("15:04")+"–"+slots[i].End.Format("15:04"))
+		}
+		line := fmt.Sprintf("<b>%s, %s</b>: %s\n", day.Format("02.01"), weekdayName(day.Weekday()), strings.Join(windows, ", "))
+		if sb.Len()+len(line) > maxReportLen {
+			sb.WriteString("…")
+			break
+		}
+		sb.WriteString(line)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSuffix(sb.String(), "\n"))
+	msg.ParseMode = "HTML"
+	return sendAndTrackMessage(bot, msg)
+}
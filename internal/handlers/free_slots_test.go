@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"education/internal/db"
+	"education/internal/scheduling"
+)
+
+func TestCommonFreeSlots(t *testing.T) {
+	c := newConversation(t, 2004)
+	if _, err := db.DB.Exec(`
+		INSERT INTO faculty_groups (faculty, group_name) VALUES ('Факультет Информатики', 'ББ-23-01');
+		INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration)
+			VALUES (1, 'ББ-23-01', 'TH-0001', '2025-03-18T14:00:00Z', 'Ряды', '103', 'Лекция', 90)`); err != nil {
+		t.Fatal(err)
+	}
+	SetWorkingHours(scheduling.WorkingHours{Start: 8 * 60, End: 18 * 60})
+	t.Cleanup(func() { SetWorkingHours(scheduling.DefaultWorkingHours) })
+	c.loggedIn("TH-0002", "teach123")
+
+	// Свой код подставляется сам; 17.03 у группы и преподавателя пары с 08:00 до 11:15
+	c.send("/free АА-23-01 ББ-23-01 17.03.2025 19.03.2025 120")
+	c.send("/free АА-23-01 18.03.2025 18.03.2025 45")
+	c.send("/free ВВ-00-00")
+	c.send("/free")
+	c.golden("free_slots_teacher")
+
+	admin := &conversation{t: t, bot: c.bot, srv: c.srv, chatID: 9002}
+	SetAdminChatID(9002)
+	t.Cleanup(func() { SetAdminChatID(0) })
+	admin.send("/free АА-23-01 17.03.2025")
+	admin.send("/free TH-0001 АА-23-01 17.03.2025")
+	admin.golden("free_slots_admin")
+
+	// Студентам команда недоступна
+	student := &conversation{t: t, bot: c.bot, srv: c.srv, chatID: 1005}
+	student.loggedIn("ST-0002", "secret12")
+	student.transcript()
+	student.send("/free АА-23-01")
+	if out := student.transcript(); strings.Contains(out, "свободные окна") {
+		t.Errorf("студент не должен искать окна:\n%s", out)
+	}
+}
@@ -22,11 +22,17 @@ var (
 // sendMessageAndTrack отправляет сообщение и сохраняет его MessageID в tempUserData или loginData
 
 // sendAndTrackMessage отправляет сообщение и сохраняет его MessageID в глобальном хранилище
-func sendAndTrackMessage(bot *tgbotapi.BotAPI, msg tgbotapi.MessageConfig) error {
-	sentMsg, err := bot.Send(msg)
+func sendAndTrackMessage(bot Messenger, msg tgbotapi.MessageConfig) error {
+	_, err := sendAndTrack(bot, msg)
+	return err
+}
+
+// sendAndTrack — то же, что sendAndTrackMessage, но возвращает отправленное сообщение.
+func sendAndTrack(bot Messenger, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	sentMsg, err := bot.SendMessage(msg)
 	if err != nil {
 		fmt.Println("Ошибка отправки сообщения:", err)
-		return err
+		return sentMsg, err
 	}
 
 	// Сохраняем MessageID в глобальном хранилище
@@ -34,12 +40,12 @@ func sendAndTrackMessage(bot *tgbotapi.BotAPI, msg tgbotapi.MessageConfig) error
 	chatMessages[msg.ChatID] = append(chatMessages[msg.ChatID], sentMsg.MessageID)
 	chatMessagesMu.Unlock()
 
-	return nil
+	return sentMsg, nil
 }
 
 // deleteMessages удаляет все сообщения, связанные с процессом
 // deleteMessages удаляет все сообщения, связанные с данным chatID
-func deleteMessages(chatID int64, bot *tgbotapi.BotAPI, delay time.Duration) {
+func deleteMessages(chatID int64, bot Messenger, delay time.Duration) {
 	chatMessagesMu.Lock()
 	defer chatMessagesMu.Unlock()
 
@@ -56,8 +62,7 @@ func deleteMessages(chatID int64, bot *tgbotapi.BotAPI, delay time.Duration) {
 
 	// Удаляем все сообщения
 	for _, msgID := range msgIDs {
-		delMsg := tgbotapi.NewDeleteMessage(chatID, msgID)
-		if _, err := bot.Request(delMsg); err != nil {
+		if err := bot.DeleteMessage(chatID, msgID); err != nil {
 			fmt.Println("Ошибка удаления сообщения:", err)
 		}
 	}
@@ -68,13 +73,20 @@ func deleteMessages(chatID int64, bot *tgbotapi.BotAPI, delay time.Duration) {
 
 // sendMainMenu формирует меню (Reply-кнопка «Главное меню» + Inline-кнопки),
 // с приветствием при первом вызове и коротким текстом при повторных вызовах.
-func sendMainMenu(chatID int64, bot *tgbotapi.BotAPI, user *models.User) {
+func sendMainMenu(chatID int64, bot Messenger, user *models.User) {
 	// Кнопка «Главное меню»
 	replyKeyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("🏠 Главное меню"),
 		),
 	)
+	if user != nil {
+		// Быстрый доступ к текущему и следующему занятию
+		replyKeyboard.Keyboard = append([][]tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(nowButton),
+			tgbotapi.NewKeyboardButton(nextButton),
+		)}, replyKeyboard.Keyboard...)
+	}
 	replyKeyboard.OneTimeKeyboard = false
 	replyKeyboard.ResizeKeyboard = true
 
@@ -96,8 +108,12 @@ func sendMainMenu(chatID int64, bot *tgbotapi.BotAPI, user *models.User) {
 				user.Name, user.Faculty, user.Role)
 		} else {
 			// Для студента
+			group := user.Group
+			if name := subgroupName(user); name != "" {
+				group += ", подгруппа " + name
+			}
 			firstMsgText = fmt.Sprintf("👤 Привет, %s!\n🏫 Факультет: %s\n📚 Группа: %s\n🔑 Роль: %s",
-				user.Name, user.Faculty, user.Group, user.Role)
+				user.Name, user.Faculty, group, user.Role)
 		}
 	} else {
 		firstMsgText = "🤖 Готов к работе! Выбирай действие ниже."
@@ -125,9 +141,29 @@ func sendMainMenu(chatID int64, bot *tgbotapi.BotAPI, user *models.User) {
 			// Новая кнопка для преподавателя
 			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("📋 Мои предметы и группы", "menu_teacher_courses"),
+				tgbotapi.NewInlineKeyboardButtonData("🏫 Аудитории", "menu_rooms"),
 			))
 
 		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔔 Напоминания", "menu_reminders"),
+			tgbotapi.NewInlineKeyboardButtonData("📬 Сводка", "menu_digest"),
+		))
+		if user.Role == "teacher" {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👤 Мой профиль", "menu_profile"),
+				tgbotapi.NewInlineKeyboardButtonData("🎓 Консультации", "menu_consult"),
+			))
+		} else if groupHasSubgroups(user.Group) {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👥 Подгруппа", "menu_subgroup"),
+				tgbotapi.NewInlineKeyboardButtonData("🎓 Консультации", "menu_consult"),
+			))
+		} else {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🎓 Консультации", "menu_consult"),
+			))
+		}
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🚪 Выход", "menu_logout"),
 		))
@@ -143,7 +179,7 @@ func sendMainMenu(chatID int64, bot *tgbotapi.BotAPI, user *models.User) {
 // или при желании тоже подкорректировать тексты сообщений.
 
 // ProcessMessage — обрабатывает входящие текстовые сообщения (включая нажатие «Главное меню»).
-func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
+func ProcessMessage(update *tgbotapi.Update, bot Messenger) {
 	if update.Message == nil {
 		return
 	}
@@ -153,6 +189,13 @@ func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
 	// Если пользователь нажал на кнопку «Главное меню» (ReplyKeyboard)
 	if text == "🏠 Главное меню" {
 		// Сбрасываем все активные процессы (регистрация, логин и т.д.)
+		setLessonEdit(chatID, nil)
+		setDigestTimeInput(chatID, false)
+		setExportRangeInput(chatID, false)
+		setPendingImport(chatID, nil)
+		setRoomSearchInput(chatID, false)
+		setProfileEditInput(chatID, "")
+		setConsultationInput(chatID, false)
 		if userStates[chatID] != "" {
 			delete(userStates, chatID)
 			delete(userTempDataMap, chatID)
@@ -168,9 +211,27 @@ func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
 		return
 	}
 
+	// Кнопки «Сейчас» и «Следующее» на reply-клавиатуре работают как /now и /next
+	if text == nowButton || text == nextButton {
+		user, _ := auth.GetUserByTelegramID(chatID)
+		mode := cardNow
+		if text == nextButton {
+			mode = cardNext
+		}
+		ShowLiveCard(chatID, bot, user, mode, wallClockNow())
+		return
+	}
+
 	// --- Проверка /cancel ---
 	if update.Message.IsCommand() && update.Message.Command() == "cancel" {
 		// Сбрасываем состояния
+		setLessonEdit(chatID, nil)
+		setDigestTimeInput(chatID, false)
+		setExportRangeInput(chatID, false)
+		setPendingImport(chatID, nil)
+		setRoomSearchInput(chatID, false)
+		setProfileEditInput(chatID, "")
+		setConsultationInput(chatID, false)
 		if userStates[chatID] != "" {
 			delete(userStates, chatID)
 			delete(userTempDataMap, chatID)
@@ -190,6 +251,58 @@ func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
 		return
 	}
 
+	// Если преподаватель вводит поле занятия в диалоге редактирования
+	if edit := getLessonEdit(chatID); edit != nil && edit.Awaiting != "" && !update.Message.IsCommand() {
+		if user, _ := auth.GetUserByTelegramID(chatID); user != nil {
+			processLessonEditMessage(chatID, bot, user, edit, text)
+			return
+		}
+	}
+
+	// Если пользователь вводит своё время сводки
+	if isDigestTimeInput(chatID) && !update.Message.IsCommand() {
+		if user, _ := auth.GetUserByTelegramID(chatID); user != nil {
+			processDigestTimeMessage(chatID, bot, user, text)
+			return
+		}
+	}
+
+	// Если пользователь вводит период выгрузки в календарь
+	if isExportRangeInput(chatID) && !update.Message.IsCommand() {
+		if user, _ := auth.GetUserByTelegramID(chatID); user != nil {
+			processExportRangeMessage(chatID, bot, user, text)
+			return
+		}
+	}
+
+	// Если преподаватель вводит время для поиска свободной аудитории
+	if isRoomSearchInput(chatID) && !update.Message.IsCommand() {
+		processRoomSearchMessage(chatID, bot, text)
+		return
+	}
+
+	// Если преподаватель вводит новое значение поля своего профиля
+	if getProfileEditInput(chatID) != "" && !update.Message.IsCommand() {
+		if user, _ := auth.GetUserByTelegramID(chatID); user != nil {
+			processProfileEditMessage(chatID, bot, user, text)
+			return
+		}
+	}
+
+	// Если преподаватель вводит параметры новой консультации
+	if isConsultationInput(chatID) && !update.Message.IsCommand() {
+		if user, _ := auth.GetUserByTelegramID(chatID); user != nil {
+			processConsultationMessage(chatID, bot, user, text, wallClockNow())
+			return
+		}
+	}
+
+	// Администратор прислал файл с расписанием для импорта
+	if update.Message.Document != nil && update.Message.From != nil && isImportAdmin(update.Message.From.ID) {
+		processImportDocument(chatID, bot, update.Message.Document)
+		return
+	}
+
 	// Если пользователь в процессе логина
 	if state, ok := loginStates[chatID]; ok {
 		processLoginMessage(update, bot, state, text)
@@ -223,6 +336,54 @@ func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
 				sendMainMenu(chatID, bot, nil)
 			}
 			return
+		case "conflicts":
+			if isAdminChat(chatID) {
+				ShowConflictReport(chatID, bot, update.Message.CommandArguments())
+				return
+			}
+			user, _ := auth.GetUserByTelegramID(chatID)
+			sendMainMenu(chatID, bot, user)
+			return
+		case "calendar":
+			if isAdminChat(chatID) {
+				ShowAcademicCalendar(chatID, bot, update.Message.CommandArguments())
+				return
+			}
+			user, _ := auth.GetUserByTelegramID(chatID)
+			sendMainMenu(chatID, bot, user)
+			return
+		case "subgroups":
+			if isAdminChat(chatID) {
+				ShowSubgroupsAdmin(chatID, bot, update.Message.CommandArguments())
+				return
+			}
+			user, _ := auth.GetUserByTelegramID(chatID)
+			sendMainMenu(chatID, bot, user)
+			return
+		case "now", "next":
+			user, _ := auth.GetUserByTelegramID(chatID)
+			mode := cardNow
+			if update.Message.Command() == "next" {
+				mode = cardNext
+			}
+			ShowLiveCard(chatID, bot, user, mode, wallClockNow())
+			return
+		case "free":
+			user, _ := auth.GetUserByTelegramID(chatID)
+			if isAdminChat(chatID) || (user != nil && user.Role == "teacher") {
+				ShowCommonFreeSlots(chatID, bot, user, update.Message.CommandArguments(), wallClockNow())
+				return
+			}
+			sendMainMenu(chatID, bot, user)
+			return
+		case "import":
+			if update.Message.From != nil && isImportAdmin(update.Message.From.ID) {
+				ShowImportHelp(chatID, bot)
+				return
+			}
+			user, _ := auth.GetUserByTelegramID(chatID)
+			sendMainMenu(chatID, bot, user)
+			return
 		default:
 			user, _ := auth.GetUserByTelegramID(chatID)
 			sendMainMenu(chatID, bot, user)
@@ -237,7 +398,7 @@ func ProcessMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI) {
 }
 
 // ProcessCallback — обрабатывает нажатия инлайн-кнопок (меню регистрации, входа, расписания и т.д.).
-func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
+func ProcessCallback(callback *tgbotapi.CallbackQuery, bot Messenger) {
 	chatID := callback.Message.Chat.ID
 	data := callback.Data
 
@@ -246,7 +407,12 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	if err != nil {
 		// Если мы не можем получить пользователя, то часть функций будет недоступна
 		// но можем вывести callback
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка получения данных пользователя"))
+		bot.AnswerCallback(callback.ID, "Ошибка получения данных пользователя")
+		return
+	}
+
+	// Подтверждение импорта расписания в административном чате
+	if ProcessImportCallback(callback, bot) {
 		return
 	}
 
@@ -255,19 +421,67 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		return
 	}
 
+	// Диалог редактирования занятий преподавателем
+	if user != nil && ProcessLessonEditCallback(callback, bot, user) {
+		return
+	}
+
+	// Настройки напоминаний о занятиях
+	if user != nil && ProcessReminderCallback(callback, bot, user) {
+		return
+	}
+
+	// Настройки ежедневной и недельной сводки
+	if user != nil && ProcessDigestCallback(callback, bot, user) {
+		return
+	}
+
+	// Экспорт расписания в календарь (.ics)
+	if user != nil && ProcessExportCallback(callback, bot, user) {
+		return
+	}
+	if user != nil && ProcessFeedCallback(callback, bot, user) {
+		return
+	}
+
+	// Справочник аудиторий и поиск свободной
+	if user != nil && ProcessRoomsCallback(callback, bot, user) {
+		return
+	}
+
+	// Карточки преподавателей и редактирование своего профиля
+	if user != nil && ProcessProfileCallback(callback, bot, user) {
+		return
+	}
+
+	// Выбор подгруппы студентом
+	if user != nil && ProcessSubgroupCallback(callback, bot, user) {
+		return
+	}
+
+	// Изменения недельного расписания с прошлого просмотра
+	if user != nil && ProcessScheduleChangesCallback(callback, bot, user) {
+		return
+	}
+
+	// Консультации: список, запись студентов, добавление и отмена преподавателем
+	if user != nil && ProcessConsultationCallback(callback, bot, user) {
+		return
+	}
+
 	// Проверяем, не является ли callback связанным с фильтрами расписания
 	if strings.HasPrefix(data, "filter_") {
 		if data == "filter_course_menu" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Выбор курса для фильтра"))
+			bot.AnswerCallback(callback.ID, "Выбор курса для фильтра")
 			ShowCourseFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_lesson_type_menu" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Выбор типа занятия для фильтра"))
+			bot.AnswerCallback(callback.ID, "Выбор типа занятия для фильтра")
 			ShowLessonTypeFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_reset_all" {
 			ResetUserFilters(chatID)
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Фильтры сброшены"))
+			bot.AnswerCallback(callback.ID, "Фильтры сброшены")
 			ShowFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_course_reset" {
@@ -275,22 +489,22 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 			filter.CourseID = 0
 			filter.CourseName = ""
 			SetUserFilter(chatID, filter)
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Фильтр по курсу сброшен"))
+			bot.AnswerCallback(callback.ID, "Фильтр по курсу сброшен")
 			ShowFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_lesson_type_reset" {
 			filter := GetUserFilter(chatID)
 			filter.LessonType = ""
 			SetUserFilter(chatID, filter)
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Фильтр по типу занятия сброшен"))
+			bot.AnswerCallback(callback.ID, "Фильтр по типу занятия сброшен")
 			ShowFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_menu" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Возврат к меню фильтров"))
+			bot.AnswerCallback(callback.ID, "Возврат к меню фильтров")
 			ShowFilterMenu(chatID, bot)
 			return
 		} else if data == "filter_apply" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Применение фильтров"))
+			bot.AnswerCallback(callback.ID, "Применение фильтров")
 			// Получаем текущую дату
 			now := time.Now()
 			offset := int(now.Weekday())
@@ -311,7 +525,7 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 				filter.CourseID = parseID(courseID) // Добавим функцию для конвертации строки в int64
 				filter.CourseName = courseName
 				SetUserFilter(chatID, filter)
-				bot.Request(tgbotapi.NewCallback(callback.ID, "Выбран курс: "+courseName))
+				bot.AnswerCallback(callback.ID, "Выбран курс: "+courseName)
 				ShowFilterMenu(chatID, bot)
 				return
 			}
@@ -320,7 +534,7 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 			filter := GetUserFilter(chatID)
 			filter.LessonType = lessonType
 			SetUserFilter(chatID, filter)
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Выбран тип занятия: "+lessonType))
+			bot.AnswerCallback(callback.ID, "Выбран тип занятия: "+lessonType)
 			ShowFilterMenu(chatID, bot)
 			return
 		}
@@ -331,36 +545,33 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	// Обработка навигации по неделям
 	// --- 1) Навигация по неделям ---
 	if strings.HasPrefix(data, "week_prev_") {
-		currentWeekStr := strings.TrimPrefix(data, "week_prev_")
-		currentWeekStart, err := time.Parse("2006-01-02", currentWeekStr)
+		// Кнопки навигации уже содержат понедельник нужной недели
+		newWeekStr := strings.TrimPrefix(data, "week_prev_")
+		newWeekStart, err := time.Parse("2006-01-02", newWeekStr)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка обработки даты"))
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
 			return
 		}
-		newWeekStart := currentWeekStart.AddDate(0, 0, -7)
-		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		bot.AnswerCallback(callback.ID, "")
 		ShowScheduleWeek(chatID, bot, user, newWeekStart)
 		return
 	}
 
 	if strings.HasPrefix(data, "week_next_") {
-		currentWeekStr := strings.TrimPrefix(data, "week_next_")
-		currentWeekStart, err := time.Parse("2006-01-02", currentWeekStr)
+		// Кнопки навигации уже содержат понедельник нужной недели
+		newWeekStr := strings.TrimPrefix(data, "week_next_")
+		newWeekStart, err := time.Parse("2006-01-02", newWeekStr)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка обработки даты"))
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
 			return
 		}
-		newWeekStart := currentWeekStart.AddDate(0, 0, 7)
-		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		bot.AnswerCallback(callback.ID, "")
 		ShowScheduleWeek(chatID, bot, user, newWeekStart)
 		return
 	}
 
-	// Удаляем обработку навигации по месяцам
-	// НЕ НУЖНО: Обработка навигации по месяцам
-
 	if data == "week_today" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Переход к текущей неделе"))
+		bot.AnswerCallback(callback.ID, "Переход к текущей неделе")
 		now := time.Now()
 		offset := int(now.Weekday())
 		if offset == 0 {
@@ -371,16 +582,16 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		return
 	}
 	if data == "mode_day" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Переход к дневному режиму"))
+		bot.AnswerCallback(callback.ID, "Переход к дневному режиму")
 		// Используем новую улучшенную версию
 		selectedDay, err := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка обработки даты"))
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
 			return
 		}
 		err = ShowEnhancedScheduleDay(chatID, bot, user, selectedDay)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка отображения дневного расписания"))
+			bot.AnswerCallback(callback.ID, "Ошибка отображения дневного расписания")
 		}
 		return
 	} else if data == "mode_week" {
@@ -390,15 +601,33 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 			offset = 7
 		}
 		weekStart := now.AddDate(0, 0, -(offset - 1))
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Переход к недельному режиму"))
+		bot.AnswerCallback(callback.ID, "Переход к недельному режиму")
 		err := ShowScheduleWeek(chatID, bot, user, weekStart)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка отображения недельного расписания"))
+			bot.AnswerCallback(callback.ID, "Ошибка отображения недельного расписания")
+		}
+		return
+	} else if data == "mode_month" || data == "month_today" {
+		bot.AnswerCallback(callback.ID, "Переход к месячному режиму")
+		if err := ShowScheduleMonth(chatID, bot, user, time.Now()); err != nil {
+			fmt.Println("Ошибка отображения месячного расписания:", err)
+		}
+		return
+	}
+
+	// Навигация по месяцам: month_YYYY-MM
+	if strings.HasPrefix(data, "month_") {
+		month, err := time.Parse("2006-01", strings.TrimPrefix(data, "month_"))
+		if err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
+			return
 		}
+		bot.AnswerCallback(callback.ID, "")
+		ShowScheduleMonth(chatID, bot, user, month)
 		return
-	} else if data == "mode_month" {
-		// Сохраняем этот обработчик, но меняем его поведение
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Режим 'Месяц' больше не поддерживается"))
+	}
+	if data == calendarNoop {
+		bot.AnswerCallback(callback.ID, "")
 		return
 	}
 	// В начале ProcessCallback, после получения user
@@ -412,10 +641,10 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		if user.Role == "teacher" {
 			schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, dayStart, dayEnd)
 		} else {
-			schedules, err = GetSchedulesForGroupByDateRange(user.Group, dayStart, dayEnd)
+			schedules, err = GetSchedulesForStudentByDateRange(user, dayStart, dayEnd)
 		}
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка загрузки расписания"))
+			bot.AnswerCallback(callback.ID, "Ошибка загрузки расписания")
 			return
 		}
 
@@ -423,9 +652,9 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		msg := tgbotapi.NewMessage(chatID, timelineText)
 		msg.ParseMode = "HTML"
 		if err := sendAndTrackMessage(bot, msg); err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка отображения таймлайна"))
+			bot.AnswerCallback(callback.ID, "Ошибка отображения таймлайна")
 		} else {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Таймлайн загружен"))
+			bot.AnswerCallback(callback.ID, "Таймлайн загружен")
 		}
 		return
 	}
@@ -435,10 +664,10 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		dayStr := strings.TrimPrefix(data, "day_")
 		selectedDay, err := time.Parse("2006-01-02", dayStr)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка обработки даты"))
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
 			return
 		}
-		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		bot.AnswerCallback(callback.ID, "")
 		// Используем новую улучшенную версию вместо старой
 		ShowEnhancedScheduleDay(chatID, bot, user, selectedDay)
 		return
@@ -447,7 +676,7 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	// --- 3) Фильтрация по курсу ---
 	// 3.1) Кнопка, открывающая меню выбора курса
 	if data == "filter_menu" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Выбор фильтра"))
+		bot.AnswerCallback(callback.ID, "Выбор фильтра")
 		ShowFilterMenu(chatID, bot)
 		return
 	}
@@ -456,8 +685,8 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	if userStates[chatID] != "" || loginStates[chatID] != "" {
 		switch callback.Data {
 		case "menu_register", "menu_login":
-			bot.Request(tgbotapi.NewCallback(callback.ID,
-				"Сначала заверши текущий процесс или отмени его командой /cancel."))
+			bot.AnswerCallback(callback.ID,
+				"Сначала заверши текущий процесс или отмени его командой /cancel.")
 			return
 		}
 	}
@@ -466,31 +695,35 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	case "menu_register":
 		userStates[chatID] = StateWaitingForRole
 		userTempDataMap[chatID] = &tempUserData{}
-		bot.Request(tgbotapi.NewCallback(callback.ID, "📝 Начинаем регистрацию!"))
+		bot.AnswerCallback(callback.ID, "📝 Начинаем регистрацию!")
 		sendRoleSelection(chatID, bot)
 		return
 
 	case "menu_login":
 		loginStates[chatID] = LoginStateWaitingForRegCode
 		loginTempDataMap[chatID] = &loginData{}
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🔑 Выполняем вход..."))
+		bot.AnswerCallback(callback.ID, "🔑 Выполняем вход...")
 		msg := tgbotapi.NewMessage(chatID, "Введите свой регистрационный код:")
 		sendAndTrackMessage(bot, msg)
 		return
 
 	case "menu_schedule":
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🗓 Расписание"))
+		bot.AnswerCallback(callback.ID, "🗓 Расписание")
 		// Улучшенное меню выбора режима расписания
 		keyboard := tgbotapi.NewInlineKeyboardMarkup(
 			// Первый ряд с режимами просмотра
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("📅 День", "mode_day"),
 				tgbotapi.NewInlineKeyboardButtonData("📊 Неделя", "mode_week"),
+				tgbotapi.NewInlineKeyboardButtonData("🗓 Месяц", "mode_month"),
 			),
 			// Второй ряд с кнопкой фильтров, выделенной и заметной
 			tgbotapi.NewInlineKeyboardRow(
 				tgbotapi.NewInlineKeyboardButtonData("🔍 Настроить фильтры", "filter_menu"),
 			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("📤 Экспорт в календарь", "menu_export"),
+			),
 		)
 		msg := tgbotapi.NewMessage(chatID, "<b>Просмотр расписания</b>\n\nВыберите режим отображения или настройте фильтры:")
 		msg.ParseMode = "HTML"
@@ -499,7 +732,7 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		return
 
 	case "menu_materials":
-		bot.Request(tgbotapi.NewCallback(callback.ID, "📚 Материалы"))
+		bot.AnswerCallback(callback.ID, "📚 Материалы")
 		user, _ := auth.GetUserByTelegramID(chatID)
 
 		// Сбрасываем состояние пагинации материалов при первом входе
@@ -515,11 +748,11 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	case "menu_logout":
 		user, err := auth.GetUserByTelegramID(chatID)
 		if err != nil || user == nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Вы не авторизованы."))
+			bot.AnswerCallback(callback.ID, "Вы не авторизованы.")
 		} else {
 			user.TelegramID = 0
 			_ = auth.SaveUser(user)
-			bot.Request(tgbotapi.NewCallback(callback.ID, "🚪 Выход"))
+			bot.AnswerCallback(callback.ID, "🚪 Выход")
 			msg := tgbotapi.NewMessage(chatID, "Вы успешно вышли. До скорой встречи!")
 			sendAndTrackMessage(bot, msg)
 			// Удаляем все сообщения из чата с задержкой
@@ -529,11 +762,14 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 		sendMainMenu(chatID, bot, nil)
 		return
 	case "menu_help":
-		bot.Request(tgbotapi.NewCallback(callback.ID, "❓ Справка"))
+		bot.AnswerCallback(callback.ID, "❓ Справка")
 		msg := tgbotapi.NewMessage(chatID,
 			"Вот что я умею:\n"+
 				"• Студенты: смотреть расписание и материалы\n"+
 				"• Преподаватели: плюс редактировать расписание и материалы\n"+
+				"• /now и /next — текущее и следующее занятие с обратным отсчётом\n"+
+				"• Преподаватели: /free — общие свободные окна с группами\n"+
+				"• В любом чате: @имя_бота today, tomorrow или week — поделиться расписанием\n"+
 				"• Кнопка «Выход» завершает работу\n"+
 				"• В любой момент жми «🏠 Главное меню» внизу экрана")
 		sendAndTrackMessage(bot, msg)
@@ -542,10 +778,10 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	case "menu_edit_schedule":
 		user, err := auth.GetUserByTelegramID(chatID)
 		if err != nil || user == nil || user.Role != "teacher" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "У вас нет прав для редактирования расписания."))
+			bot.AnswerCallback(callback.ID, "У вас нет прав для редактирования расписания.")
 			return
 		}
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🛠 Изменение расписания..."))
+		bot.AnswerCallback(callback.ID, "🛠 Изменение расписания...")
 		msg := tgbotapi.NewMessage(chatID, "Добавьте или отредактируйте расписание (реализуйте по-своему).")
 		sendAndTrackMessage(bot, msg)
 		return
@@ -553,32 +789,32 @@ func ProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
 	case "menu_edit_materials":
 		user, err := auth.GetUserByTelegramID(chatID)
 		if err != nil || user == nil || user.Role != "teacher" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "У вас нет прав для редактирования материалов."))
+			bot.AnswerCallback(callback.ID, "У вас нет прав для редактирования материалов.")
 			return
 		}
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🛠 Изменение материалов..."))
+		bot.AnswerCallback(callback.ID, "🛠 Изменение материалов...")
 		msg := tgbotapi.NewMessage(chatID, "Здесь можно загрузить или обновить учебные материалы (реализуйте по-своему).")
 		sendAndTrackMessage(bot, msg)
 		return
 	case "menu_teacher_courses":
 		// Answer callback immediately to stop the looping animation
-		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+		bot.AnswerCallback(callback.ID, "")
 		// Получаем пользователя по chatID
 		user, err := auth.GetUserByTelegramID(chatID)
 		if err != nil || user == nil || user.Role != "teacher" {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Нет доступа"))
+			bot.AnswerCallback(callback.ID, "Нет доступа")
 			return
 		}
 
 		// Получаем курсы и группы преподавателя
 		courses, err := GetCoursesByTeacherRegCode(user.RegistrationCode)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка получения курсов"))
+			bot.AnswerCallback(callback.ID, "Ошибка получения курсов")
 			return
 		}
 		groups, err := GetTeacherGroupsByRegCode(user.RegistrationCode)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Ошибка получения групп"))
+			bot.AnswerCallback(callback.ID, "Ошибка получения групп")
 			return
 		}
 
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	feedPastDays   = 30  // сколько прошедших дней попадает в ленту
+	feedFutureDays = 180 // и сколько будущих
+)
+
+var (
+	// Публичный адрес HTTP-сервера для ссылок на ленту, например https://bot.example.com
+	icalBaseURL   string
+	icalBaseURLMu sync.RWMutex
+)
+
+// SetICalBaseURL задаёт публичный адрес, по которому доступен /ical/ (пусто — подписка выключена).
+func SetICalBaseURL(url string) {
+	icalBaseURLMu.Lock()
+	defer icalBaseURLMu.Unlock()
+	icalBaseURL = strings.TrimRight(url, "/")
+}
+
+func feedEnabled() bool {
+	icalBaseURLMu.RLock()
+	defer icalBaseURLMu.RUnlock()
+	return icalBaseURL != ""
+}
+
+func feedURL(token string) string {
+	icalBaseURLMu.RLock()
+	defer icalBaseURLMu.RUnlock()
+	return icalBaseURL + "/ical/" + token + ".ics"
+}
+
+// newFeedToken генерирует секретный токен ленты.
+func newFeedToken() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GetFeedToken возвращает токен ленты пользователя, создавая его при первом обращении.
+func GetFeedToken(regCode string) (string, error) {
+	var token string
+	err := db.DB.QueryRow(`SELECT token FROM ical_tokens WHERE registration_code = ?`, regCode).Scan(&token)
+	if err == sql.ErrNoRows {
+		return RotateFeedToken(regCode)
+	}
+	return token, err
+}
+
+// RotateFeedToken выпускает новый токен; старая ссылка сразу перестаёт работать.
+func RotateFeedToken(regCode string) (string, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = db.DB.Exec(`
+		INSERT INTO ical_tokens (token, registration_code, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(registration_code) DO UPDATE SET
+			token = excluded.token, created_at = excluded.created_at, etag = '', modified_at = ''`,
+		token, regCode, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeFeedToken отключает ленту пользователя.
+func RevokeFeedToken(regCode string) error {
+	_, err := db.DB.Exec(`DELETE FROM ical_tokens WHERE registration_code = ?`, regCode)
+	return err
+}
+
+// ICalFeedHandler отдаёт ленту GET /ical/<token>.ics. ETag и Last-Modified меняются только
+// при изменении занятий, поэтому клиенты, опрашивающие ленту, обычно получают 304.
+func ICalFeedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/ical/")
+		token := strings.TrimSuffix(name, ".ics")
+		if token == name || token == "" || strings.Contains(token, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, etag, modified, err := renderFeed(token, wallClockNow(), time.Now().UTC())
+		if err == sql.ErrNoRows {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка формирования ленты календаря: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("ETag", `"`+etag+`"`)
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		// ServeContent сам отвечает 304 на If-None-Match / If-Modified-Since и обрабатывает HEAD
+		http.ServeContent(w, r, name, modified, bytes.NewReader(body))
+	})
+}
+
+// renderFeed формирует ленту по токену. Окно занятий отсчитывается от дня wallNow (время в том же
+// виде, что и времена занятий). ETag — хэш содержимого без DTSTAMP; когда он меняется, время
+// изменения ленты сдвигается на реальный момент now. sql.ErrNoRows — токен неизвестен или отозван.
+func renderFeed(token string, wallNow, now time.Time) (body []byte, etag string, modified time.Time, err error) {
+	var user models.User
+	var storedETag, modifiedAt string
+	err = db.DB.QueryRow(`
+		SELECT u.role, u.name, COALESCE(u.group_name, ''), COALESCE(u.subgroup_id, 0), u.registration_code, t.etag, t.modified_at
+		FROM ical_tokens t
+		JOIN users u ON u.registration_code = t.registration_code
+		WHERE t.token = ?`, token).
+		Scan(&user.Role, &user.Name, &user.Group, &user.SubgroupID, &user.RegistrationCode, &storedETag, &modifiedAt)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	today := truncateToDay(wallNow)
+	start, end := today.AddDate(0, 0, -feedPastDays), today.AddDate(0, 0, feedFutureDays)
+	var schedules []models.Schedule
+	if user.Role == "teacher" {
+		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, start, end)
+	} else {
+		schedules, err = GetSchedulesForStudentByDateRange(&user, start, end)
+	}
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	courseNames := make(map[int64]string)
+	if courses, err := GetAllCourses(); err == nil {
+		for _, c := range courses {
+			courseNames[c.ID] = c.Name
+		}
+	}
+	name := "Расписание " + user.Group
+	if user.Role == "teacher" {
+		name = "Расписание " + user.Name
+	}
+	cal := BuildCalendar(name, schedules, courseNames, user.Role)
+
+	sum := sha256.Sum256(cal.Marshal(time.Time{}))
+	etag = hex.EncodeToString(sum[:16])
+	modified, _ = time.Parse(time.RFC3339, modifiedAt)
+	if etag != storedETag || modified.IsZero() {
+		modified = now.Truncate(time.Second)
+		if _, err := db.DB.Exec(`UPDATE ical_tokens SET etag = ?, modified_at = ? WHERE token = ?`,
+			etag, modified.Format(time.RFC3339), token); err != nil {
+			log.Printf("Ошибка сохранения состояния ленты: %v", err)
+		}
+	}
+	return cal.Marshal(modified), etag, modified, nil
+}
+
+// ShowFeedSubscription показывает ссылку на ленту и кнопки её замены и отключения.
+func ShowFeedSubscription(chatID int64, bot Messenger, user *models.User) error {
+	if !feedEnabled() {
+		msg := tgbotapi.NewMessage(chatID, "🔗 Подписка на календарь пока недоступна: сервер календаря не настроен.")
+		return sendAndTrackMessage(bot, msg)
+	}
+	token, err := GetFeedToken(user.RegistrationCode)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения ссылки: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	text := fmt.Sprintf("🔗 <b>Подписка на календарь</b>\n\n"+
+		"Добавьте ссылку в календарь как подписку (Google: «Добавить по URL», iPhone: «Добавить подписку»). "+
+		"Календарь сам подтянет изменения расписания.\n\n<code>%s</code>\n\n"+
+		"⚠️ Не делитесь ссылкой: по ней доступно ваше расписание. Если ссылка попала к посторонним, выпустите новую.",
+		feedURL(token))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Новая ссылка", "ical_rotate"),
+			tgbotapi.NewInlineKeyboardButtonData("🚫 Отключить", "ical_revoke"),
+		),
+	)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessFeedCallback обрабатывает кнопки подписки. Возвращает true, если callback обработан.
+func ProcessFeedCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	switch callback.Data {
+	case "ical_feed":
+		bot.AnswerCallback(callback.ID, "🔗 Подписка")
+	case "ical_rotate":
+		if _, err := RotateFeedToken(user.RegistrationCode); err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка выпуска ссылки")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "Старая ссылка отключена")
+	case "ical_revoke":
+		if err := RevokeFeedToken(user.RegistrationCode); err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка отключения ссылки")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "Ссылка отключена")
+		msg := tgbotapi.NewMessage(chatID, "🚫 Ссылка на календарь отключена. Новую можно получить в меню экспорта.")
+		sendAndTrackMessage(bot, msg)
+		return true
+	default:
+		return false
+	}
+	if err := ShowFeedSubscription(chatID, bot, user); err != nil {
+		fmt.Println("Ошибка отображения подписки:", err)
+	}
+	return true
+}
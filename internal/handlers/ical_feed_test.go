@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestICalFeedConditionalRequestsAndRevocation(t *testing.T) {
+	c := newConversation(t, 1010)
+	c.loggedIn("ST-0002", "secret12")
+	SetICalBaseURL("https://bot.example.com/")
+	t.Cleanup(func() { SetICalBaseURL("") })
+
+	c.press("ical_feed")
+	sent := c.srv.CallsTo("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Text(), "https://bot.example.com/ical/") {
+		t.Fatalf("ожидалась ссылка на ленту: %v", sent)
+	}
+	token, err := GetFeedToken("ST-0002")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(ICalFeedHandler())
+	t.Cleanup(srv.Close)
+	get := func(path string, header map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Лента строится от текущей даты — добавим занятие на завтра
+	tomorrow := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1).Add(10 * time.Hour)
+	lesson := models.Schedule{CourseID: 1, GroupName: testGroup, TeacherRegCode: "TH-0001",
+		ScheduleTime: tomorrow, Duration: 90, LessonType: "Лекция", Auditory: "101"}
+	if err := scheduling.CreateLesson(&lesson, true, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/ical/" + token + ".ics"
+	first := get(path, nil)
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" || first.Header.Get("Last-Modified") == "" {
+		t.Fatalf("первый запрос: %d, ETag %q", first.StatusCode, etag)
+	}
+	if ct := first.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if resp := get(path, map[string]string{"If-None-Match": etag}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match: %d, ожидался 304", resp.StatusCode)
+	}
+	if resp := get(path, map[string]string{"If-Modified-Since": first.Header.Get("Last-Modified")}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: %d, ожидался 304", resp.StatusCode)
+	}
+
+	// Изменение расписания меняет ETag
+	lesson.Auditory = "305"
+	if err := scheduling.UpdateLesson(lesson, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	changed := get(path, map[string]string{"If-None-Match": etag})
+	if changed.StatusCode != http.StatusOK || changed.Header.Get("ETag") == etag {
+		t.Errorf("после изменения: %d, ETag %q", changed.StatusCode, changed.Header.Get("ETag"))
+	}
+
+	// Новая ссылка отключает старую
+	c.press("ical_rotate")
+	if resp := get(path, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("старый токен после замены: %d, ожидался 404", resp.StatusCode)
+	}
+	newToken, _ := GetFeedToken("ST-0002")
+	if resp := get("/ical/"+newToken+".ics", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("новый токен: %d", resp.StatusCode)
+	}
+	c.press("ical_revoke")
+	if resp := get("/ical/"+newToken+".ics", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("отозванный токен: %d, ожидался 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+
+	"education/internal/scheduling"
+	"education/internal/timetable"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxImportFileSize — ограничение на размер файла с расписанием.
+const maxImportFileSize = 5 << 20
+
+// pendingImport — проверенный файл, ожидающий подтверждения администратора.
+type pendingImport struct {
+	FileName string
+	Plan     *scheduling.ImportPlan
+}
+
+var (
+	pendingImports   = make(map[int64]*pendingImport)
+	pendingImportsMu sync.Mutex
+)
+
+func setPendingImport(chatID int64, p *pendingImport) {
+	pendingImportsMu.Lock()
+	defer pendingImportsMu.Unlock()
+	if p == nil {
+		delete(pendingImports, chatID)
+		return
+	}
+	pendingImports[chatID] = p
+}
+
+// takePendingImport забирает ожидающий импорт чата: повторное нажатие кнопки его уже не найдёт.
+func takePendingImport(chatID int64) *pendingImport {
+	pendingImportsMu.Lock()
+	defer pendingImportsMu.Unlock()
+	p := pendingImports[chatID]
+	delete(pendingImports, chatID)
+	return p
+}
+
+var (
+	importAdmins   = make(map[int64]bool)
+	importAdminsMu sync.Mutex
+)
+
+// SetImportAdmins задаёт Telegram ID пользователей, которым разрешён импорт расписания.
+// Права не связаны с чатом отчётов об ошибках (ADMIN_CHAT_ID).
+func SetImportAdmins(ids []int64) {
+	importAdminsMu.Lock()
+	defer importAdminsMu.Unlock()
+	importAdmins = make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		importAdmins[id] = true
+	}
+}
+
+// isImportAdmin сообщает, может ли пользователь импортировать расписание.
+func isImportAdmin(userID int64) bool {
+	importAdminsMu.Lock()
+	defer importAdminsMu.Unlock()
+	return importAdmins[userID]
+}
+
+// ShowImportHelp объясняет администратору формат файла для импорта.
+func ShowImportHelp(chatID int64, bot Messenger) error {
+	text := "📥 <b>Импорт расписания</b>\n\n" +
+		"Пришлите файл .csv или .xlsx. Первая строка — заголовок, далее по занятию в строке.\n\n" +
+		"Колонки:\n" +
+		"• <b>Группа</b> — например АА-23-01\n" +
+		"• <b>Курс</b> — название или номер курса\n" +
+		"• <b>Преподаватель</b> — регистрационный код, например TH-0001\n" +
+		"• <b>Дата</b> (ДД.ММ.ГГГГ) и <b>Время</b> (ЧЧ:ММ) — или одна колонка <b>Дата и время</b>\n" +
+		"• Длительность — в минутах, по умолчанию 90\n" +
+		"• Подгруппа — название подгруппы (см. /subgroups), пусто — вся группа\n" +
+		"• Аудитория, Тип, Описание — необязательно\n\n" +
+		"Сначала бот проверит файл и покажет, что будет добавлено. Занятия сохранятся только после подтверждения — все сразу или ни одного."
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	return sendAndTrackMessage(bot, msg)
+}
+
+// processImportDocument скачивает присланный файл, проверяет его и показывает результат пробного импорта.
+func processImportDocument(chatID int64, bot Messenger, doc *tgbotapi.Document) {
+	if doc.FileSize > maxImportFileSize {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Файл слишком большой: не больше 5 МБ."))
+		return
+	}
+	data, err := bot.DownloadFile(doc.FileID)
+	if err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Не удалось скачать файл: "+err.Error()))
+		return
+	}
+	rows, err := timetable.ReadFile(doc.FileName, data)
+	if err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()))
+		return
+	}
+	records, rowErrs := timetable.Parse(rows)
+	plan, err := scheduling.PlanImport(records)
+	if err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "Ошибка проверки расписания: "+err.Error()))
+		return
+	}
+	plan.Errors = append(rowErrs, plan.Errors...)
+	sort.SliceStable(plan.Errors, func(i, j int) bool { return plan.Errors[i].Line < plan.Errors[j].Line })
+
+	setPendingImport(chatID, &pendingImport{FileName: doc.FileName, Plan: plan})
+	msg := tgbotapi.NewMessage(chatID, formatImportPreview(doc.FileName, plan))
+	msg.ParseMode = "HTML"
+	if markup := importPreviewKeyboard(plan); markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+	sendAndTrackMessage(bot, msg)
+}
+
+// formatImportPreview описывает результат проверки файла (HTML).
+func formatImportPreview(fileName string, plan *scheduling.ImportPlan) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📥 <b>Проверка файла %s</b>\n\n", html.EscapeString(fileName)))
+
+	if n := len(plan.Lessons); n > 0 {
+		groups := make(map[string]bool)
+		first, last := plan.Lessons[0].Lesson.ScheduleTime, plan.Lessons[0].Lesson.ScheduleTime
+		for _, l := range plan.Lessons {
+			groups[l.Lesson.GroupName] = true
+			if t := l.Lesson.ScheduleTime; t.Before(first) {
+				first = t
+			} else if t.After(last) {
+				last = t
+			}
+		}
+		sb.WriteString(fmt.Sprintf("✅ Занятий к импорту: %d (групп: %d, %s – %s)\n",
+			n, len(groups), first.Format("02.01.2006"), last.Format("02.01.2006")))
+	} else {
+		sb.WriteString("В файле нет занятий, которые можно импортировать.\n")
+	}
+
+	var items []string
+	if len(plan.Errors) > 0 {
+		items = append(items, fmt.Sprintf("\n❗ <b>Ошибки (%d)</b> — исправьте файл и пришлите его снова:", len(plan.Errors)))
+		for _, e := range plan.Errors {
+			items = append(items, "• "+html.EscapeString(e.Error()))
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		items = append(items, fmt.Sprintf("\n⚠️ <b>Пересечения (%d)</b>:", len(plan.Conflicts)))
+		for _, c := range plan.Conflicts {
+			items = append(items, "• "+html.EscapeString(c.String()))
+		}
+	}
+	for i, item := range items {
+		if sb.Len()+len(item) > maxReportLen {
+			sb.WriteString(fmt.Sprintf("…и ещё %d", len(items)-i))
+			break
+		}
+		sb.WriteString(item + "\n")
+	}
+	if plan.Ready(false) {
+		sb.WriteString("\nПересечений нет. Импортировать?")
+	}
+	return sb.String()
+}
+
+// importPreviewKeyboard — кнопки подтверждения; при ошибках в файле импорт недоступен.
+func importPreviewKeyboard(plan *scheduling.ImportPlan) *tgbotapi.InlineKeyboardMarkup {
+	cancel := tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "imp_cancel")
+	var markup tgbotapi.InlineKeyboardMarkup
+	switch {
+	case plan.Ready(false):
+		markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Импортировать", "imp_commit"), cancel,
+		))
+	case plan.Ready(true):
+		markup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("⚠️ Импортировать несмотря на пересечения", "imp_force")),
+			tgbotapi.NewInlineKeyboardRow(cancel),
+		)
+	default:
+		return nil
+	}
+	return &markup
+}
+
+// ProcessImportCallback обрабатывает кнопки подтверждения импорта. Возвращает true, если callback обработан.
+func ProcessImportCallback(callback *tgbotapi.CallbackQuery, bot Messenger) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	if data != "imp_commit" && data != "imp_force" && data != "imp_cancel" {
+		return false
+	}
+	if !isImportAdmin(callback.From.ID) {
+		bot.AnswerCallback(callback.ID, "Недоступно")
+		return true
+	}
+	// Апдейты обрабатываются параллельно: при двойном нажатии файл импортирует только первое
+	pending := takePendingImport(chatID)
+	if pending == nil {
+		bot.AnswerCallback(callback.ID, "Файл уже обработан — пришлите его заново")
+		return true
+	}
+	if data == "imp_cancel" {
+		bot.AnswerCallback(callback.ID, "Импорт отменён")
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "❌ Импорт отменён, расписание не изменилось."))
+		return true
+	}
+
+	bot.AnswerCallback(callback.ID, "Импортирую…")
+	if err := scheduling.CommitImport(pending.Plan, data == "imp_force", scheduling.ChangedByAdmin); err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID,
+			"⚠️ Импорт не выполнен, расписание не изменилось: "+err.Error()+"\n\nПришлите файл снова, чтобы проверить его заново."))
+		return true
+	}
+	for _, l := range pending.Plan.Lessons {
+		lessonChanged(nil, &l.Lesson)
+	}
+	sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID,
+		fmt.Sprintf("✅ Импортировано занятий: %d (%s).", len(pending.Plan.Lessons), pending.FileName)))
+	return true
+}
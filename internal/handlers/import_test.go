@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"education/internal/db"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendDocument имитирует присланный файл: содержимое доступно боту через getFile.
+func (c *conversation) sendDocument(fileID, name string, data []byte) {
+	c.srv.AddFile(fileID, data)
+	msg := &tgbotapi.Message{
+		MessageID: 1,
+		Chat:      &tgbotapi.Chat{ID: c.chatID, Type: "private"},
+		From:      &tgbotapi.User{ID: c.chatID},
+		Document:  &tgbotapi.Document{FileID: fileID, FileName: name, FileSize: len(data)},
+	}
+	ProcessMessage(&tgbotapi.Update{Message: msg}, c.bot)
+}
+
+func countSchedules(t *testing.T) int {
+	t.Helper()
+	var n int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM schedules`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestAdminImportsTimetable(t *testing.T) {
+	c := newConversation(t, 9001)
+	SetImportAdmins([]int64{9001})
+	t.Cleanup(func() { SetImportAdmins(nil) })
+
+	c.send("/import")
+
+	// Файл с ошибкой: импорт недоступен, расписание не меняется
+	c.sendDocument("bad", "bad.csv", []byte("Группа;Курс;Преподаватель;Дата;Время\n"+
+		"АА-23-01;Матем;TH-0001;20.03.2025;08:00\n"+
+		"АА-23-01;Химия;TH-0001;20.03.2025;10:00\n"))
+
+	// Пересечение с занятиями 17.03: можно импортировать только явно, повторное нажатие ничего не делает
+	c.sendDocument("clash", "clash.csv", []byte("Группа,Курс,Преподаватель,Дата и время,Аудитория\n"+
+		"АА-23-01,Матем,TH-0001,17.03.2025 09:00,305\n"))
+	c.press("imp_force")
+	c.press("imp_force")
+
+	c.sendDocument("good", "good.csv", []byte("Группа;Курс;Преподаватель;Дата;Время;Длительность;Аудитория;Тип\n"+
+		"АА-23-01;Матем;TH-0001;20.03.2025;08:00;90;101;Лекция\n"+
+		"АА-23-01;прог;TH-0002;20.03.2025;09:45;90;201;Практика\n"))
+	if n := countSchedules(t); n != 6 {
+		t.Fatalf("до подтверждения расписание не должно меняться, занятий %d", n)
+	}
+	c.press("imp_commit")
+	c.golden("bulk_import")
+
+	// Отмена сбрасывает проверенный файл
+	c.sendDocument("empty", "empty.csv", nil)
+	c.press("imp_cancel")
+	c.press("imp_commit")
+	if got := c.transcript(); !strings.Contains(got, "файл пустой") || !strings.Contains(got, "Файл уже обработан") {
+		t.Errorf("неожиданный ответ на пустой файл и отмену:\n%s", got)
+	}
+
+	if n := countSchedules(t); n != 8 {
+		t.Errorf("ожидалось 8 занятий после импорта, получено %d", n)
+	}
+}
+
+func TestImportIgnoresDocumentsFromNonAdmins(t *testing.T) {
+	c := newConversation(t, 1001)
+	// Чат отчётов об ошибках права на импорт не даёт
+	SetAdminChatID(1001)
+	t.Cleanup(func() { SetAdminChatID(0) })
+	c.sendDocument("good", "good.csv", []byte("Группа;Курс;Преподаватель;Дата;Время\n"+
+		"АА-23-01;Матем;TH-0001;20.03.2025;08:00\n"))
+	c.press("imp_commit")
+	if n := countSchedules(t); n != 5 {
+		t.Errorf("импорт не администратором изменил расписание: %d занятий", n)
+	}
+	if calls := c.srv.CallsTo("getFile"); len(calls) != 0 {
+		t.Errorf("файл не должен скачиваться: %d вызовов getFile", len(calls))
+	}
+}
+
+func TestImportDoubleTapCommitsOnce(t *testing.T) {
+	c := newConversation(t, 9001)
+	SetImportAdmins([]int64{9001})
+	t.Cleanup(func() { SetImportAdmins(nil) })
+
+	c.sendDocument("clash", "clash.csv", []byte("Группа,Курс,Преподаватель,Дата и время,Аудитория\n"+
+		"АА-23-01,Матем,TH-0001,17.03.2025 09:00,305\n"))
+	before := countSchedules(t)
+
+	// Апдейты обрабатываются несколькими воркерами: два нажатия могут прийти одновременно
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.press("imp_force")
+		}()
+	}
+	wg.Wait()
+
+	if n := countSchedules(t); n != before+1 {
+		t.Errorf("файл должен импортироваться один раз: было %d занятий, стало %d", before, n)
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"education/internal/auth"
+	"education/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// inlineCacheSeconds — сколько Telegram кэширует ответ на inline-запрос. Ответы личные,
+// а расписание меняется, поэтому кэш короткий.
+const inlineCacheSeconds = 60
+
+// Виды inline-результатов
+const (
+	inlineToday    = "today"
+	inlineTomorrow = "tomorrow"
+	inlineWeek     = "week"
+)
+
+// inlineKeywords — слова запроса, выбирающие вид результата.
+var inlineKeywords = map[string]string{
+	"today": inlineToday, "сегодня": inlineToday,
+	"tomorrow": inlineTomorrow, "завтра": inlineTomorrow,
+	"week": inlineWeek, "неделя": inlineWeek,
+}
+
+// errInlineForbidden — пользователь запросил расписание группы, которое ему не видно.
+var errInlineForbidden = errors.New("нет доступа к расписанию группы")
+
+// parseInlineQuery разбирает запрос "[today|tomorrow|week] [группа]". Без ключевого слова
+// предлагаются все виды результатов; повторы ("today сегодня") дают один результат,
+// иначе Telegram отклонит ответ с одинаковыми id.
+func parseInlineQuery(query string) (kinds []string, group string) {
+	for _, f := range strings.Fields(query) {
+		if kind, ok := inlineKeywords[strings.ToLower(f)]; ok {
+			if !containsString(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		} else if group == "" {
+			group = strings.ToUpper(f)
+		}
+	}
+	if len(kinds) == 0 {
+		kinds = []string{inlineToday, inlineTomorrow, inlineWeek}
+	}
+	return kinds, group
+}
+
+// inlineSchedules возвращает занятия за [start, end] с правами пользователя: студенту видна
+// только своя группа (с учётом подгруппы), преподавателю — свои занятия и группы, у которых он ведёт.
+// Возвращает также роль, в которой оформлять расписание, и подпись «чьё это расписание».
+func inlineSchedules(user *models.User, group string, start, end time.Time) ([]models.Schedule, string, string, error) {
+	if user.Role != "teacher" {
+		if group != "" && group != user.Group {
+			return nil, "", "", errInlineForbidden
+		}
+		label := "👥 " + user.Group
+		if name := subgroupName(user); name != "" {
+			label += ", подгруппа " + name
+		}
+		schedules, err := GetSchedulesForStudentByDateRange(user, start, end)
+		return schedules, user.Role, label, err
+	}
+
+	if group == "" {
+		schedules, err := GetSchedulesForTeacherByDateRange(user.RegistrationCode, start, end)
+		return schedules, user.Role, "👨‍🏫 " + user.Name, err
+	}
+	groups, err := GetTeacherGroups(user.RegistrationCode)
+	if err != nil {
+		return nil, "", "", err
+	}
+	for _, g := range groups {
+		if g.GroupName == group {
+			// Расписание группы оформляется как у студента — с преподавателями
+			schedules, err := GetSchedulesForGroupByDateRange(group, start, end)
+			return schedules, "student", "👥 " + group, err
+		}
+	}
+	return nil, "", "", errInlineForbidden
+}
+
+// truncateInlineText обрезает текст по строкам до лимита сообщения.
+func truncateInlineText(text string) string {
+	if len(text) <= maxReportLen {
+		return text
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if sb.Len()+len(line)+1 > maxReportLen {
+			break
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String() + "…"
+}
+
+// inlineResult собирает результат одного вида: текст для отправки в чат, заголовок и описание.
+func inlineResult(user *models.User, kind, group string, now time.Time) (tgbotapi.InlineQueryResultArticle, error) {
+	today := truncateToDay(now)
+	var start, end time.Time
+	var title string
+	switch kind {
+	case inlineToday, inlineTomorrow:
+		start = today
+		title = "📆 Сегодня"
+		if kind == inlineTomorrow {
+			start = today.AddDate(0, 0, 1)
+			title = "📆 Завтра"
+		}
+		end = start
+		title += fmt.Sprintf(", %s (%s)", start.Format("02.01"), weekdayName(start.Weekday()))
+	default:
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		end = start.AddDate(0, 0, 6)
+		title = fmt.Sprintf("📊 Неделя %s – %s", start.Format("02.01"), end.Format("02.01"))
+	}
+
+	schedules, role, label, err := inlineSchedules(user, group, start, end)
+	if err != nil {
+		return tgbotapi.InlineQueryResultArticle{}, err
+	}
+	var text string
+	if kind == inlineWeek {
+		text = FormatSchedulesByWeek(schedules, start, end, role, user)
+	} else {
+		text = FormatEnhancedDaySchedule(schedules, start, role)
+	}
+	text = truncateInlineText("<b>" + html.EscapeString(label) + "</b>\n" + text)
+
+	id := kind + "_" + start.Format("20060102")
+	if group != "" {
+		id += "_" + group
+	}
+	result := tgbotapi.NewInlineQueryResultArticleHTML(id, title, text)
+	result.Description = fmt.Sprintf("%s: занятий %d", label, len(schedules))
+	return result, nil
+}
+
+// answerInlineQuery отвечает на inline-запрос расписанием пользователя, который его отправил.
+func answerInlineQuery(query *tgbotapi.InlineQuery, bot Messenger, now time.Time) error {
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		IsPersonal:    true,
+		CacheTime:     inlineCacheSeconds,
+		Results:       []interface{}{},
+	}
+	user, _ := auth.GetUserByTelegramID(query.From.ID)
+	if user == nil {
+		// Кнопка над результатами открывает личный чат с ботом для входа
+		answer.SwitchPMText = "🔑 Войдите в бот, чтобы делиться расписанием"
+		answer.SwitchPMParameter = "login"
+		return bot.AnswerInlineQuery(answer)
+	}
+
+	kinds, group := parseInlineQuery(query.Query)
+	for _, kind := range kinds {
+		result, err := inlineResult(user, kind, group, now)
+		if errors.Is(err, errInlineForbidden) {
+			answer.Results = []interface{}{}
+			answer.SwitchPMText = "🚫 Нет доступа к расписанию группы " + group
+			answer.SwitchPMParameter = "start"
+			break
+		}
+		if err != nil {
+			fmt.Println("Ошибка inline-запроса:", err)
+			continue
+		}
+		answer.Results = append(answer.Results, result)
+	}
+	return bot.AnswerInlineQuery(answer)
+}
+
+// ProcessInlineQuery обрабатывает inline-запрос (@bot today, @bot week АА-23-01).
+func ProcessInlineQuery(query *tgbotapi.InlineQuery, bot Messenger) {
+	if err := answerInlineQuery(query, bot, wallClockNow()); err != nil {
+		fmt.Println("Ошибка ответа на inline-запрос:", err)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// inlineAnswers форматирует ответы на inline-запросы так, как их увидит пользователь.
+func (c *conversation) inlineAnswers() string {
+	c.t.Helper()
+	var sb strings.Builder
+	for _, call := range c.srv.CallsTo("answerInlineQuery") {
+		sb.WriteString("=== inline " + call.Params.Get("inline_query_id") + "\n")
+		if text := call.Params.Get("switch_pm_text"); text != "" {
+			sb.WriteString(fmt.Sprintf("[кнопка: %s → /start %s]\n", text, call.Params.Get("switch_pm_parameter")))
+		}
+		var results []struct {
+			ID          string `json:"id"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Content     struct {
+				Text string `json:"message_text"`
+			} `json:"input_message_content"`
+		}
+		if err := json.Unmarshal([]byte(call.Params.Get("results")), &results); err != nil {
+			c.t.Fatalf("results: %v", err)
+		}
+		for _, r := range results {
+			sb.WriteString(fmt.Sprintf("--- %s | %s | %s\n%s\n", r.ID, r.Title, r.Description, r.Content.Text))
+		}
+	}
+	return sb.String()
+}
+
+// inline отправляет inline-запрос от пользователя чата так, будто сейчас now.
+func (c *conversation) inline(query string, now time.Time) {
+	c.t.Helper()
+	q := &tgbotapi.InlineQuery{ID: query, From: &tgbotapi.User{ID: c.chatID}, Query: query}
+	if err := answerInlineQuery(q, c.bot, now); err != nil {
+		c.t.Fatalf("inline %q: %v", query, err)
+	}
+}
+
+func TestInlineScheduleVisibility(t *testing.T) {
+	now := time.Date(2025, 3, 17, 7, 0, 0, 0, time.UTC)
+	student := newConversation(t, 1007)
+	student.loggedIn("ST-0002", "secret12")
+	student.srv.Reset()
+	student.inline("today", now)
+	student.inline("tomorrow аа-23-01", now)
+	student.inline("week", now)
+	// Чужая группа недоступна, даже если ключевое слово верное
+	student.inline("today ББ-24-02", now)
+	student.goldenText("inline_student", student.inlineAnswers())
+
+	teacher := &conversation{t: t, bot: student.bot, srv: student.srv, chatID: 2006}
+	teacher.loggedIn("TH-0002", "teach123")
+	teacher.srv.Reset()
+	teacher.inline("", now)
+	teacher.inline("today АА-23-01", now)
+	teacher.goldenText("inline_teacher", teacher.inlineAnswers())
+
+	// Без входа результатов нет, только кнопка перехода в бот
+	guest := &conversation{t: t, bot: student.bot, srv: student.srv, chatID: 3001}
+	guest.srv.Reset()
+	guest.inline("week", now)
+	guest.goldenText("inline_guest", guest.inlineAnswers())
+}
+
+func TestParseInlineQuery(t *testing.T) {
+	kinds, group := parseInlineQuery("  Завтра аа-23-01 ")
+	if len(kinds) != 1 || kinds[0] != inlineTomorrow || group != "АА-23-01" {
+		t.Errorf("parseInlineQuery = %v, %q", kinds, group)
+	}
+	if kinds, _ := parseInlineQuery(""); len(kinds) != 3 {
+		t.Errorf("пустой запрос должен предлагать все виды: %v", kinds)
+	}
+	for _, q := range []string{"today сегодня", "week week", "Неделя WEEK"} {
+		if kinds, _ := parseInlineQuery(q); len(kinds) != 1 {
+			t.Errorf("parseInlineQuery(%q) = %v, ожидался один вид", q, kinds)
+		}
+	}
+	if kinds, _ := parseInlineQuery("week today неделя"); len(kinds) != 2 || kinds[0] != inlineWeek || kinds[1] != inlineToday {
+		t.Errorf("повторы должны отбрасываться с сохранением порядка: %v", kinds)
+	}
+}
@@ -0,0 +1,550 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Поля, которые преподаватель вводит текстом в диалоге редактирования
+const (
+	editAwaitTime = "time"
+	editAwaitRoom = "room"
+	editAwaitDesc = "desc"
+)
+
+const (
+	defaultLessonDuration = 90
+	maxAuditoryLen        = 20
+	maxDescriptionLen     = 200
+)
+
+var lessonTypeOptions = []string{"Лекция", "Практика", "Лабораторная", "Семинар"}
+
+// lessonEdit — состояние диалога редактирования занятия для одного чата.
+type lessonEdit struct {
+	Lesson   models.Schedule // редактируемая копия (teacher_reg_code — код, а не имя)
+	Original models.Schedule // занятие до изменений
+	IsNew    bool
+	TimeSet  bool                        // для нового занятия: время начала уже введено
+	Awaiting string                      // какое поле ждём текстом (пусто — ничего)
+	Pairs    []models.TeacherCourseGroup // пары курс/группа преподавателя для нового занятия
+	Day      time.Time                   // день, к которому вернуться после сохранения
+}
+
+var (
+	lessonEdits  = make(map[int64]*lessonEdit)
+	lessonEditMu sync.Mutex
+)
+
+// getLessonEdit возвращает копию состояния диалога: апдейты одного чата обрабатываются
+// параллельно, поэтому изменения сохраняются обратно через setLessonEdit.
+func getLessonEdit(chatID int64) *lessonEdit {
+	lessonEditMu.Lock()
+	defer lessonEditMu.Unlock()
+	e, ok := lessonEdits[chatID]
+	if !ok {
+		return nil
+	}
+	c := *e
+	return &c
+}
+
+func setLessonEdit(chatID int64, e *lessonEdit) {
+	lessonEditMu.Lock()
+	defer lessonEditMu.Unlock()
+	if e == nil {
+		delete(lessonEdits, chatID)
+		return
+	}
+	c := *e
+	lessonEdits[chatID] = &c
+}
+
+// lessonRef кодирует занятие в callback_data: "s<id>" для разовых занятий,
+// "r<ruleID>_<YYYYMMDD>" для занятий по правилу.
+func lessonRef(s models.Schedule) string {
+	if s.ID != 0 {
+		return fmt.Sprintf("s%d", s.ID)
+	}
+	return fmt.Sprintf("r%d_%s", s.RuleID, s.ScheduleTime.Format("20060102"))
+}
+
+// loadLessonByRef загружает занятие по ссылке из lessonRef.
+func loadLessonByRef(ref string) (models.Schedule, error) {
+	switch {
+	case strings.HasPrefix(ref, "s"):
+		id, err := strconv.ParseInt(ref[1:], 10, 64)
+		if err != nil {
+			return models.Schedule{}, err
+		}
+		return scheduling.GetLesson(id)
+	case strings.HasPrefix(ref, "r"):
+		parts := strings.SplitN(ref[1:], "_", 2)
+		if len(parts) != 2 {
+			return models.Schedule{}, fmt.Errorf("некорректная ссылка на занятие %q", ref)
+		}
+		ruleID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return models.Schedule{}, err
+		}
+		day, err := time.Parse("20060102", parts[1])
+		if err != nil {
+			return models.Schedule{}, err
+		}
+		return scheduling.GetRuleOccurrence(ruleID, day)
+	}
+	return models.Schedule{}, fmt.Errorf("некорректная ссылка на занятие %q", ref)
+}
+
+// buildTeacherEditRows добавляет к дневному расписанию преподавателя кнопки редактирования.
+func buildTeacherEditRows(schedules []models.Schedule, day time.Time) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, s := range schedules {
+		label := fmt.Sprintf("✏️ %s %s", s.ScheduleTime.Format("15:04"), s.Audience())
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, "edit_l_"+lessonRef(s)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Добавить занятие", "edit_add_"+day.Format("2006-01-02")),
+	))
+	return rows
+}
+
+// ProcessLessonEditCallback обрабатывает кнопки диалога редактирования (edit_*).
+// Возвращает false, если callback к редактированию не относится.
+func ProcessLessonEditCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	data := callback.Data
+	if !strings.HasPrefix(data, "edit_") {
+		return false
+	}
+	chatID := callback.Message.Chat.ID
+	if user.Role != "teacher" {
+		bot.AnswerCallback(callback.ID, "Редактирование доступно только преподавателям")
+		return true
+	}
+	action := strings.TrimPrefix(data, "edit_")
+	edit := getLessonEdit(chatID)
+
+	switch {
+	case strings.HasPrefix(action, "add_"):
+		day, err := time.Parse("2006-01-02", strings.TrimPrefix(action, "add_"))
+		if err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "")
+		startNewLesson(chatID, bot, user, day)
+		return true
+
+	case strings.HasPrefix(action, "pair_"):
+		idx, err := strconv.Atoi(strings.TrimPrefix(action, "pair_"))
+		if edit == nil || !edit.IsNew || err != nil || idx < 0 || idx >= len(edit.Pairs) {
+			bot.AnswerCallback(callback.ID, "Диалог устарел, начните заново")
+			return true
+		}
+		edit.Lesson.CourseID = edit.Pairs[idx].CourseID
+		edit.Lesson.GroupName = edit.Pairs[idx].GroupName
+		edit.Lesson.SubgroupID, edit.Lesson.SubgroupName = 0, ""
+		edit.Awaiting = editAwaitTime
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "")
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⏰ Введите время начала в формате ЧЧ:ММ (например, 09:45):"))
+		return true
+
+	case strings.HasPrefix(action, "l_"):
+		lesson, err := loadLessonByRef(strings.TrimPrefix(action, "l_"))
+		if err != nil {
+			bot.AnswerCallback(callback.ID, "Занятие не найдено")
+			return true
+		}
+		if lesson.TeacherRegCode != user.RegistrationCode {
+			bot.AnswerCallback(callback.ID, "Это занятие ведёт другой преподаватель")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "")
+		edit = &lessonEdit{Lesson: lesson, Original: lesson, Day: lesson.ScheduleTime}
+		setLessonEdit(chatID, edit)
+		showLessonEditCard(chatID, bot, user, edit)
+		return true
+	}
+
+	if edit == nil {
+		bot.AnswerCallback(callback.ID, "Диалог устарел, откройте занятие заново")
+		return true
+	}
+
+	switch {
+	case action == "card":
+		bot.AnswerCallback(callback.ID, "")
+		edit.Awaiting = ""
+		setLessonEdit(chatID, edit)
+		showLessonEditCard(chatID, bot, user, edit)
+	case action == "time":
+		edit.Awaiting = editAwaitTime
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "")
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID,
+			"⏰ Введите новое время: ЧЧ:ММ (тот же день) или ДД.ММ.ГГГГ ЧЧ:ММ:"))
+	case action == "room":
+		edit.Awaiting = editAwaitRoom
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "")
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "🚪 Введите номер аудитории:"))
+	case action == "desc":
+		edit.Awaiting = editAwaitDesc
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "")
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "💬 Введите описание занятия:"))
+	case action == "type":
+		bot.AnswerCallback(callback.ID, "")
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, t := range lessonTypeOptions {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(t, "edit_settype_"+t),
+			))
+		}
+		msg := tgbotapi.NewMessage(chatID, "📝 Выберите тип занятия:")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		sendAndTrackMessage(bot, msg)
+	case action == "sg":
+		subgroups, err := scheduling.ListSubgroups(edit.Lesson.GroupName)
+		if err != nil || len(subgroups) == 0 {
+			bot.AnswerCallback(callback.ID, "У группы нет подгрупп")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "")
+		rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Вся группа", "edit_setsg_0"),
+		)}
+		for _, sg := range subgroups {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Подгруппа "+sg.Name, fmt.Sprintf("edit_setsg_%d", sg.ID)),
+			))
+		}
+		msg := tgbotapi.NewMessage(chatID, "👥 Для кого проводится занятие?")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		sendAndTrackMessage(bot, msg)
+	case strings.HasPrefix(action, "setsg_"):
+		id, err := strconv.ParseInt(strings.TrimPrefix(action, "setsg_"), 10, 64)
+		if err != nil {
+			bot.AnswerCallback(callback.ID, "Неизвестная подгруппа")
+			return true
+		}
+		name := ""
+		if id != 0 {
+			sg, err := scheduling.GetSubgroup(id)
+			if err != nil || sg.GroupName != edit.Lesson.GroupName {
+				bot.AnswerCallback(callback.ID, "Подгруппа не найдена")
+				return true
+			}
+			name = sg.Name
+		}
+		edit.Lesson.SubgroupID, edit.Lesson.SubgroupName = id, name
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "Группа: "+edit.Lesson.Audience())
+		showLessonEditCard(chatID, bot, user, edit)
+	case strings.HasPrefix(action, "settype_"):
+		lessonType := strings.TrimPrefix(action, "settype_")
+		if !containsString(lessonTypeOptions, lessonType) {
+			bot.AnswerCallback(callback.ID, "Неизвестный тип занятия")
+			return true
+		}
+		edit.Lesson.LessonType = lessonType
+		setLessonEdit(chatID, edit)
+		bot.AnswerCallback(callback.ID, "Тип занятия: "+lessonType)
+		showLessonEditCard(chatID, bot, user, edit)
+	case action == "save", action == "force":
+		bot.AnswerCallback(callback.ID, "")
+		saveLessonEdit(chatID, bot, user, edit, action == "force")
+	case action == "cancel":
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, "❗ Отменить занятие?\n\n"+describeEditLesson(edit.Original))
+		msg.ParseMode = "HTML"
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Да, отменить", "edit_cancel_yes"),
+			tgbotapi.NewInlineKeyboardButtonData("◀️ Нет", "edit_card"),
+		))
+		sendAndTrackMessage(bot, msg)
+	case action == "cancel_yes":
+		bot.AnswerCallback(callback.ID, "")
+		cancelEditedLesson(chatID, bot, user, edit)
+	case action == "abort":
+		setLessonEdit(chatID, nil)
+		bot.AnswerCallback(callback.ID, "Изменения отменены")
+		ShowEnhancedScheduleDay(chatID, bot, user, edit.Day)
+	default:
+		bot.AnswerCallback(callback.ID, "Неизвестное действие")
+	}
+	return true
+}
+
+// startNewLesson начинает добавление занятия: преподаватель выбирает одну из своих пар курс/группа.
+func startNewLesson(chatID int64, bot Messenger, user *models.User, day time.Time) {
+	pairs, err := GetTeacherGroupsByRegCode(user.RegistrationCode)
+	if err != nil || len(pairs) == 0 {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ За вами не закреплено ни одного курса и группы."))
+		return
+	}
+	courseNames := teacherCourseNames(user.RegistrationCode)
+
+	edit := &lessonEdit{
+		IsNew: true,
+		Pairs: pairs,
+		Day:   day,
+		Lesson: models.Schedule{
+			TeacherRegCode: user.RegistrationCode,
+			ScheduleTime:   day,
+			Duration:       defaultLessonDuration,
+			LessonType:     lessonTypeOptions[0],
+		},
+	}
+	setLessonEdit(chatID, edit)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, p := range pairs {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s — %s", courseNames[p.CourseID], p.GroupName), fmt.Sprintf("edit_pair_%d", i)),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Отмена", "edit_abort"),
+	))
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("➕ Новое занятие на %s\n\nВыберите курс и группу:", day.Format("02.01.2006")))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrackMessage(bot, msg)
+}
+
+// processLessonEditMessage принимает текстовый ввод в диалоге редактирования.
+func processLessonEditMessage(chatID int64, bot Messenger, user *models.User, edit *lessonEdit, text string) {
+	text = strings.TrimSpace(text)
+	switch edit.Awaiting {
+	case editAwaitTime:
+		t, err := parseLessonTime(text, edit.Lesson.ScheduleTime)
+		if err != nil {
+			sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Не удалось разобрать время. Пример: 09:45 или 21.03.2025 09:45"))
+			return
+		}
+		edit.Lesson.ScheduleTime = t
+		edit.TimeSet = true
+	case editAwaitRoom:
+		if text == "" || len([]rune(text)) > maxAuditoryLen {
+			sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID,
+				fmt.Sprintf("⚠️ Номер аудитории должен быть от 1 до %d символов.", maxAuditoryLen)))
+			return
+		}
+		edit.Lesson.Auditory = text
+	case editAwaitDesc:
+		if len([]rune(text)) > maxDescriptionLen {
+			sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID,
+				fmt.Sprintf("⚠️ Описание не должно превышать %d символов.", maxDescriptionLen)))
+			return
+		}
+		edit.Lesson.Description = text
+	}
+	edit.Awaiting = ""
+	setLessonEdit(chatID, edit)
+	showLessonEditCard(chatID, bot, user, edit)
+}
+
+// parseLessonTime разбирает "ЧЧ:ММ" (в день занятия) или "ДД.ММ.ГГГГ ЧЧ:ММ".
+func parseLessonTime(text string, day time.Time) (time.Time, error) {
+	if t, err := time.Parse("02.01.2006 15:04", text); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC), nil
+}
+
+// showLessonEditCard показывает текущее состояние занятия и кнопки изменения полей.
+func showLessonEditCard(chatID int64, bot Messenger, user *models.User, edit *lessonEdit) {
+	var sb strings.Builder
+	if edit.IsNew {
+		sb.WriteString("➕ <b>Новое занятие</b>\n\n")
+	} else {
+		sb.WriteString("✏️ <b>Редактирование занятия</b>\n\n")
+	}
+	sb.WriteString(describeEditLesson(edit.Lesson))
+	if !edit.IsNew && edit.Lesson != edit.Original {
+		sb.WriteString("\n<i>Есть несохранённые изменения</i>\n")
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏰ Время", "edit_time"),
+			tgbotapi.NewInlineKeyboardButtonData("🚪 Аудитория", "edit_room"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📝 Тип", "edit_type"),
+			tgbotapi.NewInlineKeyboardButtonData("💬 Описание", "edit_desc"),
+		),
+	}
+	// Подгруппу можно выбрать, только если группа на них разделена
+	if groupHasSubgroups(edit.Lesson.GroupName) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👥 Подгруппа", "edit_sg"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Сохранить", "edit_save"),
+	))
+	if !edit.IsNew {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отменить занятие", "edit_cancel"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Выйти без сохранения", "edit_abort"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sendAndTrackMessage(bot, msg)
+}
+
+// describeEditLesson — описание занятия для карточки редактирования (HTML).
+func describeEditLesson(s models.Schedule) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📅 %s (%s)\n", s.ScheduleTime.Format("02.01.2006"), weekdayName(s.ScheduleTime.Weekday())))
+	sb.WriteString(fmt.Sprintf("⏰ %s - %s (%d мин.)\n", s.ScheduleTime.Format("15:04"), scheduling.End(s).Format("15:04"), s.Duration))
+	sb.WriteString(fmt.Sprintf("📚 %s — группа %s\n", html.EscapeString(teacherCourseNames(s.TeacherRegCode)[s.CourseID]), html.EscapeString(s.Audience())))
+	sb.WriteString(fmt.Sprintf("📝 Тип: %s\n", html.EscapeString(s.LessonType)))
+	sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", html.EscapeString(valueOrDash(s.Auditory))))
+	sb.WriteString(fmt.Sprintf("💬 Описание: %s\n", html.EscapeString(valueOrDash(s.Description))))
+	return sb.String()
+}
+
+// saveLessonEdit сохраняет занятие через сервис расписания. Пересечения показываются
+// преподавателю, сохранить поверх них можно только явным подтверждением.
+func saveLessonEdit(chatID int64, bot Messenger, user *models.User, edit *lessonEdit, force bool) {
+	if edit.IsNew && edit.Lesson.GroupName == "" {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Сначала выберите курс и группу."))
+		return
+	}
+	if edit.IsNew && !edit.TimeSet {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Укажите время начала занятия."))
+		return
+	}
+
+	lesson := edit.Lesson
+	var err error
+	switch {
+	case edit.IsNew:
+		err = scheduling.CreateLesson(&lesson, force, user.RegistrationCode)
+	case lesson.ID != 0:
+		err = scheduling.UpdateLesson(lesson, force, user.RegistrationCode)
+	default:
+		err = scheduling.OverrideOccurrence(&lesson, force, user.RegistrationCode)
+	}
+
+	var conflictErr *scheduling.ConflictError
+	if errors.As(err, &conflictErr) {
+		var sb strings.Builder
+		sb.WriteString("⚠️ Занятие пересекается с другими:\n\n")
+		for _, c := range conflictErr.Conflicts {
+			sb.WriteString("• " + c.String() + "\n")
+		}
+		msg := tgbotapi.NewMessage(chatID, sb.String())
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⚠️ Сохранить всё равно", "edit_force"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("◀️ Вернуться к занятию", "edit_card"),
+			),
+		)
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	if err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить занятие: "+err.Error()))
+		return
+	}
+
+	setLessonEdit(chatID, nil)
+	if edit.IsNew {
+		lessonChanged(nil, &lesson)
+	} else {
+		lessonChanged(&edit.Original, &lesson)
+	}
+	sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "✅ Занятие сохранено."))
+	ShowEnhancedScheduleDay(chatID, bot, user, lesson.ScheduleTime)
+}
+
+// cancelEditedLesson отменяет занятие: разовое удаляется, занятие по правилу (в том числе
+// уже изменённое или перенесённое) отменяется на свой день.
+func cancelEditedLesson(chatID int64, bot Messenger, user *models.User, edit *lessonEdit) {
+	if edit.IsNew {
+		return
+	}
+	var err error
+	if edit.Original.ID != 0 {
+		err = scheduling.DeleteLesson(edit.Original.ID, user.RegistrationCode)
+	} else {
+		err = scheduling.CancelOccurrence(edit.Original, user.RegistrationCode)
+	}
+	if err != nil {
+		sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ Не удалось отменить занятие: "+err.Error()))
+		return
+	}
+	setLessonEdit(chatID, nil)
+	lessonChanged(&edit.Original, nil)
+	sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "❌ Занятие отменено."))
+	ShowEnhancedScheduleDay(chatID, bot, user, edit.Day)
+}
+
+// lessonChanged вызывается после любого изменения занятия (before == nil — создано,
+// after == nil — отменено) и сбрасывает закешированное расписание затронутых групп и преподавателей.
+func lessonChanged(before, after *models.Schedule) {
+	for _, s := range []*models.Schedule{before, after} {
+		if s == nil {
+			continue
+		}
+		InvalidateScheduleCache(s.GroupName)
+		InvalidateScheduleCache(s.TeacherRegCode)
+	}
+}
+
+// teacherCourseNames возвращает названия курсов преподавателя по id.
+func teacherCourseNames(teacherRegCode string) map[int64]string {
+	names := make(map[int64]string)
+	courses, err := GetCoursesByTeacherRegCode(teacherRegCode)
+	if err != nil {
+		fmt.Println("Ошибка получения курсов преподавателя:", err)
+		return names
+	}
+	for _, c := range courses {
+		names[c.ID] = c.Name
+	}
+	return names
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
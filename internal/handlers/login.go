@@ -10,7 +10,7 @@ import (
 )
 
 // Remove duplicated comments
-func processLoginMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI, state, text string) {
+func processLoginMessage(update *tgbotapi.Update, bot Messenger, state, text string) {
 	chatID := update.Message.Chat.ID
 
 	// Храним временные данные логина в loginTempDataMap
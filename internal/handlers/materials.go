@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"education/internal/auth"
 	"education/internal/db"
 	"education/internal/models"
 	"fmt"
@@ -179,23 +180,19 @@ func FormatMaterials(materials []models.Material, mode string, currentPage, tota
 	// Получаем имена преподавателей (для студенческого режима)
 	teacherMap := make(map[string]string) // reg_code -> name
 	if mode == "student" {
-		teachers, err := db.DB.Query(`SELECT registration_code, name FROM users WHERE role = 'teacher'`)
-		if err != nil {
+		if teacherMap, err = auth.TeacherNames(); err != nil {
 			return "", err
 		}
-		defer teachers.Close()
-		for teachers.Next() {
-			var regCode, name string
-			if err := teachers.Scan(&regCode, &name); err != nil {
-				return "", err
-			}
-			teacherMap[regCode] = name
-		}
 	}
 
 	// Группируем материалы по курсам для более удобного отображения
+	// courseOrder сохраняет порядок появления курсов, чтобы вывод не зависел от обхода map
 	courseGroups := make(map[int64][]models.Material)
+	var courseOrder []int64
 	for _, m := range materials {
+		if _, ok := courseGroups[m.CourseID]; !ok {
+			courseOrder = append(courseOrder, m.CourseID)
+		}
 		courseGroups[m.CourseID] = append(courseGroups[m.CourseID], m)
 	}
 
@@ -203,7 +200,8 @@ func FormatMaterials(materials []models.Material, mode string, currentPage, tota
 	msgText := "📚 *Учебные материалы*\n\n"
 
 	// Для каждого курса выводим материалы
-	for courseID, courseMaterials := range courseGroups {
+	for _, courseID := range courseOrder {
+		courseMaterials := courseGroups[courseID]
 		courseName := courseMap[courseID]
 		if courseName == "" {
 			courseName = fmt.Sprintf("Курс #%d", courseID)
@@ -229,7 +227,7 @@ func FormatMaterials(materials []models.Material, mode string, currentPage, tota
 				if teacherName == "" {
 					teacherName = m.TeacherRegCode
 				}
-				msgText += fmt.Sprintf("    👨‍🏫 Преподаватель: %s\n", teacherName)
+				msgText += fmt.Sprintf("    👨‍🏫 Преподаватель: %s\n", tgbotapi.EscapeText(tgbotapi.ModeMarkdown, teacherName))
 			}
 
 			msgText += "\n"
@@ -291,7 +289,7 @@ func BuildMaterialsPaginationKeyboard(currentPage, totalPages int, filter string
 }
 
 // ShowMaterials отображает материалы с пагинацией и навигацией.
-func ShowMaterials(chatID int64, bot *tgbotapi.BotAPI, user *models.User) error {
+func ShowMaterials(chatID int64, bot Messenger, user *models.User) error {
 	if user == nil {
 		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему для просмотра материалов.")
 		return sendAndTrackMessage(bot, msg)
@@ -404,6 +402,17 @@ func ShowMaterials(chatID int64, bot *tgbotapi.BotAPI, user *models.User) error
 	// Создаем клавиатуру для навигации
 	keyboard := BuildMaterialsPaginationKeyboard(currentPage, totalPages, filter)
 
+	// Студентам — карточки преподавателей перед кнопкой главного меню
+	if mode == "student" {
+		if names, err := auth.TeacherNames(); err == nil {
+			if teacherRows := teacherButtonRows(materialTeachers(materials, names)); len(teacherRows) > 0 {
+				last := len(keyboard.InlineKeyboard) - 1
+				rows := append(teacherRows, keyboard.InlineKeyboard[last])
+				keyboard.InlineKeyboard = append(keyboard.InlineKeyboard[:last], rows...)
+			}
+		}
+	}
+
 	// Отправляем сообщение с материалами и клавиатурой
 	msg := tgbotapi.NewMessage(chatID, msgText)
 	msg.ParseMode = "Markdown"
@@ -414,7 +423,7 @@ func ShowMaterials(chatID int64, bot *tgbotapi.BotAPI, user *models.User) error
 }
 
 // ShowMaterialFilters отображает список курсов для фильтрации материалов.
-func ShowMaterialFilters(chatID int64, bot *tgbotapi.BotAPI, user *models.User) error {
+func ShowMaterialFilters(chatID int64, bot Messenger, user *models.User) error {
 	if user == nil {
 		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему.")
 		return sendAndTrackMessage(bot, msg)
@@ -495,7 +504,7 @@ func GetCoursesForGroup(group string) ([]models.Course, error) {
 }
 
 // ProcessMaterialsCallback обрабатывает коллбэки, связанные с материалами.
-func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, user *models.User) bool {
+func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
 	data := callback.Data
 	chatID := callback.Message.Chat.ID
 
@@ -504,7 +513,7 @@ func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.Bo
 		pageStr := strings.TrimPrefix(data, "mat_page_")
 		page, err := strconv.Atoi(pageStr)
 		if err != nil {
-			bot.Request(tgbotapi.NewCallback(callback.ID, "⚠️ Ошибка обработки страницы"))
+			bot.AnswerCallback(callback.ID, "⚠️ Ошибка обработки страницы")
 			return true
 		}
 
@@ -512,14 +521,14 @@ func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.Bo
 		materialPageState[chatID] = page
 		materialStateMutex.Unlock()
 
-		bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("📖 Страница %d", page)))
+		bot.AnswerCallback(callback.ID, fmt.Sprintf("📖 Страница %d", page))
 		ShowMaterials(chatID, bot, user)
 		return true
 	}
 
 	// Показать фильтры
 	if data == "mat_filter" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🔍 Выбор фильтра"))
+		bot.AnswerCallback(callback.ID, "🔍 Выбор фильтра")
 		ShowMaterialFilters(chatID, bot, user)
 		return true
 	}
@@ -531,7 +540,7 @@ func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.Bo
 		materialPageState[chatID] = 1 // Сбрасываем страницу на первую
 		materialStateMutex.Unlock()
 
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🔄 Фильтр сброшен"))
+		bot.AnswerCallback(callback.ID, "🔄 Фильтр сброшен")
 		ShowMaterials(chatID, bot, user)
 		return true
 	}
@@ -545,21 +554,21 @@ func ProcessMaterialsCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.Bo
 		materialPageState[chatID] = 1 // При изменении фильтра возвращаемся на первую страницу
 		materialStateMutex.Unlock()
 
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🔍 Фильтр установлен"))
+		bot.AnswerCallback(callback.ID, "🔍 Фильтр установлен")
 		ShowMaterials(chatID, bot, user)
 		return true
 	}
 
 	// Отмена действия в материалах
 	if data == "mat_cancel" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "❌ Отменено"))
+		bot.AnswerCallback(callback.ID, "❌ Отменено")
 		ShowMaterials(chatID, bot, user)
 		return true
 	}
 
 	// Переход в главное меню из материалов
 	if data == "menu_main" {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "🏠 Главное меню"))
+		bot.AnswerCallback(callback.ID, "🏠 Главное меню")
 		sendMainMenu(chatID, bot, user)
 		return true
 	}
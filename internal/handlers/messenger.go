@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Messenger — узкий интерфейс к Telegram, которым пользуются все обработчики.
+// В проде это обёртка над *tgbotapi.BotAPI, в тестах её можно подменить.
+type Messenger interface {
+	SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error)
+	EditMessage(edit tgbotapi.Chattable) error
+	DeleteMessage(chatID int64, messageID int) error
+	AnswerCallback(callbackID, text string) error
+	SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error)
+	DownloadFile(fileID string) ([]byte, error)
+	AnswerInlineQuery(answer tgbotapi.InlineConfig) error
+}
+
+// maxDownloadSize — Bot API отдаёт ботам файлы не больше 20 МБ.
+const maxDownloadSize = 20 << 20
+
+// botMessenger реализует Messenger поверх настоящего клиента Bot API.
+type botMessenger struct {
+	api          *tgbotapi.BotAPI
+	fileEndpoint string // шаблон адреса скачивания: токен и file_path
+}
+
+// NewBotMessenger оборачивает клиент Bot API в Messenger.
+func NewBotMessenger(api *tgbotapi.BotAPI) Messenger {
+	return &botMessenger{api: api, fileEndpoint: tgbotapi.FileEndpoint}
+}
+
+func (b *botMessenger) SendMessage(msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	return b.api.Send(msg)
+}
+
+// EditMessage принимает любую конфигурацию editMessage* (текст, клавиатура и т.д.).
+// Используется Request, так как для inline-сообщений Telegram возвращает true, а не Message.
+func (b *botMessenger) EditMessage(edit tgbotapi.Chattable) error {
+	_, err := b.api.Request(edit)
+	return err
+}
+
+func (b *botMessenger) DeleteMessage(chatID int64, messageID int) error {
+	_, err := b.api.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+	return err
+}
+
+func (b *botMessenger) AnswerCallback(callbackID, text string) error {
+	_, err := b.api.Request(tgbotapi.NewCallback(callbackID, text))
+	return err
+}
+
+func (b *botMessenger) SendDocument(doc tgbotapi.DocumentConfig) (tgbotapi.Message, error) {
+	return b.api.Send(doc)
+}
+
+// DownloadFile скачивает файл, присланный пользователем, по его file_id.
+func (b *botMessenger) DownloadFile(fileID string) ([]byte, error) {
+	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(b.fileEndpoint, b.api.Token, file.FilePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.api.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("скачивание файла: HTTP %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize))
+}
+
+func (b *botMessenger) AnswerInlineQuery(answer tgbotapi.InlineConfig) error {
+	_, err := b.api.Request(answer)
+	return err
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"testing"
+
+	"education/internal/tgtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newTestMessenger(t *testing.T) (Messenger, *tgtest.Server) {
+	t.Helper()
+	srv := tgtest.NewServer()
+	t.Cleanup(srv.Close)
+	api, err := srv.NewBot()
+	if err != nil {
+		t.Fatalf("NewBot: %v", err)
+	}
+	return &botMessenger{api: api, fileEndpoint: srv.FileEndpoint()}, srv
+}
+
+func TestBotMessengerRecordsCalls(t *testing.T) {
+	bot, srv := newTestMessenger(t)
+
+	msg := tgbotapi.NewMessage(42, "привет")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Неделя", "mode_week"),
+	))
+	sent, err := bot.SendMessage(msg)
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if sent.MessageID == 0 || sent.Chat.ID != 42 {
+		t.Fatalf("unexpected message: %+v", sent)
+	}
+	if err := bot.EditMessage(tgbotapi.NewEditMessageText(42, sent.MessageID, "пока")); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if err := bot.AnswerCallback("cb-1", "ok"); err != nil {
+		t.Fatalf("AnswerCallback: %v", err)
+	}
+	if err := bot.DeleteMessage(42, sent.MessageID); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	doc := tgbotapi.NewDocument(42, tgbotapi.FileBytes{Name: "a.txt", Bytes: []byte("data")})
+	if _, err := bot.SendDocument(doc); err != nil {
+		t.Fatalf("SendDocument: %v", err)
+	}
+
+	calls := srv.Calls()
+	want := []string{"sendMessage", "editMessageText", "answerCallbackQuery", "deleteMessage", "sendDocument"}
+	if len(calls) != len(want) {
+		t.Fatalf("got %d calls, want %d", len(calls), len(want))
+	}
+	for i, m := range want {
+		if calls[i].Method != m {
+			t.Errorf("call %d: got %s, want %s", i, calls[i].Method, m)
+		}
+	}
+	if got := calls[0].CallbackData(); len(got) != 1 || got[0] != "mode_week" {
+		t.Errorf("keyboard: got %v", got)
+	}
+	if got := string(calls[4].Files["document"]); got != "data" {
+		t.Errorf("document body: got %q", got)
+	}
+}
+
+func TestBotMessengerReturnsAPIErrors(t *testing.T) {
+	bot, srv := newTestMessenger(t)
+	srv.Fail("sendMessage", tgtest.Failure{Code: 429, Description: "Too Many Requests", RetryAfter: 3})
+
+	_, err := bot.SendMessage(tgbotapi.NewMessage(1, "x"))
+	apiErr, ok := err.(*tgbotapi.Error)
+	if !ok {
+		t.Fatalf("expected *tgbotapi.Error, got %T (%v)", err, err)
+	}
+	if apiErr.Code != 429 || apiErr.RetryAfter != 3 {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+}
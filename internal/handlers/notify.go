@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultNotifyWindow — сколько ждать после первого изменения, собирая следующие в одно сообщение.
+const DefaultNotifyWindow = time.Minute
+
+// changeNotifier рассылает студентам группы изменения расписания, объединяя изменения,
+// сделанные подряд, в одно сообщение на группу.
+type changeNotifier struct {
+	bot    Messenger
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string][]scheduling.Change // группа -> изменения в порядке поступления
+	timers  map[string]*time.Timer
+}
+
+func newChangeNotifier(bot Messenger, window time.Duration) *changeNotifier {
+	return &changeNotifier{
+		bot:     bot,
+		window:  window,
+		now:     wallClockNow,
+		pending: make(map[string][]scheduling.Change),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// StartChangeNotifier подписывает рассылку уведомлений на изменения расписания.
+// Возвращает функцию остановки.
+func StartChangeNotifier(bot Messenger, window time.Duration) (stop func()) {
+	n := newChangeNotifier(bot, window)
+	return scheduling.OnChange(n.add)
+}
+
+// add ставит изменение в очередь группы (или двух групп, если занятие перенесли в другую).
+func (n *changeNotifier) add(c scheduling.Change) {
+	groups := make(map[string]bool)
+	for _, s := range []*models.Schedule{c.Before, c.After} {
+		if s != nil && s.GroupName != "" {
+			groups[s.GroupName] = true
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for group := range groups {
+		n.pending[group] = mergeChange(n.pending[group], c)
+		if _, ok := n.timers[group]; !ok {
+			group := group
+			n.timers[group] = time.AfterFunc(n.window, func() { n.flush(group) })
+		}
+	}
+}
+
+// changeKey — идентификатор занятия для склейки последовательных изменений.
+func changeKey(s *models.Schedule) string {
+	if s == nil {
+		return ""
+	}
+	return lessonRef(*s)
+}
+
+// mergeChange склеивает изменение с предыдущим изменением того же занятия:
+// сохраняется исходное состояние «до» и последнее состояние «после» вместе с его автором.
+func mergeChange(list []scheduling.Change, c scheduling.Change) []scheduling.Change {
+	if key := changeKey(c.Before); key != "" {
+		for i := range list {
+			if changeKey(list[i].After) == key {
+				list[i].After, list[i].By, list[i].At = c.After, c.By, c.At
+				return list
+			}
+		}
+	}
+	return append(list, c)
+}
+
+// flush отправляет накопленные изменения группы всем её зарегистрированным студентам.
+func (n *changeNotifier) flush(group string) {
+	n.mu.Lock()
+	changes := n.pending[group]
+	delete(n.pending, group)
+	delete(n.timers, group)
+	n.mu.Unlock()
+
+	members, err := groupMembers(group)
+	if err != nil {
+		log.Printf("Ошибка получения студентов группы %s: %v", group, err)
+		return
+	}
+	// Студенты разных подгрупп получают только изменения своих занятий
+	texts := make(map[int64]string)
+	for _, m := range members {
+		text, ok := texts[m.SubgroupID]
+		if !ok {
+			text = formatGroupChanges(group, changesForSubgroup(changes, m.SubgroupID), n.now())
+			texts[m.SubgroupID] = text
+		}
+		if text == "" {
+			continue
+		}
+		msg := tgbotapi.NewMessage(m.ChatID, text)
+		msg.ParseMode = "HTML"
+		if _, err := n.bot.SendMessage(msg); err != nil {
+			log.Printf("Не удалось отправить уведомление в чат %d: %v", m.ChatID, err)
+		}
+	}
+}
+
+// groupMember — зарегистрированный студент группы.
+type groupMember struct {
+	ChatID     int64
+	SubgroupID int64
+}
+
+// groupMembers возвращает зарегистрированных студентов группы.
+// Пустой пароль означает, что студент ещё не прошёл регистрацию (telegram_id в сиде случайный).
+func groupMembers(group string) ([]groupMember, error) {
+	rows, err := db.DB.Query(`
+		SELECT telegram_id, COALESCE(subgroup_id, 0) FROM users
+		WHERE role = 'student' AND group_name = ? AND telegram_id <> 0 AND password <> ''
+		ORDER BY telegram_id`, group)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var members []groupMember
+	for rows.Next() {
+		var m groupMember
+		if err := rows.Scan(&m.ChatID, &m.SubgroupID); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// changesForSubgroup оставляет изменения, которые касаются студентов подгруппы subgroupID.
+func changesForSubgroup(changes []scheduling.Change, subgroupID int64) []scheduling.Change {
+	var result []scheduling.Change
+	for _, c := range changes {
+		if (c.Before != nil && c.Before.ForSubgroup(subgroupID)) || (c.After != nil && c.After.ForSubgroup(subgroupID)) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// formatGroupChanges формирует сообщение об изменениях. Изменения, которые ничего не меняют
+// в итоге или касаются только прошедших занятий, пропускаются; если не осталось ничего — "".
+func formatGroupChanges(group string, changes []scheduling.Change, now time.Time) string {
+	courseNames := make(map[int64]string)
+	if courses, err := GetAllCourses(); err == nil {
+		for _, c := range courses {
+			courseNames[c.ID] = c.Name
+		}
+	}
+
+	var items []string
+	for _, c := range changes {
+		if item := formatChange(c, courseNames, now); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return ""
+	}
+	// После массового импорта изменений может быть больше, чем помещается в одно сообщение
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔔 <b>Изменения в расписании группы %s</b>\n\n", group))
+	for i, item := range items {
+		if sb.Len()+len(item) > maxReportLen {
+			sb.WriteString(fmt.Sprintf("…и ещё %d", len(items)-i))
+			break
+		}
+		sb.WriteString(item + "\n\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n\n")
+}
+
+func formatChange(c scheduling.Change, courseNames map[int64]string, now time.Time) string {
+	b, a := c.Before, c.After
+	switch {
+	case b == nil && a == nil:
+		return ""
+	case b == nil:
+		if scheduling.End(*a).Before(now) {
+			return ""
+		}
+		return "➕ <b>Новое занятие:</b> " + lessonSummary(*a, courseNames)
+	case a == nil:
+		if scheduling.End(*b).Before(now) {
+			return ""
+		}
+		return "❌ <b>Отменено:</b> " + lessonSummary(*b, courseNames)
+	}
+	if scheduling.End(*b).Before(now) && scheduling.End(*a).Before(now) {
+		return ""
+	}
+
+	var diff []string
+	if !b.ScheduleTime.Equal(a.ScheduleTime) || b.Duration != a.Duration {
+		diff = append(diff, fmt.Sprintf("⏰ %s → %s", lessonTimeRange(*b), lessonTimeRange(*a)))
+	}
+	if b.Auditory != a.Auditory {
+		diff = append(diff, fmt.Sprintf("🚪 Аудитория: %s → %s", html.EscapeString(valueOrDash(b.Auditory)), html.EscapeString(valueOrDash(a.Auditory))))
+	}
+	if b.LessonType != a.LessonType {
+		diff = append(diff, fmt.Sprintf("📝 Тип: %s → %s", html.EscapeString(valueOrDash(b.LessonType)), html.EscapeString(valueOrDash(a.LessonType))))
+	}
+	if b.Description != a.Description {
+		diff = append(diff, fmt.Sprintf("💬 Описание: %s → %s", html.EscapeString(valueOrDash(b.Description)), html.EscapeString(valueOrDash(a.Description))))
+	}
+	if b.Audience() != a.Audience() {
+		diff = append(diff, fmt.Sprintf("👥 Группа: %s → %s", html.EscapeString(b.Audience()), html.EscapeString(a.Audience())))
+	}
+	if len(diff) == 0 {
+		return ""
+	}
+	return "🔄 <b>Изменено:</b> " + lessonSummary(*b, courseNames) + "\n" + strings.Join(diff, "\n")
+}
+
+func lessonSummary(s models.Schedule, courseNames map[int64]string) string {
+	name := courseNames[s.CourseID]
+	if name == "" {
+		name = "Занятие"
+	}
+	summary := fmt.Sprintf("%s, %s", html.EscapeString(name), lessonTimeRange(s))
+	if s.Auditory != "" {
+		summary += ", ауд. " + html.EscapeString(s.Auditory)
+	}
+	return summary
+}
+
+func lessonTimeRange(s models.Schedule) string {
+	return fmt.Sprintf("%s %s–%s", s.ScheduleTime.Format("02.01"), s.ScheduleTime.Format("15:04"), scheduling.End(s).Format("15:04"))
+}
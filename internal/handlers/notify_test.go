@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestChangeNotifierBatchesGroupChanges(t *testing.T) {
+	openTestDB(t)
+	resetHandlerState()
+	bot, srv := newTestMessenger(t)
+	if _, err := db.DB.Exec(`UPDATE users SET telegram_id = 2002 WHERE registration_code = 'ST-0002'`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Окно большое: отправку запускаем вручную
+	n := newChangeNotifier(bot, time.Hour)
+	n.now = func() time.Time { return time.Date(2025, 3, 16, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(scheduling.OnChange(n.add))
+
+	lesson, err := scheduling.GetLesson(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lesson.Auditory = "305"
+	if err := scheduling.UpdateLesson(lesson, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	lesson.ScheduleTime = lesson.ScheduleTime.Add(2 * time.Hour)
+	if err := scheduling.UpdateLesson(lesson, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduling.DeleteLesson(4, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Добавленное и тут же удалённое занятие в уведомление не попадает
+	extra := models.Schedule{CourseID: 2, GroupName: testGroup, TeacherRegCode: "TH-0002",
+		ScheduleTime: time.Date(2025, 3, 20, 8, 0, 0, 0, time.UTC), Duration: 90, LessonType: "Лекция"}
+	if err := scheduling.CreateLesson(&extra, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduling.DeleteLesson(extra.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	n.flush(testGroup)
+
+	sent := srv.CallsTo("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("ожидалось одно сообщение, отправлено %d", len(sent))
+	}
+	if sent[0].ChatID() != 2002 {
+		t.Errorf("сообщение ушло в чат %d, ожидался 2002", sent[0].ChatID())
+	}
+	text := sent[0].Params.Get("text")
+	for _, want := range []string{
+		"Изменения в расписании группы АА-23-01",
+		"Изменено:</b> Матем, 17.03 08:00–09:30, ауд. 101",
+		"⏰ 17.03 08:00–09:30 → 17.03 10:00–11:30",
+		"🚪 Аудитория: 101 → 305",
+		"Отменено:</b> Прог, 19.03 08:00–09:30, ауд. 202",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в уведомлении нет %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "20.03") {
+		t.Errorf("в уведомление попало удалённое новое занятие:\n%s", text)
+	}
+}
+
+func TestFormatChangeEscapesFreeText(t *testing.T) {
+	before := models.Schedule{CourseID: 1, GroupName: testGroup, Auditory: "A&B",
+		ScheduleTime: time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC), Duration: 90, Description: "x < y"}
+	after := before
+	after.Description = "<b>"
+	text := formatChange(scheduling.Change{Before: &before, After: &after},
+		map[int64]string{1: "R&D"}, time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC))
+	want := "🔄 <b>Изменено:</b> R&amp;D, 17.03 08:00–09:30, ауд. A&amp;B\n💬 Описание: x &lt; y → &lt;b&gt;"
+	if text != want {
+		t.Errorf("formatChange:\n%s\nожидалось:\n%s", text, want)
+	}
+}
+
+func TestChangeNotifierUsesWallClock(t *testing.T) {
+	saved := time.Local
+	time.Local = time.FixedZone("MSK", 3*60*60)
+	t.Cleanup(func() { time.Local = saved })
+
+	bot, _ := newTestMessenger(t)
+	n := newChangeNotifier(bot, time.Hour)
+	// По местному времени занятие закончилось полчаса назад, хотя в UTC оно ещё впереди
+	past := models.Schedule{CourseID: 1, GroupName: testGroup,
+		ScheduleTime: wallClockNow().Add(-2 * time.Hour), Duration: 90}
+	if text := formatChange(scheduling.Change{After: &past}, map[int64]string{1: "Матем"}, n.now()); text != "" {
+		t.Errorf("о прошедшем занятии не уведомляют, получено %q", text)
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/auth"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Кнопки быстрого доступа на reply-клавиатуре
+const (
+	nowButton  = "⏱ Сейчас"
+	nextButton = "⏭ Следующее"
+)
+
+// Виды карточек: текущее занятие со следующим или только следующее
+const (
+	cardNow  = "now"
+	cardNext = "next"
+)
+
+const (
+	// DefaultLiveCardInterval — как часто обновляются карточки /now и /next.
+	DefaultLiveCardInterval = time.Minute
+	// liveCardHorizon — карточка обновляется, только если до занятия не больше этого.
+	liveCardHorizon = 12 * time.Hour
+	// nextLessonDays — на сколько дней вперёд ищется следующее занятие.
+	nextLessonDays = 7
+)
+
+// liveCard — отправленная карточка /now или /next, которая обновляется до начала занятия.
+type liveCard struct {
+	MessageID int
+	Mode      string
+	Until     time.Time // после этого момента карточка обновляется в последний раз
+	Text      string    // последний отправленный текст
+}
+
+var (
+	// У чата одна живая карточка: новая команда заменяет прежнюю
+	liveCards   = make(map[int64]*liveCard)
+	liveCardsMu sync.Mutex
+)
+
+func setLiveCard(chatID int64, card *liveCard) {
+	liveCardsMu.Lock()
+	defer liveCardsMu.Unlock()
+	if card != nil {
+		liveCards[chatID] = card
+	} else {
+		delete(liveCards, chatID)
+	}
+}
+
+// lessonStatus возвращает идущее в момент now занятие пользователя и ближайшее следующее.
+func lessonStatus(user *models.User, now time.Time) (current, next *models.Schedule, err error) {
+	start := truncateToDay(now)
+	end := start.AddDate(0, 0, nextLessonDays)
+	var lessons []models.Schedule
+	if user.Role == "teacher" {
+		lessons, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, start, end)
+	} else {
+		lessons, err = GetSchedulesForStudentByDateRange(user, start, end)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(lessons, func(i, j int) bool { return lessons[i].ScheduleTime.Before(lessons[j].ScheduleTime) })
+	for i := range lessons {
+		l := &lessons[i]
+		switch {
+		case current == nil && !l.ScheduleTime.After(now) && scheduling.End(*l).After(now):
+			current = l
+		case next == nil && l.ScheduleTime.After(now):
+			next = l
+		}
+	}
+	return current, next, nil
+}
+
+// minutesUntil — целое число минут до t с округлением вверх (не меньше 1).
+func minutesUntil(t, now time.Time) int {
+	m := int((t.Sub(now) + time.Minute - 1) / time.Minute)
+	if m < 1 {
+		m = 1
+	}
+	return m
+}
+
+// formatLiveLesson — блок занятия карточки; дата выводится, если занятие не сегодня.
+func formatLiveLesson(s models.Schedule, role string, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("📚 <b>" + html.EscapeString(s.Description) + "</b>")
+	if s.LessonType != "" {
+		sb.WriteString(" (" + html.EscapeString(s.LessonType) + ")")
+	}
+	sb.WriteString("\n🕒 ")
+	if !truncateToDay(s.ScheduleTime).Equal(truncateToDay(now)) {
+		sb.WriteString(fmt.Sprintf("%s (%s) ", s.ScheduleTime.Format("02.01"), weekdayName(s.ScheduleTime.Weekday())))
+	}
+	sb.WriteString(s.ScheduleTime.Format("15:04") + "–" + scheduling.End(s).Format("15:04") + "\n")
+	sb.WriteString("🚪 Аудитория: " + html.EscapeString(valueOrDash(s.Auditory)) + "\n")
+	if role == "teacher" {
+		sb.WriteString("👥 Группа: " + html.EscapeString(s.Audience()) + "\n")
+	} else {
+		sb.WriteString("👨‍🏫 " + html.EscapeString(teacherName(s)) + "\n")
+	}
+	return sb.String()
+}
+
+// formatLiveCard собирает текст карточки и момент, до которого её стоит обновлять
+// (начало следующего занятия или, если его нет или оно нескоро, конец текущего).
+func formatLiveCard(mode, role string, current, next *models.Schedule, now time.Time) (string, time.Time) {
+	var sb strings.Builder
+	var until time.Time
+	if mode == cardNow {
+		sb.WriteString("⏱ <b>Сейчас</b>\n")
+		if current != nil {
+			sb.WriteString(formatLiveLesson(*current, role, now))
+			sb.WriteString("⏳ До конца: " + formatMinutes(minutesUntil(scheduling.End(*current), now)) + "\n")
+			until = scheduling.End(*current)
+		} else {
+			sb.WriteString("Сейчас занятия нет.\n")
+		}
+		sb.WriteString("\n⏭ <b>Дальше</b>\n")
+	} else {
+		sb.WriteString("⏭ <b>Следующее занятие</b>\n")
+	}
+	if next != nil {
+		sb.WriteString(formatLiveLesson(*next, role, now))
+		sb.WriteString("⏳ Начнётся через " + formatMinutes(minutesUntil(next.ScheduleTime, now)) + "\n")
+		// Если следующее занятие нескоро, карточка /now отсчитывает конец текущего
+		if until.IsZero() || next.ScheduleTime.Sub(now) <= liveCardHorizon {
+			until = next.ScheduleTime
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("В ближайшие %d дней занятий нет.\n", nextLessonDays))
+	}
+	return sb.String(), until
+}
+
+// liveCardFooter — строка о том, обновляется ли карточка.
+func liveCardFooter(live bool, now time.Time) string {
+	if live {
+		return "\n<i>🔄 Обновлено в " + now.Format("15:04") + "</i>"
+	}
+	return ""
+}
+
+// ShowLiveCard отправляет карточку /now или /next. Если до занятия не больше liveCardHorizon,
+// карточка запоминается и обновляется планировщиком до начала занятия.
+func ShowLiveCard(chatID int64, bot Messenger, user *models.User, mode string, now time.Time) error {
+	if user == nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему для просмотра расписания.")
+		return sendAndTrackMessage(bot, msg)
+	}
+	current, next, err := lessonStatus(user, now)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения расписания: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	text, until := formatLiveCard(mode, user.Role, current, next, now)
+	live := !until.IsZero() && until.Sub(now) <= liveCardHorizon
+	text += liveCardFooter(live, now)
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSuffix(text, "\n"))
+	msg.ParseMode = "HTML"
+	sent, err := sendAndTrack(bot, msg)
+	if err != nil {
+		return err
+	}
+
+	if live {
+		setLiveCard(chatID, &liveCard{MessageID: sent.MessageID, Mode: mode, Until: until, Text: msg.Text})
+	} else {
+		setLiveCard(chatID, nil)
+	}
+	return nil
+}
+
+// StartLiveCardUpdater запускает фоновое обновление карточек /now и /next. Возвращает функцию остановки.
+func StartLiveCardUpdater(bot Messenger, interval time.Duration) (stop func()) {
+	return runPeriodically(interval, func(now time.Time) { refreshLiveCards(bot, wallClock(now)) })
+}
+
+// refreshLiveCards пересчитывает и редактирует живые карточки. Когда занятие, до которого
+// шёл отсчёт, начинается, карточка обновляется в последний раз и больше не отслеживается.
+func refreshLiveCards(bot Messenger, now time.Time) {
+	liveCardsMu.Lock()
+	cards := make(map[int64]liveCard, len(liveCards))
+	for chatID, card := range liveCards {
+		cards[chatID] = *card
+	}
+	liveCardsMu.Unlock()
+
+	for chatID, card := range cards {
+		updated, keep := refreshLiveCard(bot, chatID, card, now)
+		liveCardsMu.Lock()
+		// Пока карточка обновлялась, пользователь мог запросить новую
+		if cur, ok := liveCards[chatID]; ok && cur.MessageID == card.MessageID {
+			if keep {
+				*cur = updated
+			} else {
+				delete(liveCards, chatID)
+			}
+		}
+		liveCardsMu.Unlock()
+	}
+}
+
+// refreshLiveCard редактирует одну карточку и сообщает, нужно ли обновлять её дальше.
+func refreshLiveCard(bot Messenger, chatID int64, card liveCard, now time.Time) (liveCard, bool) {
+	user, err := auth.GetUserByTelegramID(chatID)
+	if err != nil || user == nil {
+		return card, false
+	}
+	current, next, err := lessonStatus(user, now)
+	if err != nil {
+		log.Printf("Ошибка обновления карточки в чате %d: %v", chatID, err)
+		return card, true
+	}
+
+	var text string
+	var until time.Time
+	started := !now.Before(card.Until)
+	if started && card.Mode == cardNext {
+		// Отсчитывали время до занятия — теперь оно идёт
+		text = "⏭ <b>Следующее занятие</b>\n"
+		if current != nil {
+			text += formatLiveLesson(*current, user.Role, now)
+		}
+		text += "🔔 Занятие началось\n"
+	} else {
+		text, until = formatLiveCard(card.Mode, user.Role, current, next, now)
+		// Занятие могли перенести: отсчёт идёт до нового времени
+		started = started || until.IsZero() || until.Sub(now) > liveCardHorizon
+	}
+	text = strings.TrimSuffix(text+liveCardFooter(!started, now), "\n")
+
+	if text != card.Text {
+		edit := tgbotapi.NewEditMessageText(chatID, card.MessageID, text)
+		edit.ParseMode = "HTML"
+		if err := bot.EditMessage(edit); err != nil {
+			log.Printf("Не удалось обновить карточку в чате %d: %v", chatID, err)
+			return card, false
+		}
+		card.Text = text
+	}
+	card.Until = until
+	return card, !started
+}
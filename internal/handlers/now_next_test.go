@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/auth"
+)
+
+func TestLiveCardCountsDownToNextLesson(t *testing.T) {
+	c := newConversation(t, 1006)
+	c.loggedIn("ST-0002", "secret12")
+	user, err := auth.GetUserByTelegramID(c.chatID)
+	if err != nil || user == nil {
+		t.Fatal("студент не вошёл")
+	}
+
+	// 17.03 в 08:30 идёт первая пара, вторая начнётся в 09:45
+	at := func(clock string) time.Time {
+		ts, _ := time.Parse("2006-01-02 15:04", "2025-03-17 "+clock)
+		return ts
+	}
+	ShowLiveCard(c.chatID, c.bot, user, cardNow, at("08:30"))
+	refreshLiveCards(c.bot, at("09:00"))
+	refreshLiveCards(c.bot, at("09:00")) // текст не изменился — не редактируем
+	// Вторая пара началась: последнее обновление, дальше карточка не отслеживается
+	refreshLiveCards(c.bot, at("09:45"))
+	refreshLiveCards(c.bot, at("09:50"))
+	if len(liveCards) != 0 {
+		t.Errorf("карточка должна перестать обновляться: %+v", liveCards)
+	}
+	// Команда и кнопка клавиатуры работают по текущему времени, занятий в фикстурах уже нет
+	c.send("/now")
+	c.send(nextButton)
+	c.golden("live_card_student")
+}
+
+func TestLiveCardNextForTeacher(t *testing.T) {
+	c := newConversation(t, 2005)
+	c.loggedIn("TH-0002", "teach123")
+	user, err := auth.GetUserByTelegramID(c.chatID)
+	if err != nil || user == nil {
+		t.Fatal("преподаватель не вошёл")
+	}
+
+	ShowLiveCard(c.chatID, c.bot, user, cardNext, time.Date(2025, 3, 17, 9, 15, 0, 0, time.UTC))
+	refreshLiveCards(c.bot, time.Date(2025, 3, 17, 9, 45, 0, 0, time.UTC))
+	// Следующее занятие через два дня — отсчёт не обновляется
+	ShowLiveCard(c.chatID, c.bot, user, cardNext, time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC))
+	if len(liveCards) != 0 {
+		t.Errorf("далёкое занятие не должно отслеживаться: %+v", liveCards)
+	}
+	c.golden("live_card_teacher")
+}
+
+func TestMinutesUntil(t *testing.T) {
+	now := time.Date(2025, 3, 17, 9, 0, 30, 0, time.UTC)
+	for _, tc := range []struct {
+		at   time.Time
+		want int
+	}{
+		{now.Add(45 * time.Minute), 45},
+		{now.Add(44*time.Minute + time.Second), 45},
+		{now.Add(10 * time.Second), 1},
+		{now.Add(-time.Minute), 1},
+	} {
+		if got := minutesUntil(tc.at, now); got != tc.want {
+			t.Errorf("minutesUntil(%s) = %d, ожидалось %d", tc.at.Format("15:04:05"), got, tc.want)
+		}
+	}
+}
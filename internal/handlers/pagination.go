@@ -4,6 +4,7 @@ import (
 	"education/internal/db"
 	"education/internal/models"
 	"fmt"
+	"html"
 	"sort"
 	"time"
 
@@ -224,13 +225,13 @@ func FormatSchedulesGroupedByDay(
 			if mode == "teacher" {
 				msgText += fmt.Sprintf(
 					"  • <b>%s</b> — %s\n    👥 Гр.: %s, 🚪 Ауд.: %s, 📋 %s, ⏱ %d мин.\n",
-					timeStr, s.Description, s.GroupName, s.Auditory, s.LessonType, s.Duration,
+					timeStr, html.EscapeString(s.Description), html.EscapeString(s.GroupName), html.EscapeString(s.Auditory), html.EscapeString(s.LessonType), s.Duration,
 				)
 			} else {
 				// Для студента
 				msgText += fmt.Sprintf(
 					"  • <b>%s</b> — %s\n    👨‍🏫 Преп.: %s, 🚪 Ауд.: %s, 📋 %s, ⏱ %d мин.\n",
-					timeStr, s.Description, s.TeacherRegCode, s.Auditory, s.LessonType, s.Duration,
+					timeStr, html.EscapeString(s.Description), html.EscapeString(teacherName(s)), html.EscapeString(s.Auditory), html.EscapeString(s.LessonType), s.Duration,
 				)
 			}
 		}
@@ -249,7 +250,7 @@ func BuildModeSwitchKeyboard(currentMode string) tgbotapi.InlineKeyboardMarkup {
 	}{
 		{"День", "mode_day"},
 		{"Неделя", "mode_week"},
-		// Удаляем опцию "Месяц"
+		{"Месяц", "mode_month"},
 	}
 
 	var rows [][]tgbotapi.InlineKeyboardButton
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"education/internal/auth"
+	"education/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// profileField — поле профиля, которое преподаватель может изменить сам.
+type profileField struct {
+	Key    string // часть callback pedit_<key>
+	Label  string
+	Prompt string
+	MaxLen int // в символах
+}
+
+var profileFields = []profileField{
+	{"name", "✏️ Имя", "Как вас показывать студентам? Например: Ольга Петровна Волкова.", 100},
+	{"dept", "🏛 Кафедра", "Введите кафедру, например: Кафедра прикладной математики.", 100},
+	{"email", "📧 Email", "Введите адрес электронной почты.", 254},
+	{"tg", "✈️ Telegram", "Введите имя пользователя в Telegram, например @volkova.", 33},
+	{"office", "🚪 Кабинет", "Введите номер кабинета, например 305.", 30},
+	{"hours", "🕐 Консультации", "Когда вас можно найти? Например: Пн 14:00–15:30, Чт 10:00–11:00.", 200},
+}
+
+var telegramHandle = regexp.MustCompile(`^[A-Za-z0-9_]{5,32}$`)
+
+var (
+	// Чаты преподавателей, от которых ждём новое значение поля профиля (ключ поля)
+	profileEditInput   = make(map[int64]string)
+	profileEditInputMu sync.Mutex
+)
+
+func setProfileEditInput(chatID int64, field string) {
+	profileEditInputMu.Lock()
+	defer profileEditInputMu.Unlock()
+	if field != "" {
+		profileEditInput[chatID] = field
+	} else {
+		delete(profileEditInput, chatID)
+	}
+}
+
+func getProfileEditInput(chatID int64) string {
+	profileEditInputMu.Lock()
+	defer profileEditInputMu.Unlock()
+	return profileEditInput[chatID]
+}
+
+func findProfileField(key string) (profileField, bool) {
+	for _, f := range profileFields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return profileField{}, false
+}
+
+// applyProfileField проверяет введённое значение и записывает его в профиль. "-" очищает поле.
+func applyProfileField(p *models.TeacherProfile, f profileField, text string) error {
+	value := strings.Join(strings.Fields(text), " ")
+	if value == "-" {
+		value = ""
+	}
+	if utf8.RuneCountInString(value) > f.MaxLen {
+		return fmt.Errorf("слишком длинно: не больше %d символов", f.MaxLen)
+	}
+	switch f.Key {
+	case "name":
+		if value != "" && utf8.RuneCountInString(value) < 2 {
+			return errors.New("имя слишком короткое")
+		}
+		p.DisplayName = value
+	case "dept":
+		p.Department = value
+	case "email":
+		if value != "" {
+			addr, err := mail.ParseAddress(value)
+			if err != nil || addr.Address != value {
+				return errors.New("не похоже на адрес почты, пример: volkova@example.edu")
+			}
+		}
+		p.Email = value
+	case "tg":
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "https://"), "t.me/")
+		value = strings.TrimPrefix(value, "@")
+		if value != "" && !telegramHandle.MatchString(value) {
+			return errors.New("имя пользователя Telegram — от 5 до 32 латинских букв, цифр или _")
+		}
+		p.Telegram = value
+	case "office":
+		p.OfficeRoom = value
+	case "hours":
+		p.OfficeHours = value
+	}
+	return nil
+}
+
+// formatTeacherProfile — карточка преподавателя (HTML).
+func formatTeacherProfile(p *models.TeacherProfile) string {
+	var sb strings.Builder
+	sb.WriteString("👨‍🏫 <b>" + html.EscapeString(p.Title()) + "</b>\n")
+	if p.DisplayName != "" && p.Name != "" && p.DisplayName != p.Name {
+		sb.WriteString("<i>" + html.EscapeString(p.Name) + "</i>\n")
+	}
+	sb.WriteString("\n")
+
+	lines := []struct{ label, value string }{
+		{"🏛 Кафедра", html.EscapeString(p.Department)},
+		{"📧 Email", html.EscapeString(p.Email)},
+		{"✈️ Telegram", ""},
+		{"🚪 Кабинет", html.EscapeString(p.OfficeRoom)},
+		{"🕐 Консультации", html.EscapeString(p.OfficeHours)},
+	}
+	if p.Telegram != "" {
+		lines[2].value = fmt.Sprintf(`<a href="https://t.me/%s">@%s</a>`, p.Telegram, p.Telegram)
+	}
+	filled := false
+	for _, l := range lines {
+		if l.value != "" {
+			sb.WriteString(l.label + ": " + l.value + "\n")
+			filled = true
+		}
+	}
+	if !filled {
+		sb.WriteString("<i>Преподаватель пока не заполнил профиль.</i>\n")
+	}
+	return sb.String()
+}
+
+// teacherRef — преподаватель, упомянутый в расписании или материалах.
+type teacherRef struct {
+	Code string
+	Name string
+}
+
+// scheduleTeachers возвращает преподавателей занятий в порядке первого появления.
+func scheduleTeachers(schedules []models.Schedule) []teacherRef {
+	seen := make(map[string]bool)
+	var refs []teacherRef
+	for _, s := range schedules {
+		if s.TeacherRegCode == "" || seen[s.TeacherRegCode] {
+			continue
+		}
+		seen[s.TeacherRegCode] = true
+		refs = append(refs, teacherRef{Code: s.TeacherRegCode, Name: teacherName(s)})
+	}
+	return refs
+}
+
+// materialTeachers возвращает авторов материалов в порядке первого появления.
+func materialTeachers(materials []models.Material, names map[string]string) []teacherRef {
+	seen := make(map[string]bool)
+	var refs []teacherRef
+	for _, m := range materials {
+		if m.TeacherRegCode == "" || seen[m.TeacherRegCode] {
+			continue
+		}
+		seen[m.TeacherRegCode] = true
+		name := names[m.TeacherRegCode]
+		if name == "" {
+			name = m.TeacherRegCode
+		}
+		refs = append(refs, teacherRef{Code: m.TeacherRegCode, Name: name})
+	}
+	return refs
+}
+
+// teacherButtonRows — кнопки, открывающие карточки преподавателей, по две в ряд.
+func teacherButtonRows(refs []teacherRef) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, t := range refs {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("👨‍🏫 "+t.Name, "tprof_"+t.Code))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// teacherName возвращает имя преподавателя занятия, а если его нет — регистрационный код.
+func teacherName(s models.Schedule) string {
+	if s.TeacherName != "" {
+		return s.TeacherName
+	}
+	return s.TeacherRegCode
+}
+
+// ShowTeacherProfile показывает карточку преподавателя. Свою карточку преподаватель может редактировать.
+func ShowTeacherProfile(chatID int64, bot Messenger, user *models.User, regCode string) error {
+	p, err := auth.GetTeacherProfile(regCode)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения профиля: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	if p == nil {
+		msg := tgbotapi.NewMessage(chatID, "Преподаватель не найден.")
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	text := formatTeacherProfile(p)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.DisableWebPagePreview = true
+	if user.Role == "teacher" && user.RegistrationCode == regCode {
+		msg.Text += "\nТак вашу карточку видят студенты. Что изменить?"
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for i := 0; i < len(profileFields); i += 2 {
+			row := tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(profileFields[i].Label, "pedit_"+profileFields[i].Key))
+			if i+1 < len(profileFields) {
+				row = append(row,
+					tgbotapi.NewInlineKeyboardButtonData(profileFields[i+1].Label, "pedit_"+profileFields[i+1].Key))
+			}
+			rows = append(rows, row)
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessProfileCallback обрабатывает карточки преподавателей и редактирование своего профиля.
+// Возвращает true, если callback обработан.
+func ProcessProfileCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	switch {
+	case strings.HasPrefix(data, "tprof_"):
+		bot.AnswerCallback(callback.ID, "")
+		ShowTeacherProfile(chatID, bot, user, strings.TrimPrefix(data, "tprof_"))
+	case data == "menu_profile":
+		if user.Role != "teacher" {
+			bot.AnswerCallback(callback.ID, "Доступно только преподавателям")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "👤 Мой профиль")
+		ShowTeacherProfile(chatID, bot, user, user.RegistrationCode)
+	case strings.HasPrefix(data, "pedit_"):
+		if user.Role != "teacher" {
+			bot.AnswerCallback(callback.ID, "Доступно только преподавателям")
+			return true
+		}
+		f, ok := findProfileField(strings.TrimPrefix(data, "pedit_"))
+		if !ok {
+			bot.AnswerCallback(callback.ID, "Неизвестное поле")
+			return true
+		}
+		setProfileEditInput(chatID, f.Key)
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, f.Prompt+"\n\nОтправьте «-», чтобы очистить поле, или /cancel для отмены.")
+		sendAndTrackMessage(bot, msg)
+	default:
+		return false
+	}
+	return true
+}
+
+// processProfileEditMessage принимает новое значение поля профиля.
+func processProfileEditMessage(chatID int64, bot Messenger, user *models.User, text string) {
+	f, ok := findProfileField(getProfileEditInput(chatID))
+	if !ok || user.Role != "teacher" {
+		setProfileEditInput(chatID, "")
+		return
+	}
+	p, err := auth.GetTeacherProfile(user.RegistrationCode)
+	if err == nil && p == nil {
+		err = errors.New("преподаватель не найден")
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения профиля: "+err.Error())
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	if err := applyProfileField(p, f, text); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+". Попробуйте ещё раз (или /cancel):")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	if err := auth.SaveTeacherProfile(p); err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка сохранения профиля: "+err.Error())
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	setProfileEditInput(chatID, "")
+	// Имя преподавателя хранится в кешированном расписании всех его групп
+	ClearScheduleCache()
+	ShowTeacherProfile(chatID, bot, user, user.RegistrationCode)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"education/internal/auth"
+	"education/internal/models"
+)
+
+func TestTeacherEditsProfile(t *testing.T) {
+	c := newConversation(t, 2006)
+	c.loggedIn("TH-0002", "teach123")
+
+	c.press("menu_profile")
+	c.press("pedit_email")
+	c.send("kozlov at example")
+	c.send("kozlov@example.edu")
+	c.press("pedit_name")
+	c.send("  Павел   Андреевич Козлов ")
+	c.press("pedit_tg")
+	c.send("https://t.me/p_kozlov")
+	c.golden("teacher_profile_edit")
+
+	p, err := auth.GetTeacherProfile("TH-0002")
+	if err != nil || p == nil {
+		t.Fatalf("профиль не найден: %v", err)
+	}
+	if p.DisplayName != "Павел Андреевич Козлов" || p.Email != "kozlov@example.edu" || p.Telegram != "p_kozlov" {
+		t.Errorf("неверный профиль: %+v", p)
+	}
+
+	// «-» очищает поле, отображаемое имя возвращается к ФИО
+	c.press("pedit_name")
+	c.send("-")
+	if p, _ := auth.GetTeacherProfile("TH-0002"); p.Title() != "Павел Козлов" {
+		t.Errorf("ожидалось ФИО после очистки имени, получено %q", p.Title())
+	}
+}
+
+func TestStudentOpensTeacherCard(t *testing.T) {
+	c := newConversation(t, 1006)
+	if err := auth.SaveTeacherProfile(&models.TeacherProfile{
+		RegistrationCode: "TH-0002", DisplayName: "Павел Андреевич Козлов", Department: "Кафедра программирования",
+		Email: "kozlov@example.edu", OfficeRoom: "305", OfficeHours: "Чт 14:00–15:30",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c.loggedIn("ST-0002", "secret12")
+
+	c.press("day_2025-03-17")
+	c.press("tprof_TH-0002")
+	c.press("tprof_TH-0001")
+	c.golden("teacher_profile_card")
+
+	c.srv.Reset()
+	c.press("pedit_name")
+	calls := c.srv.CallsTo("answerCallbackQuery")
+	if len(calls) != 1 || calls[0].Params.Get("text") != "Доступно только преподавателям" {
+		t.Errorf("студент не должен редактировать профиль: %+v", calls)
+	}
+	if getProfileEditInput(1006) != "" {
+		t.Error("студент не должен ожидать ввода поля профиля")
+	}
+}
+
+func TestApplyProfileField(t *testing.T) {
+	field := func(key string) profileField {
+		f, ok := findProfileField(key)
+		if !ok {
+			t.Fatalf("нет поля %s", key)
+		}
+		return f
+	}
+	var p models.TeacherProfile
+	for _, tc := range []struct {
+		key, text string
+		ok        bool
+	}{
+		{"email", "volkova@example.edu", true},
+		{"email", "Ольга <volkova@example.edu>", false},
+		{"tg", "@volkova", true},
+		{"tg", "@vol", false},
+		{"name", "В", false},
+		{"office", strings.Repeat("1", 31), false},
+		{"hours", "-", true},
+	} {
+		if err := applyProfileField(&p, field(tc.key), tc.text); (err == nil) != tc.ok {
+			t.Errorf("%s %q: ошибка %v", tc.key, tc.text, err)
+		}
+	}
+	if p.Email != "volkova@example.edu" || p.Telegram != "volkova" {
+		t.Errorf("неверный профиль: %+v", p)
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	panicDedupWindow   = 10 * time.Minute // одинаковые паники внутри окна не дублируются
+	panicReportsPerMin = 5                // не больше отчётов в админ-чат за минуту
+	maxReportStackLen  = 3000             // лимит сообщения Telegram — 4096 символов
+)
+
+// panicReporter отправляет отчёты о паниках в админ-чат с дедупликацией и ограничением частоты.
+type panicReporter struct {
+	mu          sync.Mutex
+	adminChatID int64
+	lastSent    map[string]time.Time // ключ паники -> время последнего отчёта
+	suppressed  map[string]int       // сколько раз паника повторилась без отчёта
+	recent      []time.Time          // время отчётов за последнюю минуту
+}
+
+var reporter = &panicReporter{
+	lastSent:   make(map[string]time.Time),
+	suppressed: make(map[string]int),
+}
+
+// SetAdminChatID задаёт чат, куда уходят отчёты об ошибках (0 — не отправлять).
+func SetAdminChatID(chatID int64) {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	reporter.adminChatID = chatID
+}
+
+// HandleUpdate обрабатывает один апдейт, изолируя панику в рамках этого апдейта.
+func HandleUpdate(update tgbotapi.Update, bot Messenger) {
+	defer recoverUpdate(update, bot)
+
+	if chatID := updateChatID(update); chatID != 0 {
+		clearUndeliverable(chatID)
+	}
+	if !allowUpdate(update, bot) {
+		return
+	}
+	if update.CallbackQuery != nil {
+		ProcessCallback(update.CallbackQuery, bot)
+	}
+	if update.Message != nil {
+		ProcessMessage(&update, bot)
+	}
+	if update.InlineQuery != nil {
+		ProcessInlineQuery(update.InlineQuery, bot)
+	}
+}
+
+// recoverUpdate перехватывает панику: пишет стек в лог, извиняется перед пользователем
+// и сообщает администратору.
+func recoverUpdate(update tgbotapi.Update, bot Messenger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	log.Printf("Паника при обработке апдейта %d: %v\n%s", update.UpdateID, r, stack)
+
+	chatID := updateChatID(update)
+	if update.CallbackQuery != nil {
+		bot.AnswerCallback(update.CallbackQuery.ID, "⚠️ Произошла ошибка")
+	}
+	if chatID != 0 {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Что-то пошло не так. Мы уже разбираемся, попробуйте ещё раз чуть позже.")
+		sendAndTrackMessage(bot, msg)
+	}
+
+	reporter.report(bot, r, stack, chatID, time.Now())
+}
+
+// updateChatID определяет чат, из которого пришёл апдейт.
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil && update.Message.Chat != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil && update.CallbackQuery.Message.Chat != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.From != nil:
+		return update.CallbackQuery.From.ID
+	case update.InlineQuery != nil && update.InlineQuery.From != nil:
+		return update.InlineQuery.From.ID
+	}
+	return 0
+}
+
+// report отправляет отчёт, если такая паника не отправлялась недавно и лимит не исчерпан.
+func (p *panicReporter) report(bot Messenger, r interface{}, stack []byte, chatID int64, now time.Time) {
+	p.mu.Lock()
+	if p.adminChatID == 0 {
+		p.mu.Unlock()
+		return
+	}
+	p.prune(now)
+	key := panicKey(r, stack)
+	if last, ok := p.lastSent[key]; ok && now.Sub(last) < panicDedupWindow {
+		p.suppressed[key]++
+		p.mu.Unlock()
+		return
+	}
+
+	// Скользящее окно в одну минуту для общего лимита отчётов
+	var recent []time.Time
+	for _, t := range p.recent {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	p.recent = recent
+	if len(p.recent) >= panicReportsPerMin {
+		p.suppressed[key]++
+		p.mu.Unlock()
+		return
+	}
+
+	repeats := p.suppressed[key]
+	delete(p.suppressed, key)
+	p.lastSent[key] = now
+	p.recent = append(p.recent, now)
+	adminChatID := p.adminChatID
+	p.mu.Unlock()
+
+	trace := string(stack)
+	if len(trace) > maxReportStackLen {
+		trace = trace[:maxReportStackLen] + "\n…"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🚨 Паника: %v\n", r))
+	sb.WriteString(fmt.Sprintf("Чат: %d\n", chatID))
+	if repeats > 0 {
+		sb.WriteString(fmt.Sprintf("Повторялась ещё %d раз с прошлого отчёта\n", repeats))
+	}
+	sb.WriteString("\n" + trace)
+
+	if _, err := bot.SendMessage(tgbotapi.NewMessage(adminChatID, sb.String())); err != nil {
+		log.Printf("Не удалось отправить отчёт об ошибке: %v", err)
+	}
+}
+
+// prune удаляет записи, окно дедупликации которых истекло, чтобы карты не росли всё время
+// работы процесса. Вызывается под p.mu.
+func (p *panicReporter) prune(now time.Time) {
+	for key, last := range p.lastSent {
+		if now.Sub(last) >= panicDedupWindow {
+			delete(p.lastSent, key)
+			delete(p.suppressed, key)
+		}
+	}
+	// Паники, пропущенные только из-за общего лимита, забываем, когда минута лимита прошла
+	if len(p.recent) == 0 || now.Sub(p.recent[len(p.recent)-1]) >= time.Minute {
+		for key := range p.suppressed {
+			if _, ok := p.lastSent[key]; !ok {
+				delete(p.suppressed, key)
+			}
+		}
+	}
+}
+
+// panicKey строит ключ дедупликации: значение паники и место, где она произошла.
+func panicKey(r interface{}, stack []byte) string {
+	return fmt.Sprintf("%v@%s", r, panicLocation(stack))
+}
+
+// panicLocation возвращает место паники: первый кадр стека после panic(), не относящийся к runtime.
+func panicLocation(stack []byte) string {
+	lines := strings.Split(string(stack), "\n")
+	afterPanic := false
+	for i := 0; i+1 < len(lines); i++ {
+		fn := lines[i]
+		if strings.HasPrefix(fn, "panic(") {
+			afterPanic = true
+			continue
+		}
+		if !afterPanic || strings.HasPrefix(fn, "\t") || strings.HasPrefix(fn, "runtime.") {
+			continue
+		}
+		// Строка с файлом идёт следом за функцией; смещение "+0x..." отбрасываем
+		loc := strings.TrimSpace(lines[i+1])
+		if j := strings.Index(loc, " +0x"); j > 0 {
+			loc = loc[:j]
+		}
+		return loc
+	}
+	return ""
+}
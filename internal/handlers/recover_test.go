@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/tgtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// brokenCallback — колбэк без Message: ProcessCallback падает на callback.Message.Chat.
+func brokenCallback() tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   "cb-broken",
+		From: &tgbotapi.User{ID: 77},
+		Data: "week_today",
+	}}
+}
+
+func TestHandleUpdateRecoversAndReportsOnce(t *testing.T) {
+	openTestDB(t)
+	resetHandlerState()
+	bot, srv := newTestMessenger(t)
+	reporter = &panicReporter{lastSent: map[string]time.Time{}, suppressed: map[string]int{}}
+	SetAdminChatID(999)
+	t.Cleanup(func() { SetAdminChatID(0) })
+
+	HandleUpdate(brokenCallback(), bot)
+	HandleUpdate(brokenCallback(), bot)
+
+	var userMsgs, adminMsgs []tgtest.Call
+	for _, c := range srv.CallsTo("sendMessage") {
+		switch c.ChatID() {
+		case 77:
+			userMsgs = append(userMsgs, c)
+		case 999:
+			adminMsgs = append(adminMsgs, c)
+		}
+	}
+	if len(userMsgs) != 2 {
+		t.Errorf("пользователь должен получить сообщение об ошибке на каждую панику, получено %d", len(userMsgs))
+	}
+	if len(adminMsgs) != 1 {
+		t.Fatalf("ожидался один отчёт в админ-чат, получено %d", len(adminMsgs))
+	}
+	if !strings.Contains(adminMsgs[0].Text(), "nil pointer dereference") {
+		t.Errorf("в отчёте нет текста паники: %q", adminMsgs[0].Text())
+	}
+	if got := len(srv.CallsTo("answerCallbackQuery")); got != 2 {
+		t.Errorf("ожидалось 2 ответа на колбэк, получено %d", got)
+	}
+}
+
+func TestUnauthenticatedWeekTodayDoesNotPanic(t *testing.T) {
+	c := newConversation(t, 1010)
+	HandleUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "cb",
+		From:    &tgbotapi.User{ID: c.chatID},
+		Data:    "week_today",
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: c.chatID}},
+	}}, c.bot)
+
+	msgs := c.srv.CallsTo("sendMessage")
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Text(), "Необходимо войти") {
+		t.Fatalf("ожидалась просьба войти, получено %+v", msgs)
+	}
+}
+
+func TestPanicReporterForgetsExpiredPanics(t *testing.T) {
+	bot, _ := newTestMessenger(t)
+	now := time.Date(2025, 3, 17, 12, 0, 0, 0, time.UTC)
+	p := &panicReporter{adminChatID: 999, lastSent: map[string]time.Time{}, suppressed: map[string]int{}}
+	for i := 0; i < 20; i++ {
+		p.report(bot, i, nil, 1, now)
+	}
+	if len(p.lastSent) != panicReportsPerMin || len(p.suppressed) != 20-panicReportsPerMin {
+		t.Fatalf("до истечения окна: отправлено %d, отложено %d", len(p.lastSent), len(p.suppressed))
+	}
+
+	p.report(bot, "новая", nil, 1, now.Add(panicDedupWindow))
+	if len(p.lastSent) != 1 || len(p.suppressed) != 0 {
+		t.Errorf("устаревшие записи не удалены: отправлено %d, отложено %d", len(p.lastSent), len(p.suppressed))
+	}
+}
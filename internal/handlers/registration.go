@@ -10,7 +10,7 @@ import (
 )
 
 // processRegistrationMessage — обрабатывает ввод от пользователя в ходе регистрации.
-func processRegistrationMessage(update *tgbotapi.Update, bot *tgbotapi.BotAPI, state, text string) {
+func processRegistrationMessage(update *tgbotapi.Update, bot Messenger, state, text string) {
 	chatID := update.Message.Chat.ID
 	tempData, ok := userTempDataMap[chatID]
 	if !ok {
@@ -200,7 +200,7 @@ func completeTeacherRegistration(chatID int64, userID int64, password, faculty s
 	return nil
 }
 
-func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI) {
+func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot Messenger) {
 	chatID := callback.Message.Chat.ID
 	data := callback.Data
 
@@ -211,7 +211,7 @@ func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi
 			callback.Message.MessageID,
 			tgbotapi.InlineKeyboardMarkup{},
 		)
-		bot.Request(edit)
+		bot.EditMessage(edit)
 
 		if userStates[chatID] != "" {
 			delete(userStates, chatID)
@@ -233,7 +233,7 @@ func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi
 	// --- 1) Проверяем наличие состояния регистрации ---
 	state, exists := userStates[chatID]
 	if !exists {
-		bot.Request(tgbotapi.NewCallback(callback.ID, "Нечего выбирать в данный момент."))
+		bot.AnswerCallback(callback.ID, "Нечего выбирать в данный момент.")
 		return
 	}
 
@@ -243,7 +243,7 @@ func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi
 		callback.Message.MessageID,
 		tgbotapi.InlineKeyboardMarkup{},
 	)
-	bot.Request(edit)
+	bot.EditMessage(edit)
 
 	// --- 3) Обрабатываем шаг регистрации ---
 	switch state {
@@ -251,18 +251,18 @@ func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi
 		if data == "role_student" {
 			userTempDataMap[chatID].Role = "student"
 			userStates[chatID] = StateWaitingForFaculty
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Студент выбран"))
+			bot.AnswerCallback(callback.ID, "Студент выбран")
 			sendFacultySelection(chatID, bot)
 		} else if data == "role_teacher" {
 			userTempDataMap[chatID].Role = "teacher"
 			userStates[chatID] = StateWaitingForFaculty
-			bot.Request(tgbotapi.NewCallback(callback.ID, "Преподаватель выбран"))
+			bot.AnswerCallback(callback.ID, "Преподаватель выбран")
 			sendFacultySelection(chatID, bot)
 		}
 
 	case StateWaitingForFaculty:
 		userTempDataMap[chatID].Faculty = data
-		bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("✅ Факультет '%s' выбран", data)))
+		bot.AnswerCallback(callback.ID, fmt.Sprintf("✅ Факультет '%s' выбран", data))
 		if userTempDataMap[chatID].Role == "teacher" {
 			userStates[chatID] = StateTeacherWaitingForPass
 			msg := tgbotapi.NewMessage(chatID, "🔐 Введите ваш регистрационный код (например, TR-345):")
@@ -275,7 +275,7 @@ func RegistrationProcessCallback(callback *tgbotapi.CallbackQuery, bot *tgbotapi
 	case StateWaitingForGroup:
 		userTempDataMap[chatID].Group = data
 		userStates[chatID] = StateWaitingForPass
-		bot.Request(tgbotapi.NewCallback(callback.ID, fmt.Sprintf("✅ Группа '%s' выбрана", data)))
+		bot.AnswerCallback(callback.ID, fmt.Sprintf("✅ Группа '%s' выбрана", data))
 		msg := tgbotapi.NewMessage(chatID, "🔐 Введите ваш регистрационный код (например, ST-4506):")
 		sendAndTrackMessage(bot, msg)
 		return
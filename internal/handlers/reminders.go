@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reminderPresets — варианты, за сколько минут до занятия можно получать напоминание (по возрастанию).
+var reminderPresets = []int{5, 10, 15, 30, 60, 120}
+
+// DefaultReminderInterval — как часто планировщик проверяет ближайшие занятия.
+const DefaultReminderInterval = time.Minute
+
+// reminderRecipient — пользователь, включивший напоминания.
+type reminderRecipient struct {
+	TelegramID       int64
+	Role             string
+	Group            string
+	SubgroupID       int64
+	RegistrationCode string
+	Offsets          []int // по возрастанию
+}
+
+// StartReminderScheduler запускает фоновую рассылку напоминаний. Возвращает функцию остановки.
+func StartReminderScheduler(bot Messenger, interval time.Duration) (stop func()) {
+	return runPeriodically(interval, func(now time.Time) { sendDueReminders(bot, wallClock(now)) })
+}
+
+// sendDueReminders отправляет напоминания, срок которых наступил к моменту now.
+// Занятия каждый раз берутся из расписания заново, поэтому перенесённое или отменённое
+// после прошлой проверки занятие обрабатывается по актуальному времени.
+func sendDueReminders(bot Messenger, now time.Time) {
+	recipients, err := reminderRecipients()
+	if err != nil {
+		log.Printf("Ошибка загрузки настроек напоминаний: %v", err)
+		return
+	}
+
+	// Расписание группы или преподавателя загружаем один раз за проход
+	lessons := make(map[string][]models.Schedule)
+	for _, r := range recipients {
+		key := fmt.Sprintf("%s:%s:%d:%s", r.Role, r.Group, r.SubgroupID, r.RegistrationCode)
+		list, ok := lessons[key]
+		if !ok {
+			maxOffset := time.Duration(r.Offsets[len(r.Offsets)-1]) * time.Minute
+			if r.Role == "teacher" {
+				list, err = GetSchedulesForTeacherByDateRange(r.RegistrationCode, now, now.Add(maxOffset))
+			} else {
+				list, err = GetSchedulesForGroupByDateRange(r.Group, now, now.Add(maxOffset))
+				list = filterSubgroup(list, r.SubgroupID)
+			}
+			if err != nil {
+				log.Printf("Ошибка загрузки расписания для напоминаний (%s): %v", r.RegistrationCode, err)
+				continue
+			}
+			lessons[key] = list
+		}
+		for _, s := range list {
+			remindAboutLesson(bot, r, s, now)
+		}
+	}
+
+	// Записи о прошедших занятиях больше не нужны
+	if _, err := db.DB.Exec(`DELETE FROM sent_reminders WHERE lesson_time < ?`,
+		now.Add(-24*time.Hour).Format(time.RFC3339)); err != nil {
+		log.Printf("Ошибка очистки отправленных напоминаний: %v", err)
+	}
+}
+
+// remindAboutLesson отправляет одно напоминание о занятии, если наступил срок хотя бы одного
+// из отступов. После простоя бота несколько отступов могут наступить сразу — тогда
+// отправляется одно сообщение, а все наступившие отступы помечаются отправленными.
+func remindAboutLesson(bot Messenger, r reminderRecipient, s models.Schedule, now time.Time) {
+	if !s.ScheduleTime.After(now) {
+		return
+	}
+	lessonKey := lessonRef(s)
+	lessonTime := s.ScheduleTime.UTC().Format(time.RFC3339)
+
+	due := false
+	for _, minutes := range r.Offsets {
+		if s.ScheduleTime.Add(-time.Duration(minutes) * time.Minute).After(now) {
+			continue
+		}
+		// INSERT OR IGNORE атомарно «забирает» напоминание: повторная попытка ничего не вставит
+		res, err := db.DB.Exec(`
+			INSERT OR IGNORE INTO sent_reminders (registration_code, lesson_key, lesson_time, minutes, sent_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			r.RegistrationCode, lessonKey, lessonTime, minutes, now.Format(time.RFC3339))
+		if err != nil {
+			log.Printf("Ошибка записи напоминания: %v", err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			due = true
+		}
+	}
+	if !due {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(r.TelegramID, FormatReminder(s, now))
+	msg.ParseMode = "HTML"
+	if _, err := bot.SendMessage(msg); err != nil {
+		log.Printf("Не удалось отправить напоминание в чат %d: %v", r.TelegramID, err)
+	}
+}
+
+// FormatReminder формирует текст напоминания о занятии.
+func FormatReminder(s models.Schedule, now time.Time) string {
+	left := int(s.ScheduleTime.Sub(now).Round(time.Minute) / time.Minute)
+	if left < 1 {
+		left = 1
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⏰ <b>Через %s занятие</b>\n\n", formatMinutes(left)))
+	sb.WriteString(fmt.Sprintf("📚 %s\n", html.EscapeString(s.Description)))
+	sb.WriteString(fmt.Sprintf("🕒 %s–%s\n", s.ScheduleTime.Format("15:04"), scheduling.End(s).Format("15:04")))
+	sb.WriteString(fmt.Sprintf("📝 %s\n", html.EscapeString(valueOrDash(s.LessonType))))
+	sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", html.EscapeString(valueOrDash(s.Auditory))))
+	return sb.String()
+}
+
+// formatMinutes выводит интервал в виде «10 мин», «1 ч» или «1 ч 30 мин».
+func formatMinutes(m int) string {
+	switch {
+	case m < 60:
+		return fmt.Sprintf("%d мин", m)
+	case m%60 == 0:
+		return fmt.Sprintf("%d ч", m/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", m/60, m%60)
+}
+
+// reminderRecipients загружает вошедших пользователей с включёнными напоминаниями.
+func reminderRecipients() ([]reminderRecipient, error) {
+	rows, err := db.DB.Query(`
+		SELECT u.telegram_id, u.role, COALESCE(u.group_name, ''), COALESCE(u.subgroup_id, 0), u.registration_code, r.minutes
+		FROM reminder_offsets r
+		JOIN users u ON u.registration_code = r.registration_code
+		WHERE u.telegram_id <> 0 AND u.password <> ''
+		ORDER BY u.registration_code, r.minutes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []reminderRecipient
+	for rows.Next() {
+		var r reminderRecipient
+		var minutes int
+		if err := rows.Scan(&r.TelegramID, &r.Role, &r.Group, &r.SubgroupID, &r.RegistrationCode, &minutes); err != nil {
+			return nil, err
+		}
+		if n := len(list); n > 0 && list[n-1].RegistrationCode == r.RegistrationCode {
+			list[n-1].Offsets = append(list[n-1].Offsets, minutes)
+			continue
+		}
+		r.Offsets = []int{minutes}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+// GetReminderOffsets возвращает отступы напоминаний пользователя по возрастанию.
+func GetReminderOffsets(regCode string) ([]int, error) {
+	rows, err := db.DB.Query(`SELECT minutes FROM reminder_offsets WHERE registration_code = ? ORDER BY minutes`, regCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var offsets []int
+	for rows.Next() {
+		var m int
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, m)
+	}
+	return offsets, rows.Err()
+}
+
+// toggleReminderOffset включает или выключает напоминание за minutes минут.
+func toggleReminderOffset(regCode string, minutes int) error {
+	res, err := db.DB.Exec(`DELETE FROM reminder_offsets WHERE registration_code = ? AND minutes = ?`, regCode, minutes)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	_, err = db.DB.Exec(`INSERT INTO reminder_offsets (registration_code, minutes) VALUES (?, ?)`, regCode, minutes)
+	return err
+}
+
+// ShowReminderSettings показывает включённые напоминания и кнопки их переключения.
+func ShowReminderSettings(chatID int64, bot Messenger, user *models.User) error {
+	offsets, err := GetReminderOffsets(user.RegistrationCode)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка загрузки настроек напоминаний: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	enabled := make(map[int]bool)
+	for _, m := range offsets {
+		enabled[m] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🔔 <b>Напоминания о занятиях</b>\n\n")
+	if len(offsets) == 0 {
+		sb.WriteString("Напоминания выключены.\n")
+	} else {
+		var parts []string
+		for _, m := range offsets {
+			parts = append(parts, formatMinutes(m))
+		}
+		sb.WriteString(fmt.Sprintf("Напомню за: <b>%s</b> до начала.\n", strings.Join(parts, ", ")))
+	}
+	sb.WriteString("\nВыберите, за сколько до занятия присылать напоминание (можно несколько):")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, m := range reminderPresets {
+		label := formatMinutes(m)
+		if enabled[m] {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "rem_toggle_"+strconv.Itoa(m)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if len(offsets) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔕 Выключить все", "rem_off"),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessReminderCallback обрабатывает кнопки настроек напоминаний. Возвращает true, если callback обработан.
+func ProcessReminderCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+
+	switch {
+	case data == "menu_reminders":
+		bot.AnswerCallback(callback.ID, "🔔 Напоминания")
+	case data == "rem_off":
+		if _, err := db.DB.Exec(`DELETE FROM reminder_offsets WHERE registration_code = ?`, user.RegistrationCode); err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка сохранения настроек")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "Напоминания выключены")
+	case strings.HasPrefix(data, "rem_toggle_"):
+		minutes, err := strconv.Atoi(strings.TrimPrefix(data, "rem_toggle_"))
+		if err != nil || !containsInt(reminderPresets, minutes) {
+			bot.AnswerCallback(callback.ID, "Некорректное значение")
+			return true
+		}
+		if err := toggleReminderOffset(user.RegistrationCode, minutes); err != nil {
+			bot.AnswerCallback(callback.ID, "Ошибка сохранения настроек")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "Настройки сохранены")
+	default:
+		return false
+	}
+
+	if err := ShowReminderSettings(chatID, bot, user); err != nil {
+		fmt.Println("Ошибка отображения настроек напоминаний:", err)
+	}
+	return true
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestRemindersAreSentOncePerOffset(t *testing.T) {
+	c := newConversation(t, 1007)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("menu_reminders")
+	c.press("rem_toggle_60")
+	c.press("rem_toggle_10")
+	c.golden("reminder_settings")
+
+	at := func(hhmm string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2025-03-17 "+hhmm)
+		return t
+	}
+	sent := func() []string {
+		var texts []string
+		for _, call := range c.srv.CallsTo("sendMessage") {
+			texts = append(texts, call.Text())
+		}
+		c.srv.Reset()
+		return texts
+	}
+
+	sendDueReminders(c.bot, at("06:30"))
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("напоминание раньше срока: %q", got)
+	}
+
+	sendDueReminders(c.bot, at("07:00"))
+	got := sent()
+	if len(got) != 1 || !strings.Contains(got[0], "Через 1 ч занятие") || !strings.Contains(got[0], "Аудитория: 101") {
+		t.Fatalf("ожидалось напоминание за час о занятии в 101: %q", got)
+	}
+
+	// Повторная проверка (как после перезапуска) не дублирует напоминание
+	sendDueReminders(c.bot, at("07:01"))
+	if got := sent(); len(got) != 0 {
+		t.Fatalf("напоминание продублировано: %q", got)
+	}
+
+	sendDueReminders(c.bot, at("07:50"))
+	if got := sent(); len(got) != 1 || !strings.Contains(got[0], "Через 10 мин") {
+		t.Fatalf("ожидалось напоминание за 10 минут: %q", got)
+	}
+
+	// Занятие перенесли — о новом времени напоминаем заново
+	lesson, err := scheduling.GetLesson(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lesson.ScheduleTime = at("11:00")
+	if err := scheduling.UpdateLesson(lesson, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	sendDueReminders(c.bot, at("10:00"))
+	got = sent()
+	if len(got) != 1 || !strings.Contains(got[0], "11:00–12:30") {
+		t.Fatalf("ожидалось напоминание о перенесённом занятии: %q", got)
+	}
+}
+
+func TestFormatReminderEscapesFreeText(t *testing.T) {
+	s := models.Schedule{Description: "Матем: x < y", Auditory: "A&B", LessonType: "Лекция",
+		ScheduleTime: time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC), Duration: 90}
+	text := FormatReminder(s, s.ScheduleTime.Add(-10*time.Minute))
+	for _, want := range []string{"📚 Матем: x &lt; y\n", "🚪 Аудитория: A&amp;B\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("в напоминании нет %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestWallClockRelabelsLocalTime(t *testing.T) {
+	saved := time.Local
+	time.Local = time.FixedZone("MSK", 3*60*60)
+	t.Cleanup(func() { time.Local = saved })
+
+	// 05:00 UTC в Москве — 08:00, и именно так записано занятие в 08:00
+	got := wallClock(time.Date(2025, 3, 17, 5, 0, 30, 0, time.UTC))
+	if want := time.Date(2025, 3, 17, 8, 0, 30, 0, time.UTC); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("wallClock = %v, ожидалось %v", got, want)
+	}
+}
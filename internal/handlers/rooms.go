@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"education/internal/auth"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Учебный день, в пределах которого показываются свободные окна аудитории
+const (
+	roomDayStartHour = 8
+	roomDayEndHour   = 20
+	maxRoomSlotLen   = 12 * 60 // длительность поиска, минут
+)
+
+var (
+	// Чаты, от которых ждём дату и время для поиска свободной аудитории
+	roomSearchInput   = make(map[int64]bool)
+	roomSearchInputMu sync.Mutex
+)
+
+func setRoomSearchInput(chatID int64, waiting bool) {
+	roomSearchInputMu.Lock()
+	defer roomSearchInputMu.Unlock()
+	if waiting {
+		roomSearchInput[chatID] = true
+	} else {
+		delete(roomSearchInput, chatID)
+	}
+}
+
+func isRoomSearchInput(chatID int64) bool {
+	roomSearchInputMu.Lock()
+	defer roomSearchInputMu.Unlock()
+	return roomSearchInput[chatID]
+}
+
+// describeRoom — краткое описание аудитории: корпус, этаж, места, оборудование.
+func describeRoom(r models.Room) string {
+	var parts []string
+	if r.Building != "" {
+		parts = append(parts, r.Building)
+	}
+	if r.Floor != 0 {
+		parts = append(parts, fmt.Sprintf("%d этаж", r.Floor))
+	}
+	if r.Capacity > 0 {
+		parts = append(parts, fmt.Sprintf("мест: %d", r.Capacity))
+	}
+	if r.Equipment != "" {
+		parts = append(parts, r.Equipment)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// roomButton открывает занятость аудитории на день.
+func roomButton(r models.Room, day time.Time) tgbotapi.InlineKeyboardButton {
+	label := "🚪 " + r.Name
+	if r.Capacity > 0 {
+		label += fmt.Sprintf(" (%d)", r.Capacity)
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("room_%d_%s", r.ID, day.Format("20060102")))
+}
+
+// roomButtonRows раскладывает кнопки аудиторий по три в ряд.
+func roomButtonRows(rooms []models.Room, day time.Time) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, r := range rooms {
+		row = append(row, roomButton(r, day))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ShowRoomsMenu показывает справочник аудиторий и поиск свободной.
+func ShowRoomsMenu(chatID int64, bot Messenger, now time.Time) error {
+	rooms, err := scheduling.GetRooms()
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения аудиторий: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	if len(rooms) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "🏫 Справочник аудиторий пуст.")
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	today := truncateToDay(now)
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🟢 Свободные сейчас", "rooms_now"),
+			tgbotapi.NewInlineKeyboardButtonData("🔍 Найти свободную", "rooms_find"),
+		),
+	}
+	rows = append(rows, roomButtonRows(rooms, today)...)
+
+	msg := tgbotapi.NewMessage(chatID, "🏫 <b>Аудитории</b>\n\nНайдите свободную аудиторию на нужное время или откройте аудиторию, чтобы посмотреть её занятость на сегодня.")
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// parseRoomSearch разбирает запрос поиска: "ДД.ММ.ГГГГ ЧЧ:ММ [минуты]" или "ЧЧ:ММ [минуты]" (на сегодня).
+func parseRoomSearch(text string, now time.Time) (time.Time, int, error) {
+	fields := strings.Fields(text)
+	minutes := defaultLessonDuration
+	if n := len(fields); n > 1 {
+		if m, err := strconv.Atoi(fields[n-1]); err == nil {
+			if m <= 0 || m > maxRoomSlotLen {
+				return time.Time{}, 0, fmt.Errorf("длительность должна быть от 1 до %d минут", maxRoomSlotLen)
+			}
+			minutes = m
+			fields = fields[:n-1]
+		}
+	}
+	start, err := parseLessonTime(strings.Join(fields, " "), now)
+	if err != nil {
+		return time.Time{}, 0, errors.New("не удалось разобрать дату и время")
+	}
+	return start, minutes, nil
+}
+
+// ShowFreeRooms показывает аудитории, свободные в интервале [start, start+minutes).
+func ShowFreeRooms(chatID int64, bot Messenger, start time.Time, minutes int) error {
+	rooms, err := scheduling.FreeRooms(start, minutes)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка поиска аудиторий: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	end := start.Add(time.Duration(minutes) * time.Minute)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔍 <b>Свободные аудитории</b>\n📅 %s (%s), %s–%s\n\n",
+		start.Format("02.01.2006"), weekdayName(start.Weekday()), start.Format("15:04"), end.Format("15:04")))
+	if len(rooms) == 0 {
+		sb.WriteString("Свободных аудиторий нет.")
+	}
+	for _, r := range rooms {
+		sb.WriteString("🚪 <b>" + html.EscapeString(r.Name) + "</b>")
+		if d := describeRoom(r); d != "" {
+			sb.WriteString(" — " + html.EscapeString(d))
+		}
+		sb.WriteString("\n")
+	}
+
+	rows := roomButtonRows(rooms, start)
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔍 Другое время", "rooms_find"),
+		tgbotapi.NewInlineKeyboardButtonData("◀️ К аудиториям", "menu_rooms"),
+	))
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ShowRoomOccupancy показывает занятия в аудитории за день и свободные окна учебного дня.
+func ShowRoomOccupancy(chatID int64, bot Messenger, roomID int64, day time.Time) error {
+	room, err := scheduling.GetRoom(roomID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Аудитория не найдена.")
+		return sendAndTrackMessage(bot, msg)
+	}
+	lessons, err := scheduling.RoomOccupancy(room.Name, day)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения занятости: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	courseNames := make(map[int64]string)
+	if courses, err := GetAllCourses(); err == nil {
+		for _, c := range courses {
+			courseNames[c.ID] = c.Name
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🚪 <b>Аудитория " + html.EscapeString(room.Name) + "</b>\n")
+	if d := describeRoom(room); d != "" {
+		sb.WriteString(html.EscapeString(d) + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("📅 %s (%s)\n\n", day.Format("02.01.2006"), weekdayName(day.Weekday())))
+
+	if len(lessons) == 0 {
+		sb.WriteString("Занятий нет, аудитория свободна весь день.\n")
+	}
+	teacherNames, err := auth.TeacherNames()
+	if err != nil {
+		fmt.Printf("Ошибка получения имён преподавателей: %v\n", err)
+	}
+	for _, l := range lessons {
+		name := teacherNames[l.TeacherRegCode]
+		if name == "" {
+			name = l.TeacherRegCode
+		}
+		sb.WriteString(fmt.Sprintf("⏰ %s–%s %s", l.ScheduleTime.Format("15:04"), scheduling.End(l).Format("15:04"),
+			html.EscapeString(courseNames[l.CourseID])))
+		if l.LessonType != "" {
+			sb.WriteString(" (" + html.EscapeString(l.LessonType) + ")")
+		}
+		sb.WriteString(fmt.Sprintf("\n   👥 %s, 👤 %s\n", html.EscapeString(l.GroupName), html.EscapeString(name)))
+	}
+	if windows := freeWindows(lessons, day); len(lessons) > 0 && len(windows) > 0 {
+		sb.WriteString("\n🟢 Свободно: " + strings.Join(windows, ", ") + "\n")
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⬅️ Пред. день", fmt.Sprintf("room_%d_%s", room.ID, day.AddDate(0, 0, -1).Format("20060102"))),
+			tgbotapi.NewInlineKeyboardButtonData("След. день ➡️", fmt.Sprintf("room_%d_%s", room.ID, day.AddDate(0, 0, 1).Format("20060102"))),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◀️ К аудиториям", "menu_rooms"),
+		),
+	)
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	return sendAndTrackMessage(bot, msg)
+}
+
+// freeWindows возвращает свободные промежутки учебного дня между занятиями ("09:30–09:45").
+func freeWindows(lessons []models.Schedule, day time.Time) []string {
+	dayStart := truncateToDay(day).Add(roomDayStartHour * time.Hour)
+	dayEnd := truncateToDay(day).Add(roomDayEndHour * time.Hour)
+	var windows []string
+	cursor := dayStart
+	for _, l := range lessons {
+		if l.ScheduleTime.After(cursor) {
+			end := l.ScheduleTime
+			if end.After(dayEnd) {
+				end = dayEnd
+			}
+			if end.After(cursor) {
+				windows = append(windows, cursor.Format("15:04")+"–"+end.Format("15:04"))
+			}
+		}
+		if e := scheduling.End(l); e.After(cursor) {
+			cursor = e
+		}
+	}
+	if cursor.Before(dayEnd) {
+		windows = append(windows, cursor.Format("15:04")+"–"+dayEnd.Format("15:04"))
+	}
+	return windows
+}
+
+// ProcessRoomsCallback обрабатывает кнопки справочника аудиторий (только для преподавателей).
+// Возвращает true, если callback обработан.
+func ProcessRoomsCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	if data != "menu_rooms" && !strings.HasPrefix(data, "rooms_") && !strings.HasPrefix(data, "room_") {
+		return false
+	}
+	if user.Role != "teacher" {
+		bot.AnswerCallback(callback.ID, "Доступно только преподавателям")
+		return true
+	}
+
+	now := wallClockNow()
+	switch {
+	case data == "menu_rooms":
+		bot.AnswerCallback(callback.ID, "🏫 Аудитории")
+		ShowRoomsMenu(chatID, bot, now)
+	case data == "rooms_now":
+		bot.AnswerCallback(callback.ID, "")
+		ShowFreeRooms(chatID, bot, now.Truncate(time.Minute), defaultLessonDuration)
+	case data == "rooms_find":
+		setRoomSearchInput(chatID, true)
+		bot.AnswerCallback(callback.ID, "")
+		msg := tgbotapi.NewMessage(chatID, "🔍 Когда нужна аудитория? Введите дату, время и длительность в минутах, "+
+			"например 21.03.2025 11:45 90 или просто 11:45 на сегодня (или /cancel):")
+		sendAndTrackMessage(bot, msg)
+	case strings.HasPrefix(data, "room_"):
+		parts := strings.Split(strings.TrimPrefix(data, "room_"), "_")
+		if len(parts) != 2 {
+			bot.AnswerCallback(callback.ID, "Неизвестная аудитория")
+			return true
+		}
+		id, err1 := strconv.ParseInt(parts[0], 10, 64)
+		day, err2 := time.Parse("20060102", parts[1])
+		if err1 != nil || err2 != nil {
+			bot.AnswerCallback(callback.ID, "Неизвестная аудитория")
+			return true
+		}
+		bot.AnswerCallback(callback.ID, "")
+		ShowRoomOccupancy(chatID, bot, id, day)
+	default:
+		bot.AnswerCallback(callback.ID, "Неизвестное действие")
+	}
+	return true
+}
+
+// processRoomSearchMessage принимает дату и время для поиска свободной аудитории.
+func processRoomSearchMessage(chatID int64, bot Messenger, text string) {
+	start, minutes, err := parseRoomSearch(text, wallClockNow())
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+". Пример: 21.03.2025 11:45 90")
+		sendAndTrackMessage(bot, msg)
+		return
+	}
+	setRoomSearchInput(chatID, false)
+	ShowFreeRooms(chatID, bot, start, minutes)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/scheduling"
+)
+
+func TestTeacherFindsFreeRoom(t *testing.T) {
+	c := newConversation(t, 2004)
+	if _, err := db.DB.Exec(`
+		INSERT INTO rooms (id, name, building, floor, capacity, equipment) VALUES
+			(1, '101', 'Главный корпус', 1, 120, 'проектор'),
+			(2, '201', 'Главный корпус', 2, 40, ''),
+			(3, '305', 'Лабораторный корпус', 3, 20, 'компьютеры')`); err != nil {
+		t.Fatal(err)
+	}
+	c.loggedIn("TH-0002", "teach123")
+
+	// 17.03 в 09:00 заняты 101 (08:00–09:30) и 201 (09:45–11:15)
+	c.press("rooms_find")
+	c.send("17.03.2025 09:00 60")
+	c.press("room_1_20250317")
+	c.press("room_3_20250317")
+	c.golden("room_search")
+
+	// Справочник заполнен: неизвестную аудиторию в занятие не поставить
+	lesson, err := scheduling.GetLesson(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lesson.Auditory = "999"
+	if err := scheduling.UpdateLesson(lesson, true, ""); err == nil {
+		t.Error("ожидалась ошибка для аудитории не из справочника")
+	}
+}
+
+func TestStudentCannotOpenRooms(t *testing.T) {
+	c := newConversation(t, 1005)
+	c.loggedIn("ST-0002", "secret12")
+	c.press("menu_rooms")
+	calls := c.srv.CallsTo("answerCallbackQuery")
+	if len(calls) != 1 || calls[0].Params.Get("text") != "Доступно только преподавателям" {
+		t.Errorf("студент не должен видеть аудитории: %+v", calls)
+	}
+}
+
+func TestParseRoomSearch(t *testing.T) {
+	now := time.Date(2025, 3, 17, 7, 0, 0, 0, time.UTC)
+	start, minutes, err := parseRoomSearch("11:45", now)
+	if err != nil || !start.Equal(time.Date(2025, 3, 17, 11, 45, 0, 0, time.UTC)) || minutes != defaultLessonDuration {
+		t.Errorf("11:45: %v %d %v", start, minutes, err)
+	}
+	start, minutes, err = parseRoomSearch("21.03.2025 08:00 45", now)
+	if err != nil || !start.Equal(time.Date(2025, 3, 21, 8, 0, 0, 0, time.UTC)) || minutes != 45 {
+		t.Errorf("21.03.2025 08:00 45: %v %d %v", start, minutes, err)
+	}
+	if _, _, err := parseRoomSearch("завтра", now); err == nil {
+		t.Error("ожидалась ошибка разбора")
+	}
+	if _, _, err := parseRoomSearch("08:00 0", now); err == nil {
+		t.Error("ожидалась ошибка длительности")
+	}
+}
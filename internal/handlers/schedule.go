@@ -3,7 +3,9 @@ package handlers
 import (
 	"education/internal/db"
 	"education/internal/models"
+	"education/internal/scheduling"
 	"fmt"
+	"html"
 	"sort"
 	"strings"
 	"time"
@@ -12,7 +14,11 @@ import (
 )
 
 // ShowEnhancedScheduleDay shows an enhanced version of the daily schedule
-func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.User, day time.Time) error {
+func ShowEnhancedScheduleDay(chatID int64, bot Messenger, user *models.User, day time.Time) error {
+	if user == nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему для просмотра расписания.")
+		return sendAndTrackMessage(bot, msg)
+	}
 	dayStart := day.Truncate(24 * time.Hour)
 	dayEnd := dayStart.Add(24*time.Hour - time.Second)
 
@@ -23,7 +29,7 @@ func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.Us
 	if user.Role == "teacher" {
 		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, dayStart, dayEnd)
 	} else {
-		schedules, err = GetSchedulesForGroupByDateRange(user.Group, dayStart, dayEnd)
+		schedules, err = GetSchedulesForStudentByDateRange(user, dayStart, dayEnd)
 	}
 	if err != nil {
 		// Return a clear error message for daily schedule display
@@ -37,16 +43,20 @@ func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.Us
 	filteredSchedules := ApplyFilters(schedules, filter)
 
 	text := FormatEnhancedDaySchedule(filteredSchedules, day, user.Role)
+	// Консультации дня — рядом с занятиями
+	text += consultationsDayNote(user, day)
 
 	// Add navigation buttons for previous/next day
 	prevDay := day.AddDate(0, 0, -1)
 	nextDay := day.AddDate(0, 0, 1)
 
 	// Создаем навигационные кнопки
+	// На границах семестра листание останавливается
+	canPrev, canNext := semesterNavigation(day, day)
 	navRow := tgbotapi.NewInlineKeyboardRow(
-		tgbotapi.NewInlineKeyboardButtonData("◀️ Пред. день", fmt.Sprintf("day_%s", prevDay.Format("2006-01-02"))),
+		navButton("◀️ Пред. день", fmt.Sprintf("day_%s", prevDay.Format("2006-01-02")), canPrev),
 		tgbotapi.NewInlineKeyboardButtonData("Сегодня", "mode_day"),
-		tgbotapi.NewInlineKeyboardButtonData("След. день ▶️", fmt.Sprintf("day_%s", nextDay.Format("2006-01-02"))),
+		navButton("След. день ▶️", fmt.Sprintf("day_%s", nextDay.Format("2006-01-02")), canNext),
 	)
 
 	// Получаем базовую клавиатуру переключения режимов
@@ -61,10 +71,10 @@ func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.Us
 	if filter.CourseName != "" || filter.LessonType != "" {
 		text += "\n\n<b>📌 Активные фильтры:</b>\n"
 		if filter.CourseName != "" {
-			text += fmt.Sprintf("• Курс: <b>%s</b>\n", filter.CourseName)
+			text += fmt.Sprintf("• Курс: <b>%s</b>\n", html.EscapeString(filter.CourseName))
 		}
 		if filter.LessonType != "" {
-			text += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", filter.LessonType)
+			text += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", html.EscapeString(filter.LessonType))
 		}
 	}
 
@@ -73,6 +83,11 @@ func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.Us
 	allRows = append(allRows, navRow)
 	allRows = append(allRows, modeKeyboard.InlineKeyboard...)
 	allRows = append(allRows, filterRow)
+	if user.Role == "teacher" {
+		allRows = append(allRows, buildTeacherEditRows(filteredSchedules, day)...)
+	} else {
+		allRows = append(allRows, teacherButtonRows(scheduleTeachers(filteredSchedules))...)
+	}
 
 	enhancedKeyboard := tgbotapi.NewInlineKeyboardMarkup(allRows...)
 
@@ -84,9 +99,12 @@ func ShowEnhancedScheduleDay(chatID int64, bot *tgbotapi.BotAPI, user *models.Us
 
 // FormatEnhancedDaySchedule creates a beautifully formatted day schedule
 func FormatEnhancedDaySchedule(schedules []models.Schedule, day time.Time, role string) string {
+	// Семестр, учебная неделя и праздник из учебного календаря
+	note := academicWeekNote(day, day) + holidayNote(day)
+	_, holiday := scheduling.CurrentCalendar().Holiday(day)
 	if len(schedules) == 0 {
-		return fmt.Sprintf("📆 <b>%s</b>\n\n🔍 <i>Нет занятий на этот день</i>",
-			day.Format("02.01.2006")+" ("+weekdayName(day.Weekday())+")")
+		return fmt.Sprintf("📆 <b>%s</b>\n%s\n🔍 <i>Нет занятий на этот день</i>",
+			day.Format("02.01.2006")+" ("+weekdayName(day.Weekday())+")", note)
 	}
 
 	// Сортируем занятия по времени
@@ -96,8 +114,8 @@ func FormatEnhancedDaySchedule(schedules []models.Schedule, day time.Time, role
 
 	var sb strings.Builder
 	// Заголовок с датой и днем недели
-	sb.WriteString(fmt.Sprintf("📆 <b>%s</b>\n\n",
-		day.Format("02.01.2006")+" ("+weekdayName(day.Weekday())+")"))
+	sb.WriteString(fmt.Sprintf("📆 <b>%s</b>\n%s\n",
+		day.Format("02.01.2006")+" ("+weekdayName(day.Weekday())+")", note))
 
 	// Разделитель заголовка
 	sb.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
@@ -113,16 +131,22 @@ func FormatEnhancedDaySchedule(schedules []models.Schedule, day time.Time, role
 		// Блок информации о занятии с порядковым номером
 		sb.WriteString(fmt.Sprintf("📌 <b>Занятие %d</b>\n", lessonCount))
 		sb.WriteString(fmt.Sprintf("⏰ <b>%s - %s</b> (%d мин.)\n", timeStr, endTimeStr, s.Duration))
-		sb.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", s.Description))
+		sb.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", html.EscapeString(s.Description)))
+		if holiday {
+			sb.WriteString("⚠️ Занятие в праздничный день\n")
+		}
 
 		if role == "teacher" {
-			sb.WriteString(fmt.Sprintf("👥 Группа: %s\n", s.GroupName))
+			sb.WriteString(fmt.Sprintf("👥 Группа: %s\n", html.EscapeString(s.Audience())))
 		} else {
-			sb.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", s.TeacherRegCode))
+			sb.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", html.EscapeString(teacherName(s))))
+			if s.SubgroupName != "" {
+				sb.WriteString(fmt.Sprintf("👥 Подгруппа: %s\n", html.EscapeString(s.SubgroupName)))
+			}
 		}
 
-		sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", s.Auditory))
-		sb.WriteString(fmt.Sprintf("📝 Тип занятия: %s\n", s.LessonType))
+		sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", html.EscapeString(s.Auditory)))
+		sb.WriteString(fmt.Sprintf("📝 Тип занятия: %s\n", html.EscapeString(s.LessonType)))
 
 		// Добавляем разделитель между занятиями
 		sb.WriteString("\n")
@@ -146,7 +170,11 @@ func FormatEnhancedDaySchedule(schedules []models.Schedule, day time.Time, role
 
 // ShowScheduleWeek отправляет расписание за выбранную неделю.
 // weekStart – дата понедельника недели, которую надо показать.
-func ShowScheduleWeek(chatID int64, bot *tgbotapi.BotAPI, user *models.User, weekStart time.Time) error {
+func ShowScheduleWeek(chatID int64, bot Messenger, user *models.User, weekStart time.Time) error {
+	if user == nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему для просмотра расписания.")
+		return sendAndTrackMessage(bot, msg)
+	}
 	weekEnd := weekStart.AddDate(0, 0, 6)
 
 	fmt.Printf("ShowScheduleWeek for user %+v, weekStart: %s\n", user, weekStart.Format("2006-01-02"))
@@ -156,7 +184,7 @@ func ShowScheduleWeek(chatID int64, bot *tgbotapi.BotAPI, user *models.User, wee
 	if user.Role == "teacher" {
 		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, weekStart, weekEnd)
 	} else {
-		schedules, err = GetSchedulesForGroupByDateRange(user.Group, weekStart, weekEnd)
+		schedules, err = GetSchedulesForStudentByDateRange(user, weekStart, weekEnd)
 	}
 	if err != nil {
 		// Return a clear error message for weekly schedule display
@@ -175,27 +203,29 @@ func ShowScheduleWeek(chatID int64, bot *tgbotapi.BotAPI, user *models.User, wee
 	if filter.CourseName != "" || filter.LessonType != "" {
 		filterInfo := "\n<b>📌 Активные фильтры:</b>\n"
 		if filter.CourseName != "" {
-			filterInfo += fmt.Sprintf("• Курс: <b>%s</b>\n", filter.CourseName)
+			filterInfo += fmt.Sprintf("• Курс: <b>%s</b>\n", html.EscapeString(filter.CourseName))
 		}
 		if filter.LessonType != "" {
-			filterInfo += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", filter.LessonType)
+			filterInfo += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", html.EscapeString(filter.LessonType))
 		}
 		text = text + filterInfo
 	}
 
-	// Создаём базовую клавиатуру
+	// Создаём базовую клавиатуру; на границах семестра листание останавливается
+	canPrev, canNext := semesterNavigation(weekStart, weekEnd)
 	baseRows := [][]tgbotapi.InlineKeyboardButton{
 		{
-			tgbotapi.NewInlineKeyboardButtonData("◄", fmt.Sprintf("week_prev_%s", weekStart.AddDate(0, 0, -7).Format("2006-01-02"))),
+			navButton("◄", fmt.Sprintf("week_prev_%s", weekStart.AddDate(0, 0, -7).Format("2006-01-02")), canPrev),
 			tgbotapi.NewInlineKeyboardButtonData("Сегодня", "week_today"),
-			tgbotapi.NewInlineKeyboardButtonData("►", fmt.Sprintf("week_next_%s", weekStart.AddDate(0, 0, 7).Format("2006-01-02"))),
+			navButton("►", fmt.Sprintf("week_next_%s", weekStart.AddDate(0, 0, 7).Format("2006-01-02")), canNext),
 		},
 		{}, // Пустая строка для разделения
 	}
 
-	// Добавляем отдельную кнопку фильтров
+	// Добавляем отдельную кнопку фильтров и кнопку изменений с прошлого просмотра
 	filterRow := tgbotapi.NewInlineKeyboardRow(
 		tgbotapi.NewInlineKeyboardButtonData("🔍 Настроить фильтры", "filter_menu"),
+		weekChangesButton(chatID, user, weekStart),
 	)
 	baseRows = append(baseRows, filterRow)
 
@@ -206,6 +236,9 @@ func ShowScheduleWeek(chatID int64, bot *tgbotapi.BotAPI, user *models.User, wee
 	if modeKeyboard.InlineKeyboard != nil {
 		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, modeKeyboard.InlineKeyboard...)
 	}
+	if user.Role != "teacher" {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, teacherButtonRows(scheduleTeachers(filteredSchedules))...)
+	}
 
 	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ParseMode = "HTML"
@@ -220,11 +253,15 @@ func GetSchedulesForTeacherByDateRange(teacherRegCode string, start, end time.Ti
         SELECT
             s.id, s.course_id, s.group_name, s.teacher_reg_code,
             s.schedule_time, s.description, s.auditory, s.lesson_type, s.duration,
-            COALESCE(u.name, s.teacher_reg_code) AS teacher_name,
-            COALESCE(c.name, 'Неизвестный курс') AS course_name
+            COALESCE(NULLIF(p.display_name, ''), u.name, s.teacher_reg_code) AS teacher_name,
+            COALESCE(c.name, 'Неизвестный курс') AS course_name,
+            COALESCE(s.rule_id, 0), COALESCE(s.rule_date, ''), s.cancelled,
+            COALESCE(s.subgroup_id, 0), COALESCE(sg.name, '')
         FROM schedules s
         LEFT JOIN users u ON s.teacher_reg_code = u.registration_code
+        LEFT JOIN teacher_profiles p ON s.teacher_reg_code = p.registration_code
         LEFT JOIN courses c ON s.course_id = c.id
+        LEFT JOIN subgroups sg ON s.subgroup_id = sg.id
         WHERE s.teacher_reg_code = ? AND date(s.schedule_time) BETWEEN ? AND ?
         ORDER BY s.schedule_time
     `
@@ -245,6 +282,8 @@ func GetSchedulesForTeacherByDateRange(teacherRegCode string, start, end time.Ti
 		var s models.Schedule
 		var scheduleTimeStr string
 		var teacherName, courseName string
+		var ruleDate string
+		var cancelled bool
 		if err := rows.Scan(
 			&s.ID,
 			&s.CourseID,
@@ -257,11 +296,25 @@ func GetSchedulesForTeacherByDateRange(teacherRegCode string, start, end time.Ti
 			&s.Duration,
 			&teacherName,
 			&courseName,
+			&s.RuleID,
+			&ruleDate,
+			&cancelled,
+			&s.SubgroupID,
+			&s.SubgroupName,
 		); err != nil {
 			fmt.Printf("Row scan error: %v\n", err)
 			return nil, err
 		}
 
+		// Разовая строка, привязанная к правилу, заменяет или отменяет занятие по правилу
+		// в день rule_date (скрытие исходного занятия — в withRuleOccurrences)
+		if s.RuleID != 0 && ruleDate != "" {
+			s.RuleDate, _ = time.Parse("2006-01-02", ruleDate)
+		}
+		if cancelled {
+			continue
+		}
+
 		// Try to parse the time with error handling
 		if parsedTime, err := time.Parse(time.RFC3339, scheduleTimeStr); err != nil {
 			fmt.Printf("Time parse error: %v for string %s\n", err, scheduleTimeStr)
@@ -287,7 +340,7 @@ func GetSchedulesForTeacherByDateRange(teacherRegCode string, start, end time.Ti
 		}
 
 		// Store teacher name and course name in context
-		s.TeacherRegCode = teacherName // Use teacher name instead of reg code
+		s.TeacherName = teacherName
 
 		// Only prepend course name if it's a valid course
 		if courseName != "Неизвестный курс" {
@@ -297,8 +350,21 @@ func GetSchedulesForTeacherByDateRange(teacherRegCode string, start, end time.Ti
 		schedules = append(schedules, s)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rules, err := scheduling.RulesForTeacher(teacherRegCode, start, end)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err = withRuleOccurrences(schedules, rules, start, end)
+	if err != nil {
+		return nil, err
+	}
+
 	fmt.Printf("Found %d schedules for teacher %s\n", len(schedules), teacherRegCode)
-	return schedules, rows.Err()
+	return schedules, nil
 }
 
 func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]models.Schedule, error) {
@@ -306,11 +372,15 @@ func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]mode
        SELECT
          s.id, s.course_id, s.group_name, s.teacher_reg_code,
          s.schedule_time, s.description, s.auditory, s.lesson_type, s.duration,
-         COALESCE(u.name, s.teacher_reg_code) AS teacher_name,
-         COALESCE(c.name, 'Неизвестный курс') AS course_name
+         COALESCE(NULLIF(p.display_name, ''), u.name, s.teacher_reg_code) AS teacher_name,
+         COALESCE(c.name, 'Неизвестный курс') AS course_name,
+         COALESCE(s.rule_id, 0), COALESCE(s.rule_date, ''), s.cancelled,
+         COALESCE(s.subgroup_id, 0), COALESCE(sg.name, '')
        FROM schedules s
        LEFT JOIN users u ON s.teacher_reg_code = u.registration_code
+       LEFT JOIN teacher_profiles p ON s.teacher_reg_code = p.registration_code
        LEFT JOIN courses c ON s.course_id = c.id
+       LEFT JOIN subgroups sg ON s.subgroup_id = sg.id
        WHERE s.group_name = ? AND date(s.schedule_time) BETWEEN ? AND ?
        ORDER BY s.schedule_time
     `
@@ -331,6 +401,8 @@ func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]mode
 		var s models.Schedule
 		var scheduleTimeStr string
 		var teacherName, courseName string
+		var ruleDate string
+		var cancelled bool
 		if err := rows.Scan(
 			&s.ID,
 			&s.CourseID,
@@ -343,11 +415,25 @@ func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]mode
 			&s.Duration,
 			&teacherName,
 			&courseName,
+			&s.RuleID,
+			&ruleDate,
+			&cancelled,
+			&s.SubgroupID,
+			&s.SubgroupName,
 		); err != nil {
 			fmt.Printf("Row scan error: %v\n", err)
 			return nil, err
 		}
 
+		// Разовая строка, привязанная к правилу, заменяет или отменяет занятие по правилу
+		// в день rule_date (скрытие исходного занятия — в withRuleOccurrences)
+		if s.RuleID != 0 && ruleDate != "" {
+			s.RuleDate, _ = time.Parse("2006-01-02", ruleDate)
+		}
+		if cancelled {
+			continue
+		}
+
 		// Try to parse the time with error handling
 		if parsedTime, err := time.Parse(time.RFC3339, scheduleTimeStr); err != nil {
 			fmt.Printf("Time parse error: %v for string %s\n", err, scheduleTimeStr)
@@ -373,7 +459,7 @@ func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]mode
 		}
 
 		// Store teacher name and course name in context
-		s.TeacherRegCode = teacherName // Use teacher name instead of reg code
+		s.TeacherName = teacherName
 
 		// Only prepend course name if it's a valid course
 		if courseName != "Неизвестный курс" {
@@ -383,8 +469,30 @@ func GetSchedulesForGroupByDateRange(group string, start, end time.Time) ([]mode
 		schedules = append(schedules, s)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rules, err := scheduling.RulesForGroup(group, start, end)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err = withRuleOccurrences(schedules, rules, start, end)
+	if err != nil {
+		return nil, err
+	}
+
 	fmt.Printf("Found %d schedules for group %s\n", len(schedules), group)
-	return schedules, rows.Err()
+	return schedules, nil
+}
+
+// GetSchedulesForStudentByDateRange возвращает занятия группы студента без занятий других подгрупп.
+func GetSchedulesForStudentByDateRange(user *models.User, start, end time.Time) ([]models.Schedule, error) {
+	schedules, err := GetSchedulesForGroupByDateRange(user.Group, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return filterSubgroup(schedules, user.SubgroupID), nil
 }
 
 // GetSchedulesByTeacher возвращает расписание преподавателя, учитывая все поля структуры Schedule.
@@ -400,10 +508,11 @@ func GetSchedulesByTeacher(teacherRegCode string) ([]models.Schedule, error) {
 			s.auditory,
 			s.lesson_type,
 			s.duration,
-			COALESCE(u.name, s.teacher_reg_code) AS teacher_name,
+			COALESCE(NULLIF(p.display_name, ''), u.name, s.teacher_reg_code) AS teacher_name,
 			COALESCE(c.name, 'Неизвестный курс') AS course_name
 		FROM schedules s
 		LEFT JOIN users u ON s.teacher_reg_code = u.registration_code
+		LEFT JOIN teacher_profiles p ON s.teacher_reg_code = p.registration_code
 		LEFT JOIN courses c ON s.course_id = c.id
 		WHERE s.teacher_reg_code = ?
 		ORDER BY s.schedule_time
@@ -440,7 +549,7 @@ func GetSchedulesByTeacher(teacherRegCode string) ([]models.Schedule, error) {
 		}
 
 		// Store teacher name and course name in context
-		s.TeacherRegCode = teacherName
+		s.TeacherName = teacherName
 
 		// Only prepend course name if it's a valid course
 		if courseName != "Неизвестный курс" {
@@ -465,10 +574,11 @@ func GetSchedulesByGroup(group string) ([]models.Schedule, error) {
 			s.auditory,
 			s.lesson_type,
 			s.duration,
-			COALESCE(u.name, s.teacher_reg_code) AS teacher_name,
+			COALESCE(NULLIF(p.display_name, ''), u.name, s.teacher_reg_code) AS teacher_name,
 			COALESCE(c.name, 'Неизвестный курс') AS course_name
 		FROM schedules s
 		LEFT JOIN users u ON s.teacher_reg_code = u.registration_code
+		LEFT JOIN teacher_profiles p ON s.teacher_reg_code = p.registration_code
 		LEFT JOIN courses c ON s.course_id = c.id
 		WHERE s.group_name = ?
 		ORDER BY s.schedule_time
@@ -505,7 +615,7 @@ func GetSchedulesByGroup(group string) ([]models.Schedule, error) {
 		}
 
 		// Store teacher name and course name in context
-		s.TeacherRegCode = teacherName
+		s.TeacherName = teacherName
 
 		// Only prepend course name if it's a valid course
 		if courseName != "Неизвестный курс" {
@@ -523,9 +633,20 @@ func FormatSchedulesByWeek(
 	mode string,
 	user *models.User,
 ) string {
+	// Семестр, учебная неделя и сессия из учебного календаря
+	note := academicWeekNote(weekStart, weekEnd)
 	if len(schedules) == 0 {
-		return fmt.Sprintf("📆 <b>Неделя %s – %s</b>\n\n🔍 <i>Нет занятий на эту неделю</i>",
-			weekStart.Format("02.01.2006"), weekEnd.Format("02.01.2006"))
+		var holidays strings.Builder
+		for d := weekStart; !d.After(weekEnd); d = d.AddDate(0, 0, 1) {
+			if p, ok := scheduling.CurrentCalendar().Holiday(d); ok {
+				holidays.WriteString(fmt.Sprintf("🎉 %s (%s): %s\n", d.Format("02.01"), weekdayName(d.Weekday()), html.EscapeString(p.Name)))
+			}
+		}
+		if holidays.Len() > 0 {
+			holidays.WriteString("\n")
+		}
+		return fmt.Sprintf("📆 <b>Неделя %s – %s</b>\n%s\n%s🔍 <i>Нет занятий на эту неделю</i>",
+			weekStart.Format("02.01.2006"), weekEnd.Format("02.01.2006"), note, holidays.String())
 	}
 
 	// Группировка по дням
@@ -536,21 +657,29 @@ func FormatSchedulesByWeek(
 	}
 
 	var msg strings.Builder
-	msg.WriteString(fmt.Sprintf("📆 <b>Неделя %s – %s</b>\n\n",
-		weekStart.Format("02.01.2006"), weekEnd.Format("02.01.2006")))
+	msg.WriteString(fmt.Sprintf("📆 <b>Неделя %s – %s</b>\n%s\n",
+		weekStart.Format("02.01.2006"), weekEnd.Format("02.01.2006"), note))
 
 	// Проходим по дням недели
 	hasEvents := false
 	for d := 0; d < 7; d++ {
 		day := weekStart.AddDate(0, 0, d)
 		dayStr := day.Format("2006-01-02")
+		holiday := holidayNote(day)
 
-		if entries, ok := grouped[dayStr]; ok {
+		entries, ok := grouped[dayStr]
+		if !ok && holiday != "" {
+			// Праздник показываем, даже если занятий нет
+			msg.WriteString(fmt.Sprintf("🗓 <b>%s (%s)</b>\n%s\n",
+				day.Format("02.01.2006"), weekdayName(day.Weekday()), holiday))
+		}
+		if ok {
 			hasEvents = true
 
 			// Заголовок дня
 			msg.WriteString(fmt.Sprintf("🗓 <b>%s (%s)</b>\n",
 				day.Format("02.01.2006"), weekdayName(day.Weekday())))
+			msg.WriteString(holiday)
 			msg.WriteString("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
 			// Сортируем занятия по времени
@@ -564,16 +693,22 @@ func FormatSchedulesByWeek(
 
 				// Полный блок информации о занятии
 				msg.WriteString(fmt.Sprintf("\n⏰ <b>%s - %s</b> (%d мин.)\n", timeStr, endTimeStr, s.Duration))
-				msg.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", s.Description))
+				msg.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", html.EscapeString(s.Description)))
+				if holiday != "" {
+					msg.WriteString("⚠️ Занятие в праздничный день\n")
+				}
 
 				if mode == "teacher" {
-					msg.WriteString(fmt.Sprintf("👥 Группа: %s\n", s.GroupName))
+					msg.WriteString(fmt.Sprintf("👥 Группа: %s\n", html.EscapeString(s.Audience())))
 				} else {
-					msg.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", s.TeacherRegCode))
+					msg.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", html.EscapeString(teacherName(s))))
+					if s.SubgroupName != "" {
+						msg.WriteString(fmt.Sprintf("👥 Подгруппа: %s\n", html.EscapeString(s.SubgroupName)))
+					}
 				}
 
-				msg.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", s.Auditory))
-				msg.WriteString(fmt.Sprintf("📝 Тип: %s\n", s.LessonType))
+				msg.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", html.EscapeString(s.Auditory)))
+				msg.WriteString(fmt.Sprintf("📝 Тип: %s\n", html.EscapeString(s.LessonType)))
 			}
 			msg.WriteString("\n")
 		}
@@ -638,12 +773,12 @@ func BuildWeekNavigationKeyboardFilteredWithFilter(weekStart time.Time, schedule
 	return tgbotapi.NewInlineKeyboardMarkup(allRows...)
 }
 
-func ShowScheduleModeMenu(chatID int64, bot *tgbotapi.BotAPI) error {
+func ShowScheduleModeMenu(chatID int64, bot Messenger) error {
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("День", "mode_day"),
 			tgbotapi.NewInlineKeyboardButtonData("Неделя", "mode_week"),
-			// Удаляем опцию "Месяц"
+			tgbotapi.NewInlineKeyboardButtonData("Месяц", "mode_month"),
 		),
 		// Добавляем строку с кнопкой фильтров
 		tgbotapi.NewInlineKeyboardRow(
@@ -710,11 +845,11 @@ func BuildCalendarTimeline(schedules []models.Schedule, day time.Time) string {
 
 		// Блок информации о занятии
 		sb.WriteString(fmt.Sprintf("⏰ <b>%s - %s</b> (%d мин.)\n", timeStr, endTimeStr, s.Duration))
-		sb.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", s.Description))
-		sb.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", s.TeacherRegCode))
-		sb.WriteString(fmt.Sprintf("👥 Группа: %s\n", s.GroupName))
-		sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", s.Auditory))
-		sb.WriteString(fmt.Sprintf("📝 Тип: %s\n", s.LessonType))
+		sb.WriteString(fmt.Sprintf("📚 <b>%s</b>\n", html.EscapeString(s.Description)))
+		sb.WriteString(fmt.Sprintf("👨‍🏫 Преподаватель: %s\n", html.EscapeString(teacherName(s))))
+		sb.WriteString(fmt.Sprintf("👥 Группа: %s\n", html.EscapeString(s.Audience())))
+		sb.WriteString(fmt.Sprintf("🚪 Аудитория: %s\n", html.EscapeString(s.Auditory)))
+		sb.WriteString(fmt.Sprintf("📝 Тип: %s\n", html.EscapeString(s.LessonType)))
 		sb.WriteString("\n")
 	}
 
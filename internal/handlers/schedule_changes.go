@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"education/internal/auth"
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// lastSeenRevision возвращает последнюю версию расписания, в которой пользователь видел неделю.
+// Если эту неделю он ещё не открывал — последнюю версию, которую он видел на любой неделе.
+// Если он не открывал ни одной недели, сравнивать не с чем: точкой отсчёта служит текущая версия,
+// иначе после массового импорта первый просмотр показал бы всю историю изменений.
+func lastSeenRevision(chatID int64, weekStart time.Time) int64 {
+	var id int64
+	err := db.DB.QueryRow(`SELECT revision_id FROM schedule_views WHERE telegram_id = ? AND week_start = ?`,
+		chatID, weekStart.Format("2006-01-02")).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		var seen sql.NullInt64
+		err = db.DB.QueryRow(`SELECT MAX(revision_id) FROM schedule_views WHERE telegram_id = ?`, chatID).Scan(&seen)
+		id = seen.Int64
+		if err == nil && !seen.Valid {
+			id, err = scheduling.LatestRevision()
+		}
+	}
+	if err != nil {
+		fmt.Println("Ошибка получения просмотренной версии расписания:", err)
+		return 0
+	}
+	return id
+}
+
+// markWeekSeen запоминает, что пользователь видел неделю в версии revision.
+func markWeekSeen(chatID int64, weekStart time.Time, revision int64) {
+	_, err := db.DB.Exec(`
+		INSERT INTO schedule_views (telegram_id, week_start, revision_id) VALUES (?, ?, ?)
+		ON CONFLICT(telegram_id, week_start) DO UPDATE SET revision_id = excluded.revision_id`,
+		chatID, weekStart.Format("2006-01-02"), revision)
+	if err != nil {
+		fmt.Println("Ошибка сохранения просмотренной версии расписания:", err)
+	}
+}
+
+// changeConcerns сообщает, касается ли изменение расписания пользователя.
+func changeConcerns(user *models.User, c scheduling.Change) bool {
+	for _, s := range []*models.Schedule{c.Before, c.After} {
+		if s == nil {
+			continue
+		}
+		if user.Role == "teacher" {
+			if s.TeacherRegCode == user.RegistrationCode {
+				return true
+			}
+		} else if s.GroupName == user.Group && s.ForSubgroup(user.SubgroupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekChanges возвращает изменения недели после версии since, касающиеся пользователя.
+// Последовательные изменения одного занятия склеены; изменения, которые в итоге
+// ничего не поменяли, отброшены.
+func weekChanges(user *models.User, weekStart time.Time, since int64) ([]scheduling.Change, error) {
+	revisions, err := scheduling.RevisionsSince(since, weekStart, weekStart.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, err
+	}
+	var merged []scheduling.Change
+	for _, r := range revisions {
+		if changeConcerns(user, r.Change) {
+			merged = mergeChange(merged, r.Change)
+		}
+	}
+	var changes []scheduling.Change
+	for _, c := range merged {
+		if formatChange(c, nil, time.Time{}) != "" {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// weekChangesButton — кнопка «Изменения» недельного расписания. Версия, с которой сравнивать,
+// фиксируется в кнопке: после показа недели пользователь считается видевшим её текущую версию.
+func weekChangesButton(chatID int64, user *models.User, weekStart time.Time) tgbotapi.InlineKeyboardButton {
+	since := lastSeenRevision(chatID, weekStart)
+	label := "🔄 Изменения"
+	if changes, err := weekChanges(user, weekStart, since); err != nil {
+		fmt.Println("Ошибка получения изменений недели:", err)
+	} else if len(changes) > 0 {
+		label = fmt.Sprintf("🔄 Изменения (%d)", len(changes))
+	}
+	if latest, err := scheduling.LatestRevision(); err == nil {
+		markWeekSeen(chatID, weekStart, latest)
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("wchg_%s_%d", weekStart.Format("2006-01-02"), since))
+}
+
+// changeAuthor — кто внёс изменение, для отображения.
+func changeAuthor(by string, names map[string]string) string {
+	switch {
+	case by == scheduling.ChangedByAdmin:
+		return "деканат"
+	case names[by] != "":
+		return names[by]
+	}
+	return by
+}
+
+// ShowWeekChanges показывает добавленные, отменённые и изменённые занятия недели
+// по сравнению с версией since.
+func ShowWeekChanges(chatID int64, bot Messenger, user *models.User, weekStart time.Time, since int64) error {
+	changes, err := weekChanges(user, weekStart, since)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения изменений: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	courseNames := make(map[int64]string)
+	if courses, err := GetAllCourses(); err == nil {
+		for _, c := range courses {
+			courseNames[c.ID] = c.Name
+		}
+	}
+	names, err := auth.TeacherNames()
+	if err != nil {
+		fmt.Println("Ошибка получения имён преподавателей:", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔄 <b>Изменения недели %s – %s</b>\n",
+		weekStart.Format("02.01"), weekStart.AddDate(0, 0, 6).Format("02.01")))
+	if len(changes) == 0 {
+		sb.WriteString("\nС вашего прошлого просмотра расписание недели не менялось.")
+	} else {
+		sb.WriteString("<i>по сравнению с версией, которую вы видели в прошлый раз</i>\n\n")
+	}
+	for i, c := range changes {
+		item := formatChange(c, courseNames, time.Time{})
+		if c.By != "" {
+			item += fmt.Sprintf("\n✍️ <i>%s, %s</i>", html.EscapeString(changeAuthor(c.By, names)), c.At.Format("02.01 15:04"))
+		}
+		if sb.Len()+len(item) > maxReportLen {
+			sb.WriteString(fmt.Sprintf("…и ещё %d", len(changes)-i))
+			break
+		}
+		sb.WriteString(item + "\n\n")
+	}
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSuffix(sb.String(), "\n\n"))
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("◀️ К неделе", "week_next_"+weekStart.Format("2006-01-02")),
+	))
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessScheduleChangesCallback обрабатывает кнопку «Изменения» (wchg_<неделя>_<версия>).
+// Возвращает true, если callback обработан.
+func ProcessScheduleChangesCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	if !strings.HasPrefix(callback.Data, "wchg_") {
+		return false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(callback.Data, "wchg_"), "_", 2)
+	if len(parts) != 2 {
+		bot.AnswerCallback(callback.ID, "Некорректный запрос")
+		return true
+	}
+	weekStart, err := time.Parse("2006-01-02", parts[0])
+	if err != nil {
+		bot.AnswerCallback(callback.ID, "Ошибка обработки даты")
+		return true
+	}
+	since, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		bot.AnswerCallback(callback.ID, "Некорректный запрос")
+		return true
+	}
+	bot.AnswerCallback(callback.ID, "🔄 Изменения")
+	ShowWeekChanges(callback.Message.Chat.ID, bot, user, weekStart, since)
+	return true
+}
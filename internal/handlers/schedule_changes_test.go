@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestWeekChangesSinceLastView(t *testing.T) {
+	student := newConversation(t, 1007)
+	student.loggedIn("ST-0002", "secret12")
+	student.press("week_next_2025-03-17")
+	student.transcript()
+
+	// Преподаватель переносит практику в другую аудиторию через диалог редактирования
+	teacher := &conversation{t: t, bot: student.bot, srv: student.srv, chatID: 2002}
+	teacher.loggedIn("TH-0002", "teach123")
+	teacher.press("edit_l_s2")
+	teacher.press("edit_room")
+	teacher.send("305")
+	teacher.press("edit_save")
+	teacher.transcript()
+
+	// Деканат отменяет лекцию и добавляет занятие, которое потом дважды правит
+	if err := scheduling.DeleteLesson(4, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	extra := models.Schedule{CourseID: 1, GroupName: testGroup, TeacherRegCode: "TH-0001",
+		ScheduleTime: time.Date(2025, 3, 20, 8, 0, 0, 0, time.UTC), Duration: 90, LessonType: "Лекция", Auditory: "101"}
+	if err := scheduling.CreateLesson(&extra, true, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	extra.Auditory = "102"
+	if err := scheduling.UpdateLesson(extra, true, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	// Добавленное и удалённое занятие, а также изменения другой недели в список не попадают
+	gone := models.Schedule{CourseID: 1, GroupName: testGroup, TeacherRegCode: "TH-0001",
+		ScheduleTime: time.Date(2025, 3, 21, 8, 0, 0, 0, time.UTC), Duration: 90, LessonType: "Лекция"}
+	if err := scheduling.CreateLesson(&gone, true, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduling.DeleteLesson(gone.ID, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduling.DeleteLesson(5, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB.Exec(`UPDATE schedule_revisions SET changed_at = '2025-03-14T10:30:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+
+	student.press("week_next_2025-03-17")
+	student.press("wchg_2025-03-17_0")
+	// Неделя показана заново — теперь сравнивать не с чем
+	student.press("week_next_2025-03-17")
+	student.press("wchg_2025-03-17_7")
+	student.golden("week_changes")
+}
+
+func TestFirstWeekViewHasNoChanges(t *testing.T) {
+	student := newConversation(t, 1009)
+	student.loggedIn("ST-0002", "secret12")
+
+	// Изменения до первого просмотра (например, массовый импорт) пропущенными не считаются
+	extra := models.Schedule{CourseID: 1, GroupName: testGroup, TeacherRegCode: "TH-0001",
+		ScheduleTime: time.Date(2025, 3, 20, 8, 0, 0, 0, time.UTC), Duration: 90, LessonType: "Лекция", Auditory: "101"}
+	if err := scheduling.CreateLesson(&extra, true, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	student.transcript()
+	student.press("week_next_2025-03-17")
+	if got := student.transcript(); strings.Contains(got, "Изменения (") {
+		t.Errorf("при первом просмотре не должно быть изменений:\n%s", got)
+	}
+
+	extra.Auditory = "102"
+	if err := scheduling.UpdateLesson(extra, true, scheduling.ChangedByAdmin); err != nil {
+		t.Fatal(err)
+	}
+	student.press("week_next_2025-03-17")
+	if got := student.transcript(); !strings.Contains(got, "Изменения (1)") {
+		t.Errorf("изменение после просмотра не показано:\n%s", got)
+	}
+}
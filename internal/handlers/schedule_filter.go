@@ -130,20 +130,20 @@ func GetRelevantLessonTypes(user *models.User) ([]string, error) {
 
 	if user.Role == "teacher" {
 		query = `
-			SELECT DISTINCT lesson_type
-			FROM schedules
-			WHERE teacher_reg_code = ?
+			SELECT lesson_type FROM schedules WHERE teacher_reg_code = ?
+			UNION
+			SELECT lesson_type FROM schedule_rules WHERE teacher_reg_code = ?
 			ORDER BY lesson_type
 		`
-		args = append(args, user.RegistrationCode)
+		args = append(args, user.RegistrationCode, user.RegistrationCode)
 	} else {
 		query = `
-			SELECT DISTINCT lesson_type
-			FROM schedules
-			WHERE group_name = ?
+			SELECT lesson_type FROM schedules WHERE group_name = ?
+			UNION
+			SELECT lesson_type FROM schedule_rules WHERE group_name = ?
 			ORDER BY lesson_type
 		`
-		args = append(args, user.Group)
+		args = append(args, user.Group, user.Group)
 	}
 
 	rows, err := db.DB.Query(query, args...)
@@ -164,7 +164,7 @@ func GetRelevantLessonTypes(user *models.User) ([]string, error) {
 }
 
 // ShowFilterMenu отображает улучшенное меню фильтров расписания
-func ShowFilterMenu(chatID int64, bot *tgbotapi.BotAPI) error {
+func ShowFilterMenu(chatID int64, bot Messenger) error {
 	// Get user data
 	user, err := auth.GetUserByTelegramID(chatID)
 	if err != nil {
@@ -245,7 +245,7 @@ func ShowFilterMenu(chatID int64, bot *tgbotapi.BotAPI) error {
 }
 
 // ShowCourseFilterMenu отображает улучшенное меню выбора курса для фильтрации
-func ShowCourseFilterMenu(chatID int64, bot *tgbotapi.BotAPI) {
+func ShowCourseFilterMenu(chatID int64, bot Messenger) {
 	// Get the user
 	user, err := auth.GetUserByTelegramID(chatID)
 	if err != nil {
@@ -312,7 +312,7 @@ func ShowCourseFilterMenu(chatID int64, bot *tgbotapi.BotAPI) {
 }
 
 // ShowLessonTypeFilterMenu отображает улучшенное меню выбора типа занятия
-func ShowLessonTypeFilterMenu(chatID int64, bot *tgbotapi.BotAPI) error {
+func ShowLessonTypeFilterMenu(chatID int64, bot Messenger) error {
 	user, err := auth.GetUserByTelegramID(chatID)
 	if err != nil {
 		msg := tgbotapi.NewMessage(chatID, "Ошибка получения данных пользователя")
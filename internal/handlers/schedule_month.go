@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// calendarNoop — callback_data для неактивных клеток календаря (заголовки, пустые дни).
+const calendarNoop = "cal_noop"
+
+var monthNames = [...]string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+var weekdayShortNames = [...]string{"Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"}
+
+// monthStart возвращает первое число месяца, в который попадает дата.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ShowScheduleMonth отправляет календарь месяца: дни с занятиями помечены числом пар.
+// month – любая дата нужного месяца.
+func ShowScheduleMonth(chatID int64, bot Messenger, user *models.User, month time.Time) error {
+	if user == nil {
+		msg := tgbotapi.NewMessage(chatID, "⚠️ Необходимо войти в систему для просмотра расписания.")
+		return sendAndTrackMessage(bot, msg)
+	}
+	first := monthStart(month)
+	last := first.AddDate(0, 1, -1)
+
+	var schedules []models.Schedule
+	var err error
+	if user.Role == "teacher" {
+		schedules, err = GetSchedulesForTeacherByDateRange(user.RegistrationCode, first, last)
+	} else {
+		schedules, err = GetSchedulesForStudentByDateRange(user, first, last)
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка отображения расписания на месяц: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	filter := GetUserFilter(chatID)
+	filteredSchedules := ApplyFilters(schedules, filter)
+
+	text := FormatMonthSummary(filteredSchedules, first)
+	if filter.CourseName != "" || filter.LessonType != "" {
+		text += "\n<b>📌 Активные фильтры:</b>\n"
+		if filter.CourseName != "" {
+			text += fmt.Sprintf("• Курс: <b>%s</b>\n", filter.CourseName)
+		}
+		if filter.LessonType != "" {
+			text += fmt.Sprintf("• Тип занятия: <b>%s</b>\n", filter.LessonType)
+		}
+	}
+
+	keyboard := BuildMonthCalendarKeyboard(first, filteredSchedules)
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔍 Настроить фильтры", "filter_menu"),
+	))
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, BuildModeSwitchKeyboard("mode_month").InlineKeyboard...)
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = keyboard
+	return sendAndTrackMessage(bot, msg)
+}
+
+// FormatMonthSummary формирует текст над календарём: месяц, число занятий и разбивку по типам.
+func FormatMonthSummary(schedules []models.Schedule, month time.Time) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🗓 <b>%s %d</b>\n\n", monthNames[month.Month()-1], month.Year()))
+
+	if len(schedules) == 0 {
+		sb.WriteString("🔍 <i>Нет занятий в этом месяце</i>\n")
+		return sb.String()
+	}
+
+	byType := make(map[string]int)
+	days := make(map[string]bool)
+	for _, s := range schedules {
+		byType[s.LessonType]++
+		days[s.ScheduleTime.Format("2006-01-02")] = true
+	}
+	sb.WriteString(fmt.Sprintf("📚 Занятий: <b>%d</b> в %d дн.\n", len(schedules), len(days)))
+
+	types := make([]string, 0, len(byType))
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		sb.WriteString(fmt.Sprintf("• %s: %d\n", t, byType[t]))
+	}
+	sb.WriteString("\n<i>Число рядом с датой — количество пар. Нажмите на день, чтобы открыть расписание.</i>\n")
+	return sb.String()
+}
+
+// BuildMonthCalendarKeyboard строит сетку месяца: навигация, заголовок дней недели и недели по строкам.
+func BuildMonthCalendarKeyboard(month time.Time, schedules []models.Schedule) tgbotapi.InlineKeyboardMarkup {
+	first := monthStart(month)
+	counts := make(map[int]int)
+	for _, s := range schedules {
+		if s.ScheduleTime.Year() == first.Year() && s.ScheduleTime.Month() == first.Month() {
+			counts[s.ScheduleTime.Day()]++
+		}
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("◄", "month_"+first.AddDate(0, -1, 0).Format("2006-01")),
+			tgbotapi.NewInlineKeyboardButtonData("Сегодня", "month_today"),
+			tgbotapi.NewInlineKeyboardButtonData("►", "month_"+first.AddDate(0, 1, 0).Format("2006-01")),
+		),
+	}
+
+	var header []tgbotapi.InlineKeyboardButton
+	for _, name := range weekdayShortNames {
+		header = append(header, tgbotapi.NewInlineKeyboardButtonData(name, calendarNoop))
+	}
+	rows = append(rows, header)
+
+	// Смещение первого числа относительно понедельника
+	offset := (int(first.Weekday()) + 6) % 7
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	var week []tgbotapi.InlineKeyboardButton
+	for i := 0; i < offset; i++ {
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(" ", calendarNoop))
+	}
+	for day := 1; day <= daysInMonth; day++ {
+		label := fmt.Sprintf("%d", day)
+		if n := counts[day]; n > 0 {
+			label = fmt.Sprintf("%d•%d", day, n)
+		}
+		date := time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+		if _, holiday := scheduling.CurrentCalendar().Holiday(date); holiday {
+			label += "🎉"
+		}
+		week = append(week, tgbotapi.NewInlineKeyboardButtonData(label, "day_"+date.Format("2006-01-02")))
+		if len(week) == 7 {
+			rows = append(rows, week)
+			week = nil
+		}
+	}
+	if len(week) > 0 {
+		for len(week) < 7 {
+			week = append(week, tgbotapi.NewInlineKeyboardButtonData(" ", calendarNoop))
+		}
+		rows = append(rows, week)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+// withRuleOccurrences добавляет к разовым занятиям занятия, развёрнутые из правил.
+// Дни, заменённые, перенесённые или отменённые разовыми строками, пропускаются.
+func withRuleOccurrences(schedules []models.Schedule, rules []scheduling.RuleInfo, start, end time.Time) ([]models.Schedule, error) {
+	if len(rules) == 0 {
+		return schedules, nil
+	}
+	overridden, err := scheduling.OverriddenDays(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		times, err := scheduling.Occurrences(rule.ScheduleRule, start, end)
+		if err != nil {
+			fmt.Printf("Ошибка развёртывания правила %d: %v\n", rule.ID, err)
+			continue
+		}
+		for _, t := range times {
+			if overridden[scheduling.OccurrenceKey(rule.ID, t)] {
+				continue
+			}
+			s := models.Schedule{
+				CourseID:       rule.CourseID,
+				GroupName:      rule.GroupName,
+				TeacherRegCode: rule.TeacherRegCode,
+				TeacherName:    rule.TeacherName,
+				ScheduleTime:   t,
+				Description:    rule.Description,
+				Auditory:       rule.Auditory,
+				LessonType:     rule.LessonType,
+				Duration:       rule.Duration,
+				RuleID:         rule.ID,
+				SubgroupID:     rule.SubgroupID,
+				SubgroupName:   rule.SubgroupName,
+			}
+			if rule.CourseName != "Неизвестный курс" {
+				s.Description = rule.CourseName + ": " + s.Description
+			}
+			schedules = append(schedules, s)
+		}
+	}
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return schedules[i].ScheduleTime.Before(schedules[j].ScheduleTime)
+	})
+	return schedules, nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/scheduling"
+)
+
+func TestRuleOccurrencesWithOverrides(t *testing.T) {
+	openTestDB(t)
+	resetHandlerState()
+
+	rule := &models.ScheduleRule{
+		CourseID:       2,
+		GroupName:      testGroup,
+		TeacherRegCode: "TH-0002",
+		Weekday:        time.Thursday,
+		StartTime:      "11:45",
+		Duration:       90,
+		Description:    "Структуры данных",
+		Auditory:       "301",
+		LessonType:     "Практика",
+		ValidFrom:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:        time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	if err := scheduling.CreateRule(rule, false); err != nil {
+		t.Fatal(err)
+	}
+	// 20.03 занятие перенесено в другую аудиторию, 27.03 — отменено, 03.04 — исключение в правиле
+	if _, err := db.DB.Exec(`
+		INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration, rule_id, rule_date, cancelled)
+		VALUES (2, ?, 'TH-0002', '2025-03-20T11:45:00Z', 'Структуры данных', '405', 'Практика', 90, ?, '2025-03-20', 0),
+		       (2, ?, 'TH-0002', '2025-03-27T11:45:00Z', 'Структуры данных', '301', 'Практика', 90, ?, '2025-03-27', 1)`,
+		testGroup, rule.ID, testGroup, rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduling.AddRuleException(rule.ID, time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 4, 13, 0, 0, 0, 0, time.UTC)
+	for name, load := range map[string]func() ([]models.Schedule, error){
+		"group":   func() ([]models.Schedule, error) { return GetSchedulesForGroupByDateRange(testGroup, start, end) },
+		"teacher": func() ([]models.Schedule, error) { return GetSchedulesForTeacherByDateRange("TH-0002", start, end) },
+	} {
+		schedules, err := load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, s := range schedules {
+			if s.RuleID == rule.ID {
+				got = append(got, s.ScheduleTime.Format("01-02")+" "+s.Auditory)
+			}
+		}
+		want := []string{"03-20 405", "04-10 301"}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+		for i := 1; i < len(schedules); i++ {
+			if schedules[i].ScheduleTime.Before(schedules[i-1].ScheduleTime) {
+				t.Errorf("%s: занятия не отсортированы по времени", name)
+			}
+		}
+	}
+}
+
+func TestRuleOccurrenceMovedToAnotherWeek(t *testing.T) {
+	openTestDB(t)
+	resetHandlerState()
+
+	rule := &models.ScheduleRule{
+		CourseID:       2,
+		GroupName:      testGroup,
+		TeacherRegCode: "TH-0002",
+		Weekday:        time.Thursday,
+		StartTime:      "11:45",
+		Duration:       90,
+		Description:    "Структуры данных",
+		Auditory:       "301",
+		ValidFrom:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:        time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	if err := scheduling.CreateRule(rule, false); err != nil {
+		t.Fatal(err)
+	}
+	// Занятие 20.03 перенесено на вторник следующей недели
+	moved := scheduling.RuleLesson(*rule, time.Date(2025, 3, 25, 13, 30, 0, 0, time.UTC))
+	moved.RuleDate = time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	if err := scheduling.OverrideOccurrence(&moved, true, "TH-0002"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, week := range []struct {
+		start time.Time
+		want  string
+	}{
+		{time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), ""},
+		{time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC), "03-25 13:30, 03-27 11:45"},
+	} {
+		end := week.start.AddDate(0, 0, 6)
+		for name, load := range map[string]func() ([]models.Schedule, error){
+			"group": func() ([]models.Schedule, error) { return GetSchedulesForGroupByDateRange(testGroup, week.start, end) },
+			"teacher": func() ([]models.Schedule, error) {
+				return GetSchedulesForTeacherByDateRange("TH-0002", week.start, end)
+			},
+		} {
+			schedules, err := load()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			var got []string
+			for _, s := range schedules {
+				if s.RuleID == rule.ID {
+					got = append(got, s.ScheduleTime.Format("01-02 15:04"))
+				}
+			}
+			if strings.Join(got, ", ") != week.want {
+				t.Errorf("%s, неделя %s: got %v, want %q", name, week.start.Format("02.01"), got, week.want)
+			}
+		}
+	}
+}
+
+func TestTeacherCancelsMovedRuleLesson(t *testing.T) {
+	c := newConversation(t, 2007)
+	c.loggedIn("TH-0002", "teach123")
+
+	rule := &models.ScheduleRule{
+		CourseID:       2,
+		GroupName:      testGroup,
+		TeacherRegCode: "TH-0002",
+		Weekday:        time.Thursday,
+		StartTime:      "11:45",
+		Duration:       90,
+		Description:    "Структуры данных",
+		Auditory:       "301",
+		ValidFrom:      time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		ValidTo:        time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	if err := scheduling.CreateRule(rule, false); err != nil {
+		t.Fatal(err)
+	}
+	moved := scheduling.RuleLesson(*rule, time.Date(2025, 3, 25, 13, 30, 0, 0, time.UTC))
+	moved.RuleDate = time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	if err := scheduling.OverrideOccurrence(&moved, true, "TH-0002"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Отмена перенесённого занятия не должна возвращать исходное занятие по правилу
+	c.press(fmt.Sprintf("edit_l_s%d", moved.ID))
+	c.press("edit_cancel")
+	c.press("edit_cancel_yes")
+
+	schedules, err := GetSchedulesForGroupByDateRange(testGroup,
+		time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range schedules {
+		if s.RuleID == rule.ID {
+			got = append(got, s.ScheduleTime.Format("01-02 15:04"))
+		}
+	}
+	if want := "03-27 11:45"; strings.Join(got, ", ") != want {
+		t.Errorf("got %v, want %q", got, want)
+	}
+}
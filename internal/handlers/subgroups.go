@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"education/internal/auth"
+	"education/internal/models"
+	"education/internal/scheduling"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const subgroupsHelp = "Команды:\n" +
+	"/subgroups АА-23-01 — подгруппы группы\n" +
+	"/subgroups АА-23-01 add 1 — добавить подгруппу\n" +
+	"/subgroups АА-23-01 del 1 — удалить подгруппу\n\n" +
+	"Студенты выбирают подгруппу в главном меню, преподаватели назначают подгруппу занятию при редактировании."
+
+// filterSubgroup оставляет занятия всей группы и подгруппы subgroupID.
+func filterSubgroup(schedules []models.Schedule, subgroupID int64) []models.Schedule {
+	if subgroupID == 0 {
+		return schedules
+	}
+	var result []models.Schedule
+	for _, s := range schedules {
+		if s.ForSubgroup(subgroupID) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// groupHasSubgroups сообщает, разделена ли группа на подгруппы.
+func groupHasSubgroups(group string) bool {
+	list, err := scheduling.ListSubgroups(group)
+	if err != nil {
+		fmt.Println("Ошибка получения подгрупп:", err)
+		return false
+	}
+	return len(list) > 0
+}
+
+// subgroupName возвращает название подгруппы студента или пустую строку.
+func subgroupName(user *models.User) string {
+	if user.SubgroupID == 0 {
+		return ""
+	}
+	sg, err := scheduling.GetSubgroup(user.SubgroupID)
+	if err != nil {
+		return ""
+	}
+	return sg.Name
+}
+
+// ShowSubgroupChoice предлагает студенту выбрать подгруппу своей группы.
+func ShowSubgroupChoice(chatID int64, bot Messenger, user *models.User) error {
+	list, err := scheduling.ListSubgroups(user.Group)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, "Ошибка получения подгрупп: "+err.Error())
+		return sendAndTrackMessage(bot, msg)
+	}
+	if len(list) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "У группы "+user.Group+" нет подгрупп: все занятия проходят для всей группы.")
+		return sendAndTrackMessage(bot, msg)
+	}
+
+	current := subgroupName(user)
+	if current == "" {
+		current = "не выбрана"
+	}
+	text := fmt.Sprintf("👥 <b>Подгруппа</b>\n\nГруппа %s, подгруппа: <b>%s</b>\n\n"+
+		"В расписании останутся занятия всей группы и вашей подгруппы. "+
+		"Без подгруппы показываются занятия всех подгрупп.",
+		html.EscapeString(user.Group), html.EscapeString(current))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, sg := range list {
+		label := "Подгруппа " + sg.Name
+		if sg.ID == user.SubgroupID {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("sgrp_%d", sg.ID)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if user.SubgroupID != 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Показывать все подгруппы", "sgrp_0"),
+		))
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "HTML"
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return sendAndTrackMessage(bot, msg)
+}
+
+// ProcessSubgroupCallback обрабатывает выбор подгруппы студентом.
+// Возвращает true, если callback обработан.
+func ProcessSubgroupCallback(callback *tgbotapi.CallbackQuery, bot Messenger, user *models.User) bool {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+	if data != "menu_subgroup" && !strings.HasPrefix(data, "sgrp_") {
+		return false
+	}
+	if user.Role == "teacher" {
+		bot.AnswerCallback(callback.ID, "Подгруппу выбирают студенты")
+		return true
+	}
+	if data == "menu_subgroup" {
+		bot.AnswerCallback(callback.ID, "👥 Подгруппа")
+		ShowSubgroupChoice(chatID, bot, user)
+		return true
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(data, "sgrp_"), 10, 64)
+	if err != nil {
+		bot.AnswerCallback(callback.ID, "Неизвестная подгруппа")
+		return true
+	}
+	answer := "✅ Показываются все подгруппы"
+	if id != 0 {
+		sg, err := scheduling.GetSubgroup(id)
+		if err != nil || sg.GroupName != user.Group {
+			bot.AnswerCallback(callback.ID, "Подгруппа не найдена")
+			return true
+		}
+		answer = "✅ Подгруппа " + sg.Name
+	}
+	if err := auth.SetUserSubgroup(user.ID, id); err != nil {
+		bot.AnswerCallback(callback.ID, "Ошибка сохранения подгруппы")
+		return true
+	}
+	user.SubgroupID = id
+	bot.AnswerCallback(callback.ID, answer)
+	ShowSubgroupChoice(chatID, bot, user)
+	return true
+}
+
+// ShowSubgroupsAdmin показывает администратору подгруппы группы или меняет их.
+// args: "группа" — список; "группа add название"; "группа del название".
+func ShowSubgroupsAdmin(chatID int64, bot Messenger, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "Укажите группу.\n\n"+subgroupsHelp))
+	}
+	group := fields[0]
+	if len(fields) > 1 {
+		var err error
+		name := strings.Join(fields[2:], " ")
+		switch strings.ToLower(fields[1]) {
+		case "add":
+			_, err = scheduling.AddSubgroup(group, name)
+		case "del":
+			var sg models.Subgroup
+			if sg, err = scheduling.FindSubgroup(group, name); err == nil {
+				err = scheduling.DeleteSubgroup(sg.ID)
+			}
+		default:
+			err = errors.New("неизвестная команда")
+		}
+		if err != nil {
+			return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "⚠️ "+err.Error()+"\n\n"+subgroupsHelp))
+		}
+	}
+
+	list, err := scheduling.ListSubgroups(group)
+	if err != nil {
+		return sendAndTrackMessage(bot, tgbotapi.NewMessage(chatID, "Ошибка получения подгрупп: "+err.Error()))
+	}
+	var sb strings.Builder
+	sb.WriteString("👥 <b>Подгруппы группы " + html.EscapeString(group) + "</b>\n\n")
+	if len(list) == 0 {
+		sb.WriteString("Группа не разделена на подгруппы.\n")
+	}
+	for _, sg := range list {
+		sb.WriteString("• " + html.EscapeString(sg.Name) + "\n")
+	}
+	sb.WriteString("\n" + html.EscapeString(subgroupsHelp))
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ParseMode = "HTML"
+	return sendAndTrackMessage(bot, msg)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/scheduling"
+)
+
+func TestSubgroupLessonsInStudentSchedule(t *testing.T) {
+	admin := newConversation(t, 9002)
+	SetAdminChatID(9002)
+	t.Cleanup(func() { SetAdminChatID(0) })
+
+	admin.send("/subgroups АА-23-01 add 1")
+	admin.send("/subgroups АА-23-01 add 2")
+	admin.send("/subgroups АА-23-01 add 1")
+	admin.send("/subgroups ЯЯ-00-00 add 1")
+	admin.golden("subgroups_admin")
+
+	// Практика 17.03 в 09:45 проводится только для первой подгруппы
+	teacher := &conversation{t: t, bot: admin.bot, srv: admin.srv, chatID: 2002}
+	teacher.loggedIn("TH-0002", "teach123")
+	teacher.press("edit_l_s2")
+	teacher.press("edit_sg")
+	teacher.press("edit_setsg_1")
+	teacher.press("edit_save")
+	teacher.golden("subgroups_teacher")
+
+	student := &conversation{t: t, bot: admin.bot, srv: admin.srv, chatID: 1007}
+	student.loggedIn("ST-0002", "secret12")
+	student.send("/start")
+	student.press("menu_subgroup")
+	student.press("sgrp_2")
+	student.press("day_2025-03-17")
+	student.golden("subgroups_student")
+
+	// Пока подгруппа не выбрана, студент видит занятия всех подгрупп
+	student.press("sgrp_0")
+	student.transcript()
+	student.press("day_2025-03-17")
+	if got := student.transcript(); !strings.Contains(got, "Подгруппа: 1") {
+		t.Errorf("без подгруппы не видно занятия первой подгруппы:\n%s", got)
+	}
+
+	// Удалить подгруппу с занятиями нельзя
+	admin.send("/subgroups АА-23-01 del 1")
+	if got := admin.transcript(); !strings.Contains(got, "сначала перенесите") {
+		t.Errorf("подгруппа с занятиями удалена:\n%s", got)
+	}
+}
+
+func TestSubgroupFilterInDigestAndFeed(t *testing.T) {
+	c := newConversation(t, 1011)
+	c.loggedIn("ST-0002", "secret12")
+	first, err := scheduling.AddSubgroup(testGroup, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := scheduling.AddSubgroup(testGroup, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Практика «Циклы» 17.03 только для первой подгруппы, студентка — во второй
+	if _, err := db.DB.Exec(`UPDATE schedules SET subgroup_id = ? WHERE id = 2`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.DB.Exec(`UPDATE users SET subgroup_id = ? WHERE registration_code = 'ST-0002'`, second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	c.press("menu_digest")
+	c.press("dig_time_0700")
+	c.srv.Reset()
+	sendDueDigests(c.bot, time.Date(2025, 3, 17, 7, 5, 0, 0, time.UTC))
+	sent := c.srv.CallsTo("sendMessage")
+	if len(sent) != 1 || !strings.Contains(sent[0].Text(), "Пределы") || strings.Contains(sent[0].Text(), "Циклы") {
+		t.Errorf("в сводке должны быть только занятия своей подгруппы: %v", sent)
+	}
+
+	token, err := GetFeedToken("ST-0002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _, _, err := renderFeed(token, time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "Пределы") || strings.Contains(string(body), "Циклы") {
+		t.Errorf("в ленте должны быть только занятия своей подгруппы:\n%s", body)
+	}
+}
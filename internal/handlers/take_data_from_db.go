@@ -8,7 +8,7 @@ import (
 )
 
 // sendRoleSelection отправляет inline‑кнопки для выбора роли.
-func sendRoleSelection(chatID int64, bot *tgbotapi.BotAPI) {
+func sendRoleSelection(chatID int64, bot Messenger) {
 	var rows [][]tgbotapi.InlineKeyboardButton
 
 	// Кнопки выбора роли
@@ -28,7 +28,7 @@ func sendRoleSelection(chatID int64, bot *tgbotapi.BotAPI) {
 }
 
 // sendFacultySelection отправляет inline‑кнопки факультетов с использованием кэша.
-func sendFacultySelection(chatID int64, bot *tgbotapi.BotAPI) {
+func sendFacultySelection(chatID int64, bot Messenger) {
 	facs := GetFaculties()
 	if len(facs) == 0 {
 		var err error
@@ -60,7 +60,7 @@ func sendFacultySelection(chatID int64, bot *tgbotapi.BotAPI) {
 }
 
 // sendGroupSelection отправляет inline‑кнопки групп для выбранного факультета с использованием кэша.
-func sendGroupSelection(chatID int64, facultyName string, bot *tgbotapi.BotAPI) {
+func sendGroupSelection(chatID int64, facultyName string, bot Messenger) {
 	groups := GetGroups(facultyName)
 	if len(groups) == 0 {
 		var err error
@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"education/internal/db"
+)
+
+// Monitor собирает сведения о состоянии бота для /healthz и /readyz.
+type Monitor struct {
+	database   *sql.DB
+	queueLen   func() int
+	queueLimit int          // при очереди длиннее бот не готов (0 — без ограничения)
+	lastUpdate atomic.Int64 // unix-время последнего апдейта от Telegram
+	migrated   atomic.Bool
+	cacheWarm  atomic.Bool
+}
+
+// Report — тело ответа эндпоинтов.
+type Report struct {
+	Status           string `json:"status"`
+	Database         string `json:"database"`
+	LastUpdate       string `json:"last_update,omitempty"`
+	QueueBacklog     int    `json:"queue_backlog"`
+	QueueLimit       int    `json:"queue_limit,omitempty"`
+	MigrationVersion int    `json:"migration_version"`
+	Migrated         bool   `json:"migrated"`
+	CacheWarm        bool   `json:"cache_warm"`
+}
+
+// NewMonitor создаёт монитор. queueLen возвращает текущую длину очереди воркеров;
+// если она больше queueLimit, /readyz отвечает, что бот не готов (0 — без ограничения).
+func NewMonitor(database *sql.DB, queueLen func() int, queueLimit int) *Monitor {
+	return &Monitor{database: database, queueLen: queueLen, queueLimit: queueLimit}
+}
+
+// MarkUpdate отмечает время получения очередного апдейта.
+func (m *Monitor) MarkUpdate() {
+	m.lastUpdate.Store(time.Now().Unix())
+}
+
+// SetMigrated отмечает, что миграции БД применены.
+func (m *Monitor) SetMigrated() {
+	m.migrated.Store(true)
+}
+
+// SetCacheWarm отмечает, что прогрев кэша завершён.
+func (m *Monitor) SetCacheWarm() {
+	m.cacheWarm.Store(true)
+}
+
+// Register вешает /healthz и /readyz на переданный mux.
+func (m *Monitor) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", m.handleHealth)
+	mux.HandleFunc("/readyz", m.handleReady)
+}
+
+// collect формирует отчёт и возвращает признак доступности БД.
+func (m *Monitor) collect(ctx context.Context) (Report, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	r := Report{
+		Database:   "ok",
+		Migrated:   m.migrated.Load(),
+		CacheWarm:  m.cacheWarm.Load(),
+		QueueLimit: m.queueLimit,
+	}
+	if m.queueLen != nil {
+		r.QueueBacklog = m.queueLen()
+	}
+	if ts := m.lastUpdate.Load(); ts != 0 {
+		r.LastUpdate = time.Unix(ts, 0).UTC().Format(time.RFC3339)
+	}
+
+	dbOK := true
+	if err := m.database.PingContext(ctx); err != nil {
+		r.Database = err.Error()
+		dbOK = false
+	} else if v, err := db.MigrationVersion(ctx); err == nil {
+		r.MigrationVersion = v
+	}
+	return r, dbOK
+}
+
+// handleHealth отвечает 200, пока процесс жив и БД доступна.
+func (m *Monitor) handleHealth(w http.ResponseWriter, r *http.Request) {
+	report, dbOK := m.collect(r.Context())
+	code := http.StatusOK
+	report.Status = "ok"
+	if !dbOK {
+		code = http.StatusServiceUnavailable
+		report.Status = "unhealthy"
+	}
+	writeJSON(w, code, report)
+}
+
+// handleReady отвечает 200 только после миграций и прогрева кэша, пока очередь не переполнена.
+func (m *Monitor) handleReady(w http.ResponseWriter, r *http.Request) {
+	report, dbOK := m.collect(r.Context())
+	code := http.StatusOK
+	report.Status = "ready"
+	if !dbOK || !report.Migrated || !report.CacheWarm || report.MigrationVersion != db.SchemaVersion ||
+		(report.QueueLimit > 0 && report.QueueBacklog > report.QueueLimit) {
+		code = http.StatusServiceUnavailable
+		report.Status = "not_ready"
+	}
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
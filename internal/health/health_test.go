@@ -0,0 +1,78 @@
+package health
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"education/internal/db"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		queue      int
+		notMigrate bool
+		oldSchema  bool
+		dbDown     bool
+		wantHealth int
+		wantReady  int
+		wantStatus string
+	}{
+		{name: "ready", queue: 3, wantHealth: http.StatusOK, wantReady: http.StatusOK, wantStatus: "ready"},
+		{name: "db_down", dbDown: true, wantHealth: http.StatusServiceUnavailable, wantReady: http.StatusServiceUnavailable, wantStatus: "not_ready"},
+		{name: "queue_over_limit", queue: 11, wantHealth: http.StatusOK, wantReady: http.StatusServiceUnavailable, wantStatus: "not_ready"},
+		{name: "schema_mismatch", oldSchema: true, wantHealth: http.StatusOK, wantReady: http.StatusServiceUnavailable, wantStatus: "not_ready"},
+		{name: "not_migrated", notMigrate: true, wantHealth: http.StatusOK, wantReady: http.StatusServiceUnavailable, wantStatus: "not_ready"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db.Open(fmt.Sprintf("file:health_%s?mode=memory&cache=shared", tc.name))
+			t.Cleanup(func() { db.DB.Close() })
+			if tc.oldSchema {
+				if _, err := db.DB.Exec(fmt.Sprintf("PRAGMA user_version = %d", db.SchemaVersion-1)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			database := db.DB
+			if tc.dbDown {
+				closed, err := sql.Open("sqlite3", ":memory:")
+				if err != nil {
+					t.Fatal(err)
+				}
+				closed.Close()
+				database = closed
+			}
+
+			m := NewMonitor(database, func() int { return tc.queue }, 10)
+			if !tc.notMigrate {
+				m.SetMigrated()
+			}
+			m.SetCacheWarm()
+			mux := http.NewServeMux()
+			m.Register(mux)
+
+			get := func(path string) (int, Report) {
+				rec := httptest.NewRecorder()
+				mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				var r Report
+				if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+					t.Fatalf("%s: %v", path, err)
+				}
+				return rec.Code, r
+			}
+			if code, _ := get("/healthz"); code != tc.wantHealth {
+				t.Errorf("/healthz = %d, ожидалось %d", code, tc.wantHealth)
+			}
+			code, report := get("/readyz")
+			if code != tc.wantReady || report.Status != tc.wantStatus {
+				t.Errorf("/readyz = %d %q, ожидалось %d %q (%+v)", code, report.Status, tc.wantReady, tc.wantStatus, report)
+			}
+			if report.QueueBacklog != tc.queue || report.QueueLimit != 10 {
+				t.Errorf("очередь в отчёте: %d/%d", report.QueueBacklog, report.QueueLimit)
+			}
+		})
+	}
+}
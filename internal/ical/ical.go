@@ -0,0 +1,95 @@
+// Package ical формирует календари в формате iCalendar (RFC 5545) для импорта в календарные приложения.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets — максимальная длина строки содержимого без CRLF (RFC 5545, 3.1).
+const maxLineOctets = 75
+
+// Время занятий в боте хранится как «настенное» время без часового пояса, поэтому
+// события выгружаются в плавающем формате: 08:00 в боте — 08:00 в календаре на любом устройстве.
+const floatingLayout = "20060102T150405"
+
+// Event — одно событие календаря (VEVENT).
+type Event struct {
+	UID         string // постоянный идентификатор: по нему календарь обновляет событие при повторном импорте
+	Start, End  time.Time
+	Summary     string
+	Location    string
+	Description string
+}
+
+// Calendar — набор событий (VCALENDAR).
+type Calendar struct {
+	Name   string // X-WR-CALNAME, отображаемое имя календаря
+	Events []Event
+}
+
+// Marshal кодирует календарь. stamp попадает в DTSTAMP каждого события.
+func (c Calendar) Marshal(stamp time.Time) []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//education-bot//schedule//RU")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART", e.Start.Format(floatingLayout))
+		line("DTEND", e.End.Format(floatingLayout))
+		line("SUMMARY", EscapeText(e.Summary))
+		if e.Location != "" {
+			line("LOCATION", EscapeText(e.Location))
+		}
+		if e.Description != "" {
+			line("DESCRIPTION", EscapeText(e.Description))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+// EscapeText экранирует значение типа TEXT (RFC 5545, 3.3.11).
+func EscapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// writeFolded записывает строку содержимого, перенося её каждые 75 октетов
+// (продолжение начинается с пробела) и не разрывая многобайтные символы UTF-8.
+func writeFolded(buf *bytes.Buffer, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // пробел в начале строки продолжения тоже считается
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,62 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalEvent(t *testing.T) {
+	start := time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC)
+	cal := Calendar{Name: "Расписание", Events: []Event{{
+		UID:         "s1@education-bot",
+		Start:       start,
+		End:         start.Add(90 * time.Minute),
+		Summary:     "Матем, Лекция",
+		Location:    "101",
+		Description: "Пределы; глава 1\nДомашнее задание",
+	}}}
+	got := string(cal.Marshal(time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)))
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//education-bot//schedule//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Расписание",
+		"BEGIN:VEVENT",
+		"UID:s1@education-bot",
+		"DTSTAMP:20250301T120000Z",
+		"DTSTART:20250317T080000",
+		"DTEND:20250317T093000",
+		`SUMMARY:Матем\, Лекция`,
+		"LOCATION:101",
+		`DESCRIPTION:Пределы\; глава 1\nДомашнее задание`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLongLinesAreFoldedOnRuneBoundaries(t *testing.T) {
+	summary := strings.Repeat("Математический анализ ", 10)
+	got := string(Calendar{Events: []Event{{UID: "x", Summary: summary}}}.Marshal(time.Now()))
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(got, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("строка длиннее %d октетов: %q", maxLineOctets, line)
+		}
+		if !strings.HasPrefix(line, " ") {
+			unfolded.WriteString("\n")
+		}
+		unfolded.WriteString(strings.TrimPrefix(line, " "))
+	}
+	if !strings.Contains(unfolded.String(), "\nSUMMARY:"+summary+"\n") {
+		t.Errorf("после склейки строк SUMMARY искажён:\n%s", unfolded.String())
+	}
+}
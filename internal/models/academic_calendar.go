@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// CalendarPeriodKind — вид периода учебного календаря.
+type CalendarPeriodKind string
+
+const (
+	PeriodSemester CalendarPeriodKind = "semester" // семестр вместе с сессией; от его начала считаются учебные недели
+	PeriodHoliday  CalendarPeriodKind = "holiday"  // праздники и каникулы: занятия по правилам не проводятся
+	PeriodExams    CalendarPeriodKind = "exams"    // экзаменационная сессия
+)
+
+// CalendarPeriod — период учебного календаря, даты включительно.
+type CalendarPeriod struct {
+	ID    int64
+	Kind  CalendarPeriodKind
+	Name  string
+	Start time.Time
+	End   time.Time
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Consultation — консультация преподавателя, на которую записываются студенты.
+type Consultation struct {
+	ID             int64
+	TeacherRegCode string
+	TeacherName    string // для отображения, заполняется при чтении
+	StartsAt       time.Time
+	Duration       int    // минут
+	Place          string // аудитория или ссылка на онлайн-встречу
+	Capacity       int    // число мест
+	Booked         int    // сколько студентов записалось
+}
+
+// End возвращает время окончания консультации.
+func (c Consultation) End() time.Time {
+	return c.StartsAt.Add(time.Duration(c.Duration) * time.Minute)
+}
+
+// FreePlaces возвращает число свободных мест.
+func (c Consultation) FreePlaces() int {
+	if c.Booked >= c.Capacity {
+		return 0
+	}
+	return c.Capacity - c.Booked
+}
+
+// ConsultationBooking — запись студента на консультацию.
+type ConsultationBooking struct {
+	StudentRegCode string
+	StudentName    string
+	GroupName      string
+	TelegramID     int64 // 0 — студент не в боте
+	BookedAt       time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+// Room — аудитория из справочника.
+type Room struct {
+	ID        int64
+	Name      string // номер аудитории, как он указывается в расписании
+	Building  string // корпус
+	Floor     int
+	Capacity  int    // число мест
+	Equipment string // оборудование через запятую
+}
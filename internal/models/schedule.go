@@ -7,10 +7,31 @@ type Schedule struct {
 	CourseID       int64
 	GroupName      string
 	TeacherRegCode string
+	TeacherName    string // Имя преподавателя для отображения (заполняется при чтении расписания)
 	ScheduleTime   time.Time
 	Description    string
 
 	Auditory   string // Новое поле: аудитория
 	LessonType string // Новое поле: тип занятия (лекция/практика/семинар)
 	Duration   int    // Продолжительность в минутах, например
+
+	RuleID   int64     // Правило, из которого развёрнуто занятие (0 — разовое занятие)
+	RuleDate time.Time // День правила, который заменяет занятие (вместе с RuleID)
+
+	SubgroupID   int64  // Подгруппа, для которой проводится занятие (0 — вся группа)
+	SubgroupName string // Название подгруппы для отображения
+}
+
+// Audience возвращает группу занятия вместе с подгруппой, например "АА-23-01 (подгр. 1)".
+func (s Schedule) Audience() string {
+	if s.SubgroupName == "" {
+		return s.GroupName
+	}
+	return s.GroupName + " (подгр. " + s.SubgroupName + ")"
+}
+
+// ForSubgroup сообщает, проводится ли занятие для студентов подгруппы subgroupID.
+// Студент без подгруппы (0) видит все занятия группы.
+func (s Schedule) ForSubgroup(subgroupID int64) bool {
+	return s.SubgroupID == 0 || subgroupID == 0 || s.SubgroupID == subgroupID
 }
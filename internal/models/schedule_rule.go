@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// WeekParity — чётность недели, в которую проходит занятие.
+type WeekParity int
+
+const (
+	ParityAny  WeekParity = 0 // каждую неделю
+	ParityOdd  WeekParity = 1 // по нечётным неделям (числитель)
+	ParityEven WeekParity = 2 // по чётным неделям (знаменатель)
+)
+
+// ScheduleRule — повторяющееся занятие: день недели, время и период действия.
+type ScheduleRule struct {
+	ID             int64
+	CourseID       int64
+	GroupName      string
+	TeacherRegCode string
+	Weekday        time.Weekday
+	StartTime      string // "15:04", UTC, как и schedule_time
+	Duration       int    // в минутах
+	Description    string
+	Auditory       string
+	LessonType     string
+	ValidFrom      time.Time // первый день действия правила
+	ValidTo        time.Time // последний день действия правила (включительно)
+	Parity         WeekParity
+	Exceptions     []time.Time // даты, в которые занятия нет
+	SubgroupID     int64       // 0 — вся группа
+	SubgroupName   string
+}
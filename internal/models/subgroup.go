@@ -0,0 +1,8 @@
+package models
+
+// Subgroup — подгруппа учебной группы для лабораторных и практик.
+type Subgroup struct {
+	ID        int64
+	GroupName string
+	Name      string // например, "1" или "англ."
+}
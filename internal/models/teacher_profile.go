@@ -0,0 +1,24 @@
+package models
+
+// TeacherProfile — карточка преподавателя, которую видят студенты.
+type TeacherProfile struct {
+	RegistrationCode string
+	Name             string // ФИО из users
+	DisplayName      string // как преподаватель хочет, чтобы его называли; пусто — Name
+	Department       string // кафедра
+	Email            string
+	Telegram         string // имя пользователя без @
+	OfficeRoom       string // кабинет
+	OfficeHours      string // часы консультаций, свободный текст
+}
+
+// Title возвращает имя для отображения: выбранное преподавателем, ФИО или регистрационный код.
+func (p TeacherProfile) Title() string {
+	switch {
+	case p.DisplayName != "":
+		return p.DisplayName
+	case p.Name != "":
+		return p.Name
+	}
+	return p.RegistrationCode
+}
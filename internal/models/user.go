@@ -9,4 +9,5 @@ type User struct {
 	Group            string // Для преподавателей не используется
 	Password         string
 	RegistrationCode string
+	SubgroupID       int64 // Подгруппа студента (0 — не выбрана)
 }
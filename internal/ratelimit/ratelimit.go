@@ -0,0 +1,235 @@
+// Package ratelimit реализует ограничение частоты запросов по алгоритму token bucket
+// отдельно для каждого чата и класса действий, с временной блокировкой флудеров.
+package ratelimit
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit — параметры корзины токенов: Rate токенов в секунду, не больше Burst подряд.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Config — настройки лимитера.
+type Config struct {
+	Limits      map[string]Limit // лимиты по классам действий
+	Default     Limit            // лимит для классов, которых нет в Limits
+	BanAfter    int              // сколько отказов за BanWindow приводят к блокировке (0 — не блокировать)
+	BanWindow   time.Duration
+	BanDuration time.Duration
+}
+
+// DefaultConfig возвращает настройки по умолчанию.
+func DefaultConfig() Config {
+	return Config{
+		Limits: map[string]Limit{
+			"schedule":  {Rate: 1, Burst: 5},
+			"materials": {Rate: 1, Burst: 5},
+			"filters":   {Rate: 2, Burst: 8},
+			"messages":  {Rate: 1, Burst: 5},
+			// Telegram присылает inline-запрос почти на каждое нажатие клавиши
+			"inline": {Rate: 2, Burst: 15},
+		},
+		Default:     Limit{Rate: 2, Burst: 10},
+		BanAfter:    20,
+		BanWindow:   time.Minute,
+		BanDuration: 10 * time.Minute,
+	}
+}
+
+// Decision — результат проверки запроса.
+type Decision int
+
+const (
+	Allowed    Decision = iota
+	Limited             // запрос отклонён, корзина пуста
+	BanStarted          // запрос отклонён, и с этого момента чат заблокирован
+	Banned              // чат уже заблокирован
+)
+
+const (
+	maxBuckets    = 10000            // после этого порога старые корзины вычищаются
+	idleBucketTTL = 10 * time.Minute // корзина без запросов дольше этого считается полной
+	pruneInterval = time.Minute      // как часто вычищаются истёкшие серии отказов и блокировки
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type strikes struct {
+	count int
+	since time.Time
+}
+
+// Limiter хранит корзины токенов и блокировки в памяти процесса.
+type Limiter struct {
+	mu          sync.Mutex
+	cfg         Config
+	buckets     map[string]*bucket // "chatID|class" -> корзина
+	strikes     map[int64]*strikes
+	bannedUntil map[int64]time.Time
+	lastPrune   time.Time
+	now         func() time.Time
+}
+
+var (
+	stats    = expvar.NewMap("ratelimit")
+	configMu sync.RWMutex
+	current  Config
+)
+
+func init() {
+	expvar.Publish("ratelimit_config", expvar.Func(func() interface{} {
+		configMu.RLock()
+		defer configMu.RUnlock()
+		return current
+	}))
+}
+
+// New создаёт лимитер. Настройки последнего созданного лимитера публикуются в метриках.
+func New(cfg Config) *Limiter {
+	configMu.Lock()
+	current = cfg
+	configMu.Unlock()
+	return &Limiter{
+		cfg:         cfg,
+		buckets:     make(map[string]*bucket),
+		strikes:     make(map[int64]*strikes),
+		bannedUntil: make(map[int64]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Allow проверяет, можно ли обработать очередное действие класса class из чата chatID.
+func (l *Limiter) Allow(chatID int64, class string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.lastPrune) >= pruneInterval {
+		l.prune(now)
+	}
+
+	if until, ok := l.bannedUntil[chatID]; ok {
+		if now.Before(until) {
+			stats.Add("banned_"+class, 1)
+			return Banned
+		}
+		delete(l.bannedUntil, chatID)
+	}
+
+	limit, ok := l.cfg.Limits[class]
+	if !ok {
+		limit = l.cfg.Default
+	}
+	key := fmt.Sprintf("%d|%s", chatID, class)
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.prune(now)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		stats.Add("allowed_"+class, 1)
+		return Allowed
+	}
+
+	stats.Add("limited_"+class, 1)
+	if l.cfg.BanAfter <= 0 {
+		return Limited
+	}
+	s, ok := l.strikes[chatID]
+	if !ok || now.Sub(s.since) > l.cfg.BanWindow {
+		s = &strikes{since: now}
+		l.strikes[chatID] = s
+	}
+	s.count++
+	if s.count >= l.cfg.BanAfter {
+		delete(l.strikes, chatID)
+		l.bannedUntil[chatID] = now.Add(l.cfg.BanDuration)
+		stats.Add("bans", 1)
+		return BanStarted
+	}
+	return Limited
+}
+
+// prune удаляет корзины, которые давно не использовались и успели бы наполниться заново,
+// а также серии отказов с истёкшим окном и закончившиеся блокировки.
+func (l *Limiter) prune(now time.Time) {
+	l.lastPrune = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+	for chatID, s := range l.strikes {
+		if now.Sub(s.since) > l.cfg.BanWindow {
+			delete(l.strikes, chatID)
+		}
+	}
+	for chatID, until := range l.bannedUntil {
+		if !now.Before(until) {
+			delete(l.bannedUntil, chatID)
+		}
+	}
+}
+
+// BanRemaining возвращает, сколько ещё продлится блокировка чата.
+func (l *Limiter) BanRemaining(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.bannedUntil[chatID]
+	if !ok {
+		return 0
+	}
+	if d := until.Sub(l.now()); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// ParseLimits разбирает строку вида "schedule=1/5,materials=0.5/3" (rate/burst по классам).
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("ParseLimits: ожидается класс=rate/burst, получено %q", part)
+		}
+		rateStr, burstStr, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("ParseLimits: ожидается rate/burst, получено %q", value)
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ParseLimits: %w", err)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil {
+			return nil, fmt.Errorf("ParseLimits: %w", err)
+		}
+		limits[strings.TrimSpace(name)] = Limit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
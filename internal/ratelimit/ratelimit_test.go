@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(cfg Config) (*Limiter, *time.Time) {
+	l := New(cfg)
+	now := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestAllowRefillsTokens(t *testing.T) {
+	l, now := newTestLimiter(Config{Default: Limit{Rate: 1, Burst: 2}})
+
+	if l.Allow(1, "x") != Allowed || l.Allow(1, "x") != Allowed {
+		t.Fatal("первые Burst запросов должны проходить")
+	}
+	if got := l.Allow(1, "x"); got != Limited {
+		t.Fatalf("третий запрос: got %v, want Limited", got)
+	}
+	if got := l.Allow(2, "x"); got != Allowed {
+		t.Fatalf("другой чат не должен страдать: got %v", got)
+	}
+	*now = now.Add(time.Second)
+	if got := l.Allow(1, "x"); got != Allowed {
+		t.Fatalf("через секунду токен восстанавливается: got %v", got)
+	}
+}
+
+func TestAllowClassesAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		Limits:  map[string]Limit{"materials": {Rate: 0, Burst: 1}},
+		Default: Limit{Rate: 0, Burst: 1},
+	})
+	if l.Allow(1, "materials") != Allowed || l.Allow(1, "schedule") != Allowed {
+		t.Fatal("у каждого класса своя корзина")
+	}
+	if l.Allow(1, "materials") != Limited {
+		t.Fatal("корзина materials исчерпана")
+	}
+}
+
+func TestPersistentAbuserIsBanned(t *testing.T) {
+	l, now := newTestLimiter(Config{
+		Default:     Limit{Rate: 0, Burst: 1},
+		BanAfter:    3,
+		BanWindow:   time.Minute,
+		BanDuration: 5 * time.Minute,
+	})
+	l.Allow(1, "x")
+	l.Allow(1, "x")
+	l.Allow(1, "x")
+	if got := l.Allow(1, "x"); got != BanStarted {
+		t.Fatalf("got %v, want BanStarted", got)
+	}
+	if got := l.Allow(1, "y"); got != Banned {
+		t.Fatalf("блокировка действует на все классы: got %v", got)
+	}
+	if d := l.BanRemaining(1); d != 5*time.Minute {
+		t.Fatalf("BanRemaining = %v", d)
+	}
+	*now = now.Add(6 * time.Minute)
+	if got := l.Allow(1, "y"); got != Allowed {
+		t.Fatalf("после окончания блокировки: got %v", got)
+	}
+}
+
+func TestExpiredStrikesAndBansArePruned(t *testing.T) {
+	l, now := newTestLimiter(Config{
+		Default:     Limit{Rate: 0, Burst: 1},
+		BanAfter:    2,
+		BanWindow:   time.Minute,
+		BanDuration: 5 * time.Minute,
+	})
+	// Чат 1 получает блокировку, чат 2 — одну отклонённую попытку
+	for i := 0; i < 3; i++ {
+		l.Allow(1, "x")
+	}
+	l.Allow(2, "x")
+	l.Allow(2, "x")
+	if len(l.bannedUntil) != 1 || len(l.strikes) != 1 {
+		t.Fatalf("блокировок %d, серий отказов %d", len(l.bannedUntil), len(l.strikes))
+	}
+
+	// Оба чата больше не пишут; запрос третьего чата вычищает истёкшие записи
+	*now = now.Add(6 * time.Minute)
+	l.Allow(3, "x")
+	if len(l.bannedUntil) != 0 || len(l.strikes) != 0 {
+		t.Errorf("истёкшие записи не удалены: блокировок %d, серий отказов %d", len(l.bannedUntil), len(l.strikes))
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("schedule=1/5, materials=0.5/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits["schedule"] != (Limit{Rate: 1, Burst: 5}) || limits["materials"] != (Limit{Rate: 0.5, Burst: 3}) {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+	if _, err := ParseLimits("schedule=1"); err == nil {
+		t.Fatal("ожидалась ошибка формата")
+	}
+}
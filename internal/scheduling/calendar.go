@@ -0,0 +1,183 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// ErrPeriodNotFound возвращается, если периода нет в учебном календаре.
+var ErrPeriodNotFound = errors.New("период не найден")
+
+// AcademicCalendar — семестры, праздники и сессии. Нулевое значение — пустой календарь.
+type AcademicCalendar struct {
+	Periods []models.CalendarPeriod // по дате начала
+}
+
+// find возвращает первый период вида kind, в который попадает день.
+func (c *AcademicCalendar) find(kind models.CalendarPeriodKind, day time.Time) (models.CalendarPeriod, bool) {
+	day = truncateDay(day)
+	for _, p := range c.Periods {
+		if p.Kind == kind && !day.Before(p.Start) && !day.After(p.End) {
+			return p, true
+		}
+	}
+	return models.CalendarPeriod{}, false
+}
+
+// Semester возвращает семестр, в который попадает день.
+func (c *AcademicCalendar) Semester(day time.Time) (models.CalendarPeriod, bool) {
+	return c.find(models.PeriodSemester, day)
+}
+
+// Holiday возвращает праздник или каникулы, в которые попадает день.
+func (c *AcademicCalendar) Holiday(day time.Time) (models.CalendarPeriod, bool) {
+	return c.find(models.PeriodHoliday, day)
+}
+
+// Exams возвращает сессию, в которую попадает день.
+func (c *AcademicCalendar) Exams(day time.Time) (models.CalendarPeriod, bool) {
+	return c.find(models.PeriodExams, day)
+}
+
+// AcademicWeek возвращает номер учебной недели и число недель в семестре.
+// Первая неделя — та, в которую попадает начало семестра (недели с понедельника).
+func (c *AcademicCalendar) AcademicWeek(day time.Time) (week, total int, ok bool) {
+	sem, ok := c.Semester(day)
+	if !ok {
+		return 0, 0, false
+	}
+	first := mondayOf(sem.Start)
+	week = int(mondayOf(day).Sub(first).Hours()/24)/7 + 1
+	total = int(mondayOf(sem.End).Sub(first).Hours()/24)/7 + 1
+	return week, total, true
+}
+
+func mondayOf(t time.Time) time.Time {
+	t = truncateDay(t)
+	return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+var current = struct {
+	sync.RWMutex
+	cal *AcademicCalendar
+}{cal: &AcademicCalendar{}}
+
+// CurrentCalendar возвращает действующий учебный календарь (не nil).
+func CurrentCalendar() *AcademicCalendar {
+	current.RLock()
+	defer current.RUnlock()
+	return current.cal
+}
+
+// SetCalendar подменяет действующий календарь; nil — пустой календарь.
+func SetCalendar(c *AcademicCalendar) {
+	if c == nil {
+		c = &AcademicCalendar{}
+	}
+	current.Lock()
+	defer current.Unlock()
+	current.cal = c
+}
+
+// ReloadCalendar перечитывает учебный календарь из базы.
+func ReloadCalendar() error {
+	c, err := LoadCalendar()
+	if err != nil {
+		return err
+	}
+	SetCalendar(c)
+	return nil
+}
+
+// LoadCalendar читает учебный календарь из базы.
+func LoadCalendar() (*AcademicCalendar, error) {
+	rows, err := db.DB.Query(`SELECT id, kind, name, start_date, end_date FROM academic_calendar ORDER BY start_date, id`)
+	if err != nil {
+		return nil, fmt.Errorf("LoadCalendar: %w", err)
+	}
+	defer rows.Close()
+	c := &AcademicCalendar{}
+	for rows.Next() {
+		var p models.CalendarPeriod
+		var start, end string
+		if err := rows.Scan(&p.ID, &p.Kind, &p.Name, &start, &end); err != nil {
+			return nil, fmt.Errorf("LoadCalendar: %w", err)
+		}
+		if p.Start, err = time.Parse(dateLayout, start); err != nil {
+			return nil, fmt.Errorf("период %d: %w", p.ID, err)
+		}
+		if p.End, err = time.Parse(dateLayout, end); err != nil {
+			return nil, fmt.Errorf("период %d: %w", p.ID, err)
+		}
+		c.Periods = append(c.Periods, p)
+	}
+	return c, rows.Err()
+}
+
+// AddCalendarPeriod проверяет и сохраняет период, после чего календарь перечитывается.
+// Семестры не могут пересекаться: иначе нумерация недель неоднозначна.
+func AddCalendarPeriod(p *models.CalendarPeriod) error {
+	switch p.Kind {
+	case models.PeriodSemester, models.PeriodHoliday, models.PeriodExams:
+	default:
+		return fmt.Errorf("неизвестный вид периода %q", p.Kind)
+	}
+	if p.Name == "" {
+		return errors.New("не указано название")
+	}
+	p.Start, p.End = truncateDay(p.Start), truncateDay(p.End)
+	if p.End.Before(p.Start) {
+		return errors.New("дата окончания раньше даты начала")
+	}
+	if p.End.Sub(p.Start) > 366*24*time.Hour {
+		return errors.New("период длиннее года")
+	}
+	if p.Kind == models.PeriodSemester {
+		c, err := LoadCalendar()
+		if err != nil {
+			return err
+		}
+		for _, other := range c.Periods {
+			if other.Kind == models.PeriodSemester && !p.End.Before(other.Start) && !p.Start.After(other.End) {
+				return fmt.Errorf("пересекается с семестром «%s»", other.Name)
+			}
+		}
+	}
+	res, err := db.DB.Exec(`INSERT INTO academic_calendar (kind, name, start_date, end_date) VALUES (?, ?, ?, ?)`,
+		string(p.Kind), p.Name, p.Start.Format(dateLayout), p.End.Format(dateLayout))
+	if err != nil {
+		return fmt.Errorf("AddCalendarPeriod: %w", err)
+	}
+	if p.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("AddCalendarPeriod: %w", err)
+	}
+	return ReloadCalendar()
+}
+
+// DeleteCalendarPeriod удаляет период по id.
+func DeleteCalendarPeriod(id int64) error {
+	res, err := db.DB.Exec(`DELETE FROM academic_calendar WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteCalendarPeriod: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPeriodNotFound
+	}
+	return ReloadCalendar()
+}
+
+// SemesterAround возвращает первый семестр, пересекающийся с диапазоном [start, end].
+func SemesterAround(start, end time.Time) (models.CalendarPeriod, bool) {
+	start, end = truncateDay(start), truncateDay(end)
+	for _, p := range CurrentCalendar().Periods {
+		if p.Kind == models.PeriodSemester && !end.Before(p.Start) && !start.After(p.End) {
+			return p, true
+		}
+	}
+	return models.CalendarPeriod{}, false
+}
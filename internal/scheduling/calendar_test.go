@@ -0,0 +1,64 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/models"
+)
+
+func TestAcademicWeekAndHolidays(t *testing.T) {
+	openTestDB(t)
+	for _, p := range []models.CalendarPeriod{
+		{Kind: models.PeriodSemester, Name: "Весенний семестр", Start: date("2025-02-05"), End: date("2025-06-15")},
+		{Kind: models.PeriodHoliday, Name: "Праздник", Start: date("2025-03-12"), End: date("2025-03-12")},
+	} {
+		if err := AddCalendarPeriod(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cal := CurrentCalendar()
+	// 05.02.2025 — среда: первая учебная неделя начинается с понедельника 03.02
+	for day, want := range map[string]int{"2025-02-03": 0, "2025-02-05": 1, "2025-02-10": 2, "2025-03-12": 6, "2025-06-15": 19} {
+		week, total, ok := cal.AcademicWeek(date(day))
+		if want == 0 {
+			if ok {
+				t.Errorf("%s вне семестра, получена неделя %d", day, week)
+			}
+			continue
+		}
+		if !ok || week != want || total != 19 {
+			t.Errorf("%s: неделя %d из %d, ожидалась %d из 19", day, week, total, want)
+		}
+	}
+
+	// Числитель — нечётные учебные недели (ISO-номера здесь другие), праздник пропускается
+	rule := models.ScheduleRule{Weekday: time.Wednesday, StartTime: "09:45", Parity: models.ParityOdd,
+		ValidFrom: date("2025-02-01"), ValidTo: date("2025-03-31")}
+	got := formatAll(mustOccurrences(t, rule, date("2025-02-01"), date("2025-03-31")))
+	want := []string{"2025-02-05 09:45", "2025-02-19 09:45", "2025-03-05 09:45", "2025-03-19 09:45"}
+	if !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	rule.Parity = models.ParityEven
+	got = formatAll(mustOccurrences(t, rule, date("2025-03-01"), date("2025-03-31")))
+	want = []string{"2025-03-26 09:45"}
+	if !equal(got, want) {
+		t.Errorf("праздник 12.03 должен пропускаться: got %v, want %v", got, want)
+	}
+
+	if err := AddCalendarPeriod(&models.CalendarPeriod{Kind: models.PeriodSemester, Name: "Летний",
+		Start: date("2025-06-01"), End: date("2025-07-31")}); err == nil {
+		t.Error("ожидалась ошибка пересечения семестров")
+	}
+	if err := DeleteCalendarPeriod(cal.Periods[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := CurrentCalendar().Holiday(date("2025-03-12")); ok {
+		t.Error("удалённый праздник остался в календаре")
+	}
+	if err := DeleteCalendarPeriod(100); err != ErrPeriodNotFound {
+		t.Errorf("ожидалась ErrPeriodNotFound, получено %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package scheduling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"education/internal/models"
+)
+
+// ConflictKind — по какому ресурсу пересекаются занятия.
+type ConflictKind string
+
+const (
+	ConflictTeacher ConflictKind = "teacher"
+	ConflictGroup   ConflictKind = "group"
+	ConflictRoom    ConflictKind = "room"
+)
+
+func (k ConflictKind) label() string {
+	switch k {
+	case ConflictTeacher:
+		return "Преподаватель"
+	case ConflictGroup:
+		return "Группа"
+	case ConflictRoom:
+		return "Аудитория"
+	}
+	return string(k)
+}
+
+// Conflict — существующее занятие, с которым пересекается проверяемое.
+type Conflict struct {
+	Kind ConflictKind
+	With models.Schedule
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s уже занят(а): %s", c.Kind.label(), resourceOf(c.Kind, c.With), describeLesson(c.With))
+}
+
+// ConflictError возвращается при сохранении занятия, пересекающегося с другими.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		lines = append(lines, c.String())
+	}
+	return "пересечение с другими занятиями:\n" + strings.Join(lines, "\n")
+}
+
+func resourceOf(kind ConflictKind, s models.Schedule) string {
+	switch kind {
+	case ConflictTeacher:
+		return s.TeacherRegCode
+	case ConflictGroup:
+		return s.GroupName
+	}
+	return s.Auditory
+}
+
+func describeLesson(s models.Schedule) string {
+	return fmt.Sprintf("%s %s–%s, группа %s, преподаватель %s, ауд. %s",
+		s.ScheduleTime.Format("02.01.2006"), s.ScheduleTime.Format("15:04"), End(s).Format("15:04"),
+		s.Audience(), s.TeacherRegCode, s.Auditory)
+}
+
+// shares сообщает, занят ли в обоих занятиях один и тот же ресурс вида kind.
+// Занятия разных подгрупп одной группы друг другу не мешают.
+func shares(kind ConflictKind, a, b models.Schedule) bool {
+	key := resourceOf(kind, a)
+	if key == "" || key != resourceOf(kind, b) {
+		return false
+	}
+	if kind == ConflictGroup {
+		return a.SubgroupID == 0 || b.SubgroupID == 0 || a.SubgroupID == b.SubgroupID
+	}
+	return true
+}
+
+// overlaps сообщает, пересекаются ли интервалы занятий (касание концами не считается).
+func overlaps(a, b models.Schedule) bool {
+	return a.ScheduleTime.Before(End(b)) && b.ScheduleTime.Before(End(a))
+}
+
+// sameLesson — то же самое занятие (при обновлении оно не конфликтует само с собой).
+func sameLesson(a, b models.Schedule) bool {
+	if a.ID != 0 && a.ID == b.ID {
+		return true
+	}
+	// Замена занятия по правилу не конфликтует с тем занятием, которое она заменяет
+	return a.RuleID != 0 && a.RuleID == b.RuleID && a.RuleDate.Equal(b.RuleDate)
+}
+
+// FindConflicts возвращает занятия, пересекающиеся с s по преподавателю, группе или аудитории.
+func FindConflicts(s models.Schedule) ([]Conflict, error) {
+	// Соседние дни захватываем на случай занятий, переходящих через полночь
+	start := s.ScheduleTime.AddDate(0, 0, -1)
+	end := End(s).AddDate(0, 0, 1)
+	existing, err := lessonsBetween(lessonFilter{Teacher: s.TeacherRegCode, Group: s.GroupName, Room: s.Auditory}, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	for _, e := range existing {
+		if sameLesson(s, e) || !overlaps(s, e) {
+			continue
+		}
+		for _, kind := range []ConflictKind{ConflictTeacher, ConflictGroup, ConflictRoom} {
+			if shares(kind, s, e) {
+				conflicts = append(conflicts, Conflict{Kind: kind, With: e})
+			}
+		}
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].With.ScheduleTime.Before(conflicts[j].With.ScheduleTime)
+	})
+	return conflicts, nil
+}
+
+// FindRuleConflicts разворачивает правило за период его действия и возвращает пересечения
+// каждого его занятия с уже сохранёнными.
+func FindRuleConflicts(rule models.ScheduleRule) ([]Conflict, error) {
+	times, err := Occurrences(rule, rule.ValidFrom, rule.ValidTo)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []Conflict
+	for _, t := range times {
+		found, err := FindConflicts(RuleLesson(rule, t))
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	return conflicts, nil
+}
+
+func checkConflicts(s models.Schedule) error {
+	conflicts, err := FindConflicts(s)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// Overlap — пара уже сохранённых занятий, пересекающихся по одному ресурсу.
+type Overlap struct {
+	Kind ConflictKind
+	A, B models.Schedule
+}
+
+func (o Overlap) String() string {
+	return fmt.Sprintf("%s %s:\n  • %s\n  • %s", o.Kind.label(), resourceOf(o.Kind, o.A), describeLesson(o.A), describeLesson(o.B))
+}
+
+// FindOverlaps просматривает расписание за диапазон дат и возвращает все пересечения.
+func FindOverlaps(start, end time.Time) ([]Overlap, error) {
+	lessons, err := lessonsBetween(lessonFilter{}, start, end)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(lessons, func(i, j int) bool {
+		return lessons[i].ScheduleTime.Before(lessons[j].ScheduleTime)
+	})
+
+	var result []Overlap
+	for _, kind := range []ConflictKind{ConflictTeacher, ConflictGroup, ConflictRoom} {
+		byResource := make(map[string][]models.Schedule)
+		var keys []string
+		for _, l := range lessons {
+			key := resourceOf(kind, l)
+			if key == "" {
+				continue
+			}
+			if _, ok := byResource[key]; !ok {
+				keys = append(keys, key)
+			}
+			byResource[key] = append(byResource[key], l)
+		}
+		for _, key := range keys {
+			list := byResource[key]
+			// Список отсортирован по началу: сравниваем только с занятиями, начавшимися до конца текущего
+			for i := range list {
+				for j := i + 1; j < len(list) && list[j].ScheduleTime.Before(End(list[i])); j++ {
+					if shares(kind, list[i], list[j]) {
+						result = append(result, Overlap{Kind: kind, A: list[i], B: list[j]})
+					}
+				}
+			}
+		}
+	}
+	return result, nil
+}
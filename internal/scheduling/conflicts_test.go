@@ -0,0 +1,144 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// openTestDB открывает отдельную in-memory БД на тест.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	db.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_")))
+	t.Cleanup(func() { db.DB.Close() })
+	if _, err := db.DB.Exec(`INSERT INTO courses (id, name) VALUES (1, 'Матем'), (2, 'Прог')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReloadCalendar(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lesson(group, teacher, room, at string, minutes int) *models.Schedule {
+	ts, err := time.Parse("2006-01-02 15:04", at)
+	if err != nil {
+		panic(err)
+	}
+	return &models.Schedule{CourseID: 1, GroupName: group, TeacherRegCode: teacher, Auditory: room,
+		ScheduleTime: ts, Duration: minutes, LessonType: "Лекция"}
+}
+
+func TestCreateLessonRejectsConflicts(t *testing.T) {
+	openTestDB(t)
+	if err := CreateLesson(lesson("АА-23-01", "TH-0001", "101", "2025-03-17 08:00", 90), false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Другая группа, тот же преподаватель и аудитория, начало до конца первого занятия
+	clash := lesson("ББ-23-01", "TH-0001", "101", "2025-03-17 09:00", 90)
+	err := CreateLesson(clash, false, "")
+	var ce *ConflictError
+	if !errors.As(err, &ce) {
+		t.Fatalf("ожидалась ConflictError, получено %v", err)
+	}
+	kinds := map[ConflictKind]bool{}
+	for _, c := range ce.Conflicts {
+		kinds[c.Kind] = true
+	}
+	if !kinds[ConflictTeacher] || !kinds[ConflictRoom] || kinds[ConflictGroup] {
+		t.Errorf("неверные виды конфликтов: %v", ce.Conflicts)
+	}
+
+	// Занятие, начинающееся ровно в момент окончания, не конфликтует
+	if err := CreateLesson(lesson("АА-23-01", "TH-0001", "101", "2025-03-17 09:30", 90), false, ""); err != nil {
+		t.Errorf("стык занятий не должен считаться конфликтом: %v", err)
+	}
+
+	// Явное разрешение сохраняет занятие несмотря на конфликт
+	if err := CreateLesson(clash, true, ""); err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	overlaps, err := FindOverlaps(time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Сохранённый конфликт пересекается с обоими занятиями по преподавателю и аудитории
+	if len(overlaps) != 4 {
+		t.Errorf("ожидалось 4 пересечения, получено %d: %v", len(overlaps), overlaps)
+	}
+}
+
+func TestUpdateLessonIgnoresItselfAndChecksRules(t *testing.T) {
+	openTestDB(t)
+	rule := &models.ScheduleRule{
+		CourseID: 2, GroupName: "АА-23-01", TeacherRegCode: "TH-0002", Weekday: time.Tuesday,
+		StartTime: "11:45", Duration: 90, Auditory: "202",
+		ValidFrom: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), ValidTo: time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if err := CreateRule(rule, false); err != nil {
+		t.Fatal(err)
+	}
+	l := lesson("АА-23-01", "TH-0001", "101", "2025-03-18 08:00", 90)
+	if err := CreateLesson(l, false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Duration = 100
+	if err := UpdateLesson(*l, false, ""); err != nil {
+		t.Errorf("занятие не должно конфликтовать само с собой: %v", err)
+	}
+
+	// Перенос на время занятия по правилу — конфликт по группе
+	l.ScheduleTime = time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)
+	var ce *ConflictError
+	if err := UpdateLesson(*l, false, ""); !errors.As(err, &ce) || ce.Conflicts[0].With.RuleID != rule.ID {
+		t.Fatalf("ожидался конфликт с правилом, получено %v", err)
+	}
+
+	// В исключённый день правила занятия нет
+	if err := AddRuleException(rule.ID, time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateLesson(*l, false, ""); err != nil {
+		t.Errorf("исключённый день правила не должен давать конфликт: %v", err)
+	}
+}
+
+func TestCreateRuleRejectsConflicts(t *testing.T) {
+	openTestDB(t)
+	// Разовое занятие в ауд. 301 в четверг 27.03
+	if err := CreateLesson(lesson("ББ-24-02", "TH-0001", "301", "2025-03-27 12:00", 90), false, ""); err != nil {
+		t.Fatal(err)
+	}
+	rule := func(group, teacher string) *models.ScheduleRule {
+		return &models.ScheduleRule{
+			CourseID: 2, GroupName: group, TeacherRegCode: teacher, Weekday: time.Thursday,
+			StartTime: "11:45", Duration: 90, Auditory: "301",
+			ValidFrom: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), ValidTo: time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	var ce *ConflictError
+	first := rule("АА-23-01", "TH-0002")
+	if err := CreateRule(first, false); !errors.As(err, &ce) || len(ce.Conflicts) != 1 ||
+		ce.Conflicts[0].Kind != ConflictRoom || !ce.Conflicts[0].With.ScheduleTime.Equal(time.Date(2025, 3, 27, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("ожидался конфликт по аудитории 27.03, получено %v", err)
+	}
+	if first.ID != 0 {
+		t.Error("правило с пересечением не должно сохраняться")
+	}
+	if err := CreateRule(first, true); err != nil {
+		t.Fatalf("с force правило сохраняется: %v", err)
+	}
+
+	// Второе правило в ту же аудиторию пересекается с первым каждую неделю
+	second := rule("ВВ-22-03", "TH-0003")
+	if err := CreateRule(second, false); !errors.As(err, &ce) || len(ce.Conflicts) < 8 {
+		t.Fatalf("ожидались еженедельные пересечения с первым правилом, получено %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package scheduling
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+var (
+	// ErrConsultationNotFound возвращается, если консультации нет (или она чужая).
+	ErrConsultationNotFound = errors.New("консультация не найдена")
+	// ErrConsultationFull возвращается при записи, когда все места заняты.
+	ErrConsultationFull = errors.New("свободных мест нет")
+	// ErrAlreadyBooked возвращается при повторной записи студента.
+	ErrAlreadyBooked = errors.New("вы уже записаны на эту консультацию")
+	// ErrNotBooked возвращается при отмене записи, которой нет.
+	ErrNotBooked = errors.New("вы не записаны на эту консультацию")
+	// ErrBookingClosed возвращается, если запись или отмена записи уже закрыта.
+	ErrBookingClosed = errors.New("запись на консультацию закрыта")
+)
+
+// BookingCutoff — за сколько до начала консультации закрывается запись на неё.
+const BookingCutoff = time.Hour
+
+// Ограничения консультации
+const (
+	maxConsultationCapacity = 100
+	maxConsultationDuration = 4 * 60 // минут
+	maxConsultationPlaceLen = 200    // символов
+)
+
+const consultationSelect = `
+	SELECT c.id, c.teacher_reg_code, COALESCE(NULLIF(p.display_name, ''), u.name, c.teacher_reg_code),
+		c.starts_at, c.duration, c.place, c.capacity,
+		(SELECT COUNT(*) FROM consultation_bookings b WHERE b.consultation_id = c.id)
+	FROM consultations c
+	LEFT JOIN users u ON u.registration_code = c.teacher_reg_code
+	LEFT JOIN teacher_profiles p ON p.registration_code = c.teacher_reg_code
+`
+
+func scanConsultation(row scanner) (models.Consultation, error) {
+	var c models.Consultation
+	var startsAt string
+	if err := row.Scan(&c.ID, &c.TeacherRegCode, &c.TeacherName, &startsAt,
+		&c.Duration, &c.Place, &c.Capacity, &c.Booked); err != nil {
+		return c, err
+	}
+	t, err := time.Parse(time.RFC3339, startsAt)
+	if err != nil {
+		return c, fmt.Errorf("консультация %d: %w", c.ID, err)
+	}
+	c.StartsAt = t.UTC()
+	return c, nil
+}
+
+func queryConsultations(where string, args ...interface{}) ([]models.Consultation, error) {
+	rows, err := db.DB.Query(consultationSelect+where+` ORDER BY c.starts_at, c.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []models.Consultation
+	for rows.Next() {
+		c, err := scanConsultation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func rfc3339(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// AddConsultation проверяет и сохраняет консультацию. Консультацию в прошлом создать нельзя.
+func AddConsultation(c *models.Consultation, now time.Time) error {
+	c.Place = strings.TrimSpace(c.Place)
+	switch {
+	case c.TeacherRegCode == "":
+		return errors.New("не указан преподаватель")
+	case !c.StartsAt.After(now):
+		return errors.New("консультация должна быть в будущем")
+	case c.Duration <= 0 || c.Duration > maxConsultationDuration:
+		return fmt.Errorf("продолжительность должна быть от 1 до %d минут", maxConsultationDuration)
+	case c.Capacity <= 0 || c.Capacity > maxConsultationCapacity:
+		return fmt.Errorf("число мест должно быть от 1 до %d", maxConsultationCapacity)
+	case c.Place == "":
+		return errors.New("не указано место: аудитория или ссылка")
+	case utf8.RuneCountInString(c.Place) > maxConsultationPlaceLen:
+		return fmt.Errorf("место длиннее %d символов", maxConsultationPlaceLen)
+	}
+	res, err := db.DB.Exec(`
+		INSERT INTO consultations (teacher_reg_code, starts_at, duration, place, capacity) VALUES (?, ?, ?, ?, ?)`,
+		c.TeacherRegCode, rfc3339(c.StartsAt), c.Duration, c.Place, c.Capacity)
+	if err != nil {
+		return fmt.Errorf("AddConsultation: %w", err)
+	}
+	c.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetConsultation возвращает консультацию по id с числом записавшихся.
+func GetConsultation(id int64) (models.Consultation, error) {
+	c, err := scanConsultation(db.DB.QueryRow(consultationSelect+` WHERE c.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrConsultationNotFound
+	}
+	return c, err
+}
+
+// TeacherConsultations возвращает консультации преподавателя, начинающиеся в [from, to).
+func TeacherConsultations(teacher string, from, to time.Time) ([]models.Consultation, error) {
+	list, err := queryConsultations(`WHERE c.teacher_reg_code = ? AND c.starts_at >= ? AND c.starts_at < ?`,
+		teacher, rfc3339(from), rfc3339(to))
+	if err != nil {
+		return nil, fmt.Errorf("TeacherConsultations: %w", err)
+	}
+	return list, nil
+}
+
+// UpcomingConsultations возвращает консультации всех преподавателей, начинающиеся в [from, to).
+func UpcomingConsultations(from, to time.Time) ([]models.Consultation, error) {
+	list, err := queryConsultations(`WHERE c.starts_at >= ? AND c.starts_at < ?`, rfc3339(from), rfc3339(to))
+	if err != nil {
+		return nil, fmt.Errorf("UpcomingConsultations: %w", err)
+	}
+	return list, nil
+}
+
+// StudentConsultations возвращает консультации в [from, to), на которые записан студент.
+func StudentConsultations(student string, from, to time.Time) ([]models.Consultation, error) {
+	list, err := queryConsultations(`
+		JOIN consultation_bookings sb ON sb.consultation_id = c.id AND sb.student_reg_code = ?
+		WHERE c.starts_at >= ? AND c.starts_at < ?`, student, rfc3339(from), rfc3339(to))
+	if err != nil {
+		return nil, fmt.Errorf("StudentConsultations: %w", err)
+	}
+	return list, nil
+}
+
+// ConsultationBookings возвращает записавшихся на консультацию в порядке записи.
+func ConsultationBookings(id int64) ([]models.ConsultationBooking, error) {
+	rows, err := db.DB.Query(`
+		SELECT b.student_reg_code, COALESCE(u.name, b.student_reg_code), COALESCE(u.group_name, ''),
+			COALESCE(u.telegram_id, 0), b.booked_at
+		FROM consultation_bookings b
+		LEFT JOIN users u ON u.registration_code = b.student_reg_code
+		WHERE b.consultation_id = ?
+		ORDER BY b.booked_at, b.student_reg_code`, id)
+	if err != nil {
+		return nil, fmt.Errorf("ConsultationBookings: %w", err)
+	}
+	defer rows.Close()
+	var list []models.ConsultationBooking
+	for rows.Next() {
+		var b models.ConsultationBooking
+		var bookedAt string
+		if err := rows.Scan(&b.StudentRegCode, &b.StudentName, &b.GroupName, &b.TelegramID, &bookedAt); err != nil {
+			return nil, fmt.Errorf("ConsultationBookings: %w", err)
+		}
+		b.BookedAt, _ = time.Parse(time.RFC3339, bookedAt)
+		list = append(list, b)
+	}
+	return list, rows.Err()
+}
+
+// IsBooked сообщает, записан ли студент на консультацию.
+func IsBooked(id int64, student string) (bool, error) {
+	var n int
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM consultation_bookings WHERE consultation_id = ? AND student_reg_code = ?`,
+		id, student).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("IsBooked: %w", err)
+	}
+	return n > 0, nil
+}
+
+// BookConsultation записывает студента на консультацию. Запись закрывается за BookingCutoff
+// до начала; студент записывается на консультацию не больше одного раза.
+func BookConsultation(id int64, student string, now time.Time) error {
+	c, err := GetConsultation(id)
+	if err != nil {
+		return err
+	}
+	if now.After(c.StartsAt.Add(-BookingCutoff)) {
+		return ErrBookingClosed
+	}
+	booked, err := IsBooked(id, student)
+	if err != nil {
+		return err
+	}
+	if booked {
+		return ErrAlreadyBooked
+	}
+	// Проверка мест и вставка одним запросом, чтобы одновременные записи не превысили вместимость
+	res, err := db.DB.Exec(`
+		INSERT INTO consultation_bookings (consultation_id, student_reg_code, booked_at)
+		SELECT ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM consultation_bookings WHERE consultation_id = ?) <
+			(SELECT capacity FROM consultations WHERE id = ?)`,
+		id, student, rfc3339(now), id, id)
+	if err != nil {
+		return fmt.Errorf("BookConsultation: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrConsultationFull
+	}
+	return nil
+}
+
+// CancelBooking отменяет запись студента. Отменить запись можно до начала консультации.
+func CancelBooking(id int64, student string, now time.Time) error {
+	c, err := GetConsultation(id)
+	if err != nil {
+		return err
+	}
+	if !now.Before(c.StartsAt) {
+		return ErrBookingClosed
+	}
+	res, err := db.DB.Exec(`DELETE FROM consultation_bookings WHERE consultation_id = ? AND student_reg_code = ?`, id, student)
+	if err != nil {
+		return fmt.Errorf("CancelBooking: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotBooked
+	}
+	return nil
+}
+
+// DeleteConsultation удаляет консультацию преподавателя вместе с записями и возвращает
+// записавшихся, чтобы их предупредить.
+func DeleteConsultation(id int64, teacher string) ([]models.ConsultationBooking, error) {
+	c, err := GetConsultation(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.TeacherRegCode != teacher {
+		return nil, ErrConsultationNotFound
+	}
+	bookings, err := ConsultationBookings(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("DeleteConsultation: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM consultation_bookings WHERE consultation_id = ?`, id); err != nil {
+		return nil, fmt.Errorf("DeleteConsultation: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM consultations WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("DeleteConsultation: %w", err)
+	}
+	return bookings, tx.Commit()
+}
@@ -0,0 +1,72 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"education/internal/models"
+)
+
+func TestConsultationBookingRules(t *testing.T) {
+	openTestDB(t)
+	now := time.Date(2025, 3, 17, 10, 0, 0, 0, time.UTC)
+	c := &models.Consultation{TeacherRegCode: "TH-0001", StartsAt: time.Date(2025, 3, 18, 15, 0, 0, 0, time.UTC),
+		Duration: 60, Place: "ауд. 305", Capacity: 2}
+	if err := AddConsultation(c, now); err != nil {
+		t.Fatal(err)
+	}
+	past := *c
+	past.StartsAt = now.Add(-time.Hour)
+	if err := AddConsultation(&past, now); err == nil {
+		t.Error("консультация в прошлом должна отклоняться")
+	}
+
+	if err := BookConsultation(c.ID, "ST-0001", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := BookConsultation(c.ID, "ST-0001", now); !errors.Is(err, ErrAlreadyBooked) {
+		t.Errorf("повторная запись: %v", err)
+	}
+	if err := BookConsultation(c.ID, "ST-0002", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := BookConsultation(c.ID, "ST-0003", now); !errors.Is(err, ErrConsultationFull) {
+		t.Errorf("запись сверх мест: %v", err)
+	}
+
+	// Освободившееся место можно занять, но не позже чем за час до начала
+	if err := CancelBooking(c.ID, "ST-0002", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := CancelBooking(c.ID, "ST-0002", now); !errors.Is(err, ErrNotBooked) {
+		t.Errorf("повторная отмена: %v", err)
+	}
+	if err := BookConsultation(c.ID, "ST-0003", c.StartsAt.Add(-30*time.Minute)); !errors.Is(err, ErrBookingClosed) {
+		t.Errorf("запись после закрытия: %v", err)
+	}
+	if err := BookConsultation(c.ID, "ST-0003", c.StartsAt.Add(-BookingCutoff)); err != nil {
+		t.Errorf("запись ровно к закрытию: %v", err)
+	}
+
+	got, err := GetConsultation(c.ID)
+	if err != nil || got.Booked != 2 || got.FreePlaces() != 0 {
+		t.Errorf("GetConsultation: %+v %v", got, err)
+	}
+	mine, err := StudentConsultations("ST-0001", now, now.AddDate(0, 0, 7))
+	if err != nil || len(mine) != 1 || mine[0].ID != c.ID {
+		t.Errorf("StudentConsultations: %+v %v", mine, err)
+	}
+
+	// Удалить консультацию может только её преподаватель; записавшиеся возвращаются для уведомления
+	if _, err := DeleteConsultation(c.ID, "TH-0002"); !errors.Is(err, ErrConsultationNotFound) {
+		t.Errorf("удаление чужой консультации: %v", err)
+	}
+	bookings, err := DeleteConsultation(c.ID, "TH-0001")
+	if err != nil || len(bookings) != 2 || bookings[0].StudentRegCode != "ST-0001" {
+		t.Errorf("DeleteConsultation: %+v %v", bookings, err)
+	}
+	if ok, _ := IsBooked(c.ID, "ST-0001"); ok {
+		t.Error("записи удалённой консультации должны удаляться")
+	}
+}
@@ -0,0 +1,57 @@
+package scheduling
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"education/internal/models"
+)
+
+// ChangedByAdmin — автор изменений, внесённых из административного чата.
+const ChangedByAdmin = "admin"
+
+// Change описывает изменение занятия: Before == nil — занятие добавлено,
+// After == nil — удалено или отменено.
+type Change struct {
+	Before *models.Schedule
+	After  *models.Schedule
+	By     string    // кто внёс изменение: регистрационный код преподавателя или ChangedByAdmin
+	At     time.Time // когда (UTC)
+}
+
+var (
+	observersMu sync.RWMutex
+	observers   = make(map[int]func(Change))
+	nextObserID int
+)
+
+// OnChange подписывает fn на изменения занятий. Возвращает функцию отписки.
+// Подписчики вызываются синхронно после успешной записи в БД.
+func OnChange(fn func(Change)) (unsubscribe func()) {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+	id := nextObserID
+	nextObserID++
+	observers[id] = fn
+	return func() {
+		observersMu.Lock()
+		defer observersMu.Unlock()
+		delete(observers, id)
+	}
+}
+
+// emit сохраняет изменение в истории расписания и оповещает подписчиков.
+func emit(c Change) {
+	if c.At.IsZero() {
+		c.At = time.Now().UTC()
+	}
+	if err := recordRevision(c); err != nil {
+		log.Printf("Ошибка записи истории расписания: %v", err)
+	}
+	observersMu.RLock()
+	defer observersMu.RUnlock()
+	for _, fn := range observers {
+		fn(c)
+	}
+}
@@ -0,0 +1,100 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"education/internal/models"
+)
+
+// WorkingHours — рабочее время учебного дня, минуты от полуночи.
+type WorkingHours struct {
+	Start, End int
+}
+
+// DefaultWorkingHours — рабочее время по умолчанию: 08:00–20:00.
+var DefaultWorkingHours = WorkingHours{Start: 8 * 60, End: 20 * 60}
+
+func (h WorkingHours) String() string {
+	return fmt.Sprintf("%02d:%02d–%02d:%02d", h.Start/60, h.Start%60, h.End/60, h.End%60)
+}
+
+// ParseWorkingHours разбирает рабочее время вида "08:00-20:00".
+func ParseWorkingHours(s string) (WorkingHours, error) {
+	parts := strings.Split(strings.ReplaceAll(s, "–", "-"), "-")
+	if len(parts) != 2 {
+		return WorkingHours{}, errors.New("рабочее время задаётся как ЧЧ:ММ-ЧЧ:ММ")
+	}
+	var h WorkingHours
+	for i, p := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(p))
+		if err != nil {
+			return WorkingHours{}, fmt.Errorf("некорректное время «%s»", strings.TrimSpace(p))
+		}
+		m := t.Hour()*60 + t.Minute()
+		if i == 0 {
+			h.Start = m
+		} else {
+			h.End = m
+		}
+	}
+	if h.End <= h.Start {
+		return WorkingHours{}, errors.New("конец рабочего дня должен быть позже начала")
+	}
+	return h, nil
+}
+
+// Slot — свободный промежуток [Start, End).
+type Slot struct {
+	Start, End time.Time
+}
+
+// Duration — длина промежутка.
+func (s Slot) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// FreeSlots возвращает свободные от занятий busy промежутки рабочего времени в дни
+// [from, to] длиной не меньше minLen. Воскресенья, праздники и каникулы пропускаются.
+func FreeSlots(busy []models.Schedule, from, to time.Time, hours WorkingHours, minLen time.Duration) []Slot {
+	lessons := append([]models.Schedule(nil), busy...)
+	sort.Slice(lessons, func(i, j int) bool { return lessons[i].ScheduleTime.Before(lessons[j].ScheduleTime) })
+
+	cal := CurrentCalendar()
+	var slots []Slot
+	add := func(start, end time.Time) {
+		if end.Sub(start) >= minLen && end.After(start) {
+			slots = append(slots, Slot{Start: start, End: end})
+		}
+	}
+	for day := truncateDay(from); !day.After(truncateDay(to)); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Sunday {
+			continue
+		}
+		if _, ok := cal.Holiday(day); ok {
+			continue
+		}
+		dayStart := day.Add(time.Duration(hours.Start) * time.Minute)
+		dayEnd := day.Add(time.Duration(hours.End) * time.Minute)
+		cursor := dayStart
+		for _, l := range lessons {
+			start, end := l.ScheduleTime, End(l)
+			if !end.After(dayStart) || !start.Before(dayEnd) {
+				continue
+			}
+			if start.After(cursor) {
+				add(cursor, start)
+			}
+			if end.After(cursor) {
+				cursor = end
+			}
+		}
+		if cursor.Before(dayEnd) {
+			add(cursor, dayEnd)
+		}
+	}
+	return slots
+}
@@ -0,0 +1,53 @@
+package scheduling
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"education/internal/models"
+)
+
+func TestFreeSlots(t *testing.T) {
+	SetCalendar(&AcademicCalendar{Periods: []models.CalendarPeriod{
+		{Kind: models.PeriodHoliday, Name: "Праздник", Start: date("2025-03-19"), End: date("2025-03-19")},
+	}})
+	t.Cleanup(func() { SetCalendar(nil) })
+
+	busy := []models.Schedule{
+		// Занятия преподавателя и группы пересекаются и идут не по порядку
+		*lesson("АА-23-01", "TH-0001", "101", "2025-03-17 09:45", 90),
+		*lesson("АА-23-01", "TH-0002", "102", "2025-03-17 08:00", 90),
+		*lesson("ББ-23-01", "TH-0001", "103", "2025-03-17 10:00", 60),
+		*lesson("АА-23-01", "TH-0001", "101", "2025-03-17 17:00", 60),
+		// Занятие в праздник не мешает: день пропускается целиком
+		*lesson("АА-23-01", "TH-0001", "101", "2025-03-19 08:00", 90),
+	}
+	hours := WorkingHours{Start: 8 * 60, End: 18 * 60}
+	var got []string
+	for _, s := range FreeSlots(busy, date("2025-03-17"), date("2025-03-23"), hours, 90*time.Minute) {
+		got = append(got, s.Start.Format("02.01 15:04")+"–"+s.End.Format("15:04"))
+	}
+	want := []string{
+		"17.03 11:15–17:00", // 09:30–09:45 короче 90 минут, 18:00 — конец рабочего дня
+		"18.03 08:00–18:00",
+		"20.03 08:00–18:00",
+		"21.03 08:00–18:00",
+		"22.03 08:00–18:00", // суббота — рабочий день, воскресенье нет
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FreeSlots:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestParseWorkingHours(t *testing.T) {
+	h, err := ParseWorkingHours("08:30-19:00")
+	if err != nil || h != (WorkingHours{Start: 510, End: 1140}) || h.String() != "08:30–19:00" {
+		t.Errorf("ParseWorkingHours: %+v %v", h, err)
+	}
+	for _, bad := range []string{"", "08:00", "20:00-08:00", "8-20"} {
+		if _, err := ParseWorkingHours(bad); err == nil {
+			t.Errorf("%q должно отклоняться", bad)
+		}
+	}
+}
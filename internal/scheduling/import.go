@@ -0,0 +1,261 @@
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+	"education/internal/timetable"
+)
+
+// ErrImportNotReady возвращается, если план импорта нельзя применить: в файле есть ошибки
+// или пересечения, которые не разрешены явно.
+var ErrImportNotReady = errors.New("импорт невозможен: исправьте ошибки в файле")
+
+// ImportLesson — занятие из строки файла, готовое к добавлению.
+type ImportLesson struct {
+	Line   int
+	Lesson models.Schedule
+}
+
+// ImportConflict — пересечение занятия из файла с уже существующим занятием
+// или с другой строкой того же файла (тогда WithLine != 0).
+type ImportConflict struct {
+	Line     int
+	Kind     ConflictKind
+	With     models.Schedule
+	WithLine int
+}
+
+func (c ImportConflict) String() string {
+	if c.WithLine != 0 {
+		return fmt.Sprintf("строка %d: %s %s занят(а) в строке %d (%s)", c.Line, c.Kind.label(),
+			resourceOf(c.Kind, c.With), c.WithLine, describeLesson(c.With))
+	}
+	return fmt.Sprintf("строка %d: %s %s уже занят(а): %s", c.Line, c.Kind.label(),
+		resourceOf(c.Kind, c.With), describeLesson(c.With))
+}
+
+// ImportPlan — результат пробного импорта: что будет добавлено и что этому мешает.
+type ImportPlan struct {
+	Lessons   []ImportLesson
+	Errors    []timetable.RowError
+	Conflicts []ImportConflict
+}
+
+// Ready сообщает, можно ли применить план (с force — несмотря на пересечения).
+func (p *ImportPlan) Ready(force bool) bool {
+	return len(p.Lessons) > 0 && len(p.Errors) == 0 && (force || len(p.Conflicts) == 0)
+}
+
+// importRefs — справочники, по которым проверяются строки файла.
+type importRefs struct {
+	groups    map[string]bool
+	courses   map[string]int64 // по названию в нижнем регистре
+	courseID  map[int64]bool
+	teachers  map[string]bool
+	rooms     map[string]bool  // пусто — справочник аудиторий не заполнен
+	subgroups map[string]int64 // ключ — "группа|подгруппа"
+}
+
+func loadImportRefs() (*importRefs, error) {
+	refs := &importRefs{
+		groups:    make(map[string]bool),
+		courses:   make(map[string]int64),
+		courseID:  make(map[int64]bool),
+		teachers:  make(map[string]bool),
+		rooms:     make(map[string]bool),
+		subgroups: make(map[string]int64),
+	}
+	load := func(query string, add func(id int64, name string)) error {
+		rows, err := db.DB.Query(query)
+		if err != nil {
+			return fmt.Errorf("loadImportRefs: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int64
+			var name string
+			if err := rows.Scan(&id, &name); err != nil {
+				return fmt.Errorf("loadImportRefs: %w", err)
+			}
+			add(id, name)
+		}
+		return rows.Err()
+	}
+	if err := load(`SELECT id, COALESCE(group_name, '') FROM faculty_groups`, func(_ int64, name string) {
+		refs.groups[name] = true
+	}); err != nil {
+		return nil, err
+	}
+	if err := load(`SELECT id, name FROM courses`, func(id int64, name string) {
+		refs.courses[strings.ToLower(strings.TrimSpace(name))] = id
+		refs.courseID[id] = true
+	}); err != nil {
+		return nil, err
+	}
+	if err := load(`SELECT id, COALESCE(registration_code, '') FROM users WHERE role = 'teacher'`, func(_ int64, code string) {
+		refs.teachers[code] = true
+	}); err != nil {
+		return nil, err
+	}
+	if err := load(`SELECT id, name FROM rooms`, func(_ int64, name string) {
+		refs.rooms[name] = true
+	}); err != nil {
+		return nil, err
+	}
+	if err := load(`SELECT id, group_name || '|' || name FROM subgroups`, func(id int64, key string) {
+		refs.subgroups[key] = id
+	}); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// courseFor находит курс по названию (без учёта регистра) или по id.
+func (r *importRefs) courseFor(v string) (int64, bool) {
+	if id, ok := r.courses[strings.ToLower(v)]; ok {
+		return id, true
+	}
+	if id, err := strconv.ParseInt(v, 10, 64); err == nil && r.courseID[id] {
+		return id, true
+	}
+	return 0, false
+}
+
+// PlanImport проверяет разобранные строки по справочникам и ищет пересечения — как с
+// расписанием в базе, так и между строками файла. Ничего не записывает.
+func PlanImport(records []timetable.Record) (*ImportPlan, error) {
+	refs, err := loadImportRefs()
+	if err != nil {
+		return nil, err
+	}
+	plan := &ImportPlan{}
+	for _, rec := range records {
+		courseID, ok := refs.courseFor(rec.Course)
+		subgroupID, subgroupOK := refs.subgroups[rec.Group+"|"+rec.Subgroup]
+		var problem string
+		switch {
+		case !refs.groups[rec.Group]:
+			problem = fmt.Sprintf("группа «%s» не найдена", rec.Group)
+		case rec.Subgroup != "" && !subgroupOK:
+			problem = fmt.Sprintf("у группы %s нет подгруппы «%s»", rec.Group, rec.Subgroup)
+		case !ok:
+			problem = fmt.Sprintf("курс «%s» не найден", rec.Course)
+		case !refs.teachers[rec.Teacher]:
+			problem = fmt.Sprintf("преподаватель с кодом «%s» не найден", rec.Teacher)
+		case rec.Room != "" && len(refs.rooms) > 0 && !refs.rooms[rec.Room]:
+			problem = fmt.Sprintf("аудитория «%s» не найдена в справочнике", rec.Room)
+		}
+		if problem != "" {
+			plan.Errors = append(plan.Errors, timetable.RowError{Line: rec.Line, Message: problem})
+			continue
+		}
+		lesson := models.Schedule{
+			CourseID:       courseID,
+			GroupName:      rec.Group,
+			TeacherRegCode: rec.Teacher,
+			ScheduleTime:   rec.Start,
+			Duration:       rec.Duration,
+			Auditory:       rec.Room,
+			LessonType:     rec.LessonType,
+			Description:    rec.Description,
+			SubgroupID:     subgroupID,
+			SubgroupName:   rec.Subgroup,
+		}
+		if err := validateLesson(lesson); err != nil {
+			plan.Errors = append(plan.Errors, timetable.RowError{Line: rec.Line, Message: err.Error()})
+			continue
+		}
+		plan.Lessons = append(plan.Lessons, ImportLesson{Line: rec.Line, Lesson: lesson})
+	}
+
+	for _, l := range plan.Lessons {
+		conflicts, err := FindConflicts(l.Lesson)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conflicts {
+			plan.Conflicts = append(plan.Conflicts, ImportConflict{Line: l.Line, Kind: c.Kind, With: c.With})
+		}
+	}
+	plan.Conflicts = append(plan.Conflicts, fileOverlaps(plan.Lessons)...)
+	sort.SliceStable(plan.Conflicts, func(i, j int) bool { return plan.Conflicts[i].Line < plan.Conflicts[j].Line })
+	return plan, nil
+}
+
+// fileOverlaps ищет пересечения между строками одного файла.
+func fileOverlaps(lessons []ImportLesson) []ImportConflict {
+	sorted := append([]ImportLesson(nil), lessons...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Lesson.ScheduleTime.Before(sorted[j].Lesson.ScheduleTime)
+	})
+	var result []ImportConflict
+	for i := range sorted {
+		// Список отсортирован по началу: сравниваем только с занятиями, начавшимися до конца текущего
+		for j := i + 1; j < len(sorted) && sorted[j].Lesson.ScheduleTime.Before(End(sorted[i].Lesson)); j++ {
+			a, b := sorted[i], sorted[j]
+			for _, kind := range []ConflictKind{ConflictTeacher, ConflictGroup, ConflictRoom} {
+				if shares(kind, a.Lesson, b.Lesson) {
+					result = append(result, ImportConflict{Line: b.Line, Kind: kind, With: a.Lesson, WithLine: a.Line})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// CommitImport добавляет занятия плана одной транзакцией: либо все, либо ни одного.
+// Без force пересечения с базой проверяются заново — расписание могло измениться после проверки файла.
+func CommitImport(plan *ImportPlan, force bool, by string) error {
+	if !plan.Ready(force) {
+		return ErrImportNotReady
+	}
+	if !force {
+		for _, l := range plan.Lessons {
+			if err := checkConflicts(l.Lesson); err != nil {
+				return fmt.Errorf("строка %d: %w", l.Line, err)
+			}
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("CommitImport: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+		INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration,
+			room_id, subgroup_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ` + roomIDByName + `, NULLIF(?, 0))`)
+	if err != nil {
+		return fmt.Errorf("CommitImport: %w", err)
+	}
+	defer stmt.Close()
+
+	added := make([]models.Schedule, 0, len(plan.Lessons))
+	for _, l := range plan.Lessons {
+		s := l.Lesson
+		res, err := stmt.Exec(s.CourseID, s.GroupName, s.TeacherRegCode, s.ScheduleTime.UTC().Format(time.RFC3339),
+			s.Description, s.Auditory, s.LessonType, s.Duration, s.Auditory, s.SubgroupID)
+		if err != nil {
+			return fmt.Errorf("строка %d: %w", l.Line, err)
+		}
+		if s.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		added = append(added, s)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("CommitImport: %w", err)
+	}
+	for i := range added {
+		emit(Change{After: &added[i], By: by})
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/db"
+	"education/internal/timetable"
+)
+
+func record(line int, group, course, teacher, room, at string) timetable.Record {
+	ts, err := time.Parse("2006-01-02 15:04", at)
+	if err != nil {
+		panic(err)
+	}
+	return timetable.Record{Line: line, Group: group, Course: course, Teacher: teacher, Room: room,
+		Start: ts, Duration: 90, LessonType: "Лекция"}
+}
+
+func TestPlanAndCommitImport(t *testing.T) {
+	openTestDB(t)
+	for _, q := range []string{
+		`INSERT INTO faculty_groups (faculty, group_name) VALUES ('ФИ', 'АА-23-01'), ('ФИ', 'ББ-23-01')`,
+		`INSERT INTO users (role, name, registration_code) VALUES ('teacher', 'Волкова', 'TH-0001'), ('teacher', 'Козлов', 'TH-0002')`,
+	} {
+		if _, err := db.DB.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CreateLesson(lesson("АА-23-01", "TH-0001", "101", "2025-03-17 08:00", 90), false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanImport([]timetable.Record{
+		record(2, "АА-23-01", "матем", "TH-0001", "101", "2025-03-18 08:00"),
+		record(3, "ББ-23-01", "2", "TH-0002", "101", "2025-03-17 09:00"),  // аудитория занята в базе
+		record(4, "ВВ-23-01", "Матем", "TH-0001", "", "2025-03-19 08:00"), // нет такой группы
+		record(5, "ББ-23-01", "Физика", "TH-0002", "", "2025-03-19 08:00"),
+		record(6, "ББ-23-01", "Прог", "TH-0003", "", "2025-03-19 08:00"),
+		record(7, "ББ-23-01", "Прог", "TH-0001", "", "2025-03-18 09:00"), // преподаватель занят в строке 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Lessons) != 3 || plan.Lessons[0].Lesson.CourseID != 1 || plan.Lessons[1].Lesson.CourseID != 2 {
+		t.Fatalf("неверный план: %+v", plan.Lessons)
+	}
+	if len(plan.Errors) != 3 || plan.Errors[0].Line != 4 || plan.Errors[1].Line != 5 || plan.Errors[2].Line != 6 {
+		t.Fatalf("неверные ошибки: %v", plan.Errors)
+	}
+	if len(plan.Conflicts) != 2 {
+		t.Fatalf("ожидалось 2 пересечения, получено %v", plan.Conflicts)
+	}
+	if c := plan.Conflicts[0]; c.Line != 3 || c.Kind != ConflictRoom || c.WithLine != 0 {
+		t.Errorf("неверное пересечение с базой: %v", c)
+	}
+	if c := plan.Conflicts[1]; c.Line != 7 || c.Kind != ConflictTeacher || c.WithLine != 2 {
+		t.Errorf("неверное пересечение внутри файла: %v", c)
+	}
+
+	// Ошибки в файле блокируют импорт даже с force
+	if err := CommitImport(plan, true, ""); err != ErrImportNotReady {
+		t.Fatalf("ожидалась ErrImportNotReady, получено %v", err)
+	}
+	plan.Errors = nil
+	if err := CommitImport(plan, false, ""); err != ErrImportNotReady {
+		t.Fatalf("без force пересечения должны блокировать импорт: %v", err)
+	}
+
+	var changes []Change
+	unsubscribe := OnChange(func(c Change) { changes = append(changes, c) })
+	defer unsubscribe()
+	if err := CommitImport(plan, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM schedules`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("ожидалось 4 занятия после импорта, в базе %d", count)
+	}
+	if len(changes) != 3 || changes[0].After == nil || changes[0].After.ID == 0 {
+		t.Errorf("ожидалось 3 события о новых занятиях, получено %+v", changes)
+	}
+}
+
+func TestCommitImportRechecksConflicts(t *testing.T) {
+	openTestDB(t)
+	for _, q := range []string{
+		`INSERT INTO faculty_groups (faculty, group_name) VALUES ('ФИ', 'АА-23-01')`,
+		`INSERT INTO users (role, name, registration_code) VALUES ('teacher', 'Волкова', 'TH-0001')`,
+	} {
+		if _, err := db.DB.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plan, err := PlanImport([]timetable.Record{
+		record(2, "АА-23-01", "Матем", "TH-0001", "101", "2025-03-18 08:00"),
+		record(3, "АА-23-01", "Прог", "TH-0001", "101", "2025-03-18 10:00"),
+	})
+	if err != nil || !plan.Ready(false) {
+		t.Fatalf("план должен быть готов: %v %+v", err, plan)
+	}
+
+	// Пока администратор смотрел на план, в расписание добавили занятие
+	if err := CreateLesson(lesson("АА-23-01", "TH-0001", "305", "2025-03-18 10:30", 90), false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitImport(plan, false, ""); err == nil {
+		t.Fatal("ожидалась ошибка пересечения")
+	}
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM schedules`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("импорт должен быть отменён целиком, в базе %d занятий", count)
+	}
+}
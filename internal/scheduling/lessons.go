@@ -0,0 +1,390 @@
+package scheduling
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// ErrLessonNotFound возвращается, если занятия с таким id нет.
+var ErrLessonNotFound = errors.New("занятие не найдено")
+
+// В отличие от запросов для отображения, здесь teacher_reg_code остаётся кодом преподавателя.
+const lessonSelect = `
+	SELECT id, course_id, group_name, teacher_reg_code, schedule_time,
+		COALESCE(description, ''), COALESCE(auditory, ''), COALESCE(lesson_type, ''), COALESCE(duration, 0),
+		COALESCE(rule_id, 0), COALESCE(rule_date, ''),
+		COALESCE(subgroup_id, 0), COALESCE((SELECT name FROM subgroups WHERE id = schedules.subgroup_id), '')
+	FROM schedules
+`
+
+// roomIDByName — подзапрос, связывающий занятие с аудиторией справочника по названию.
+const roomIDByName = `(SELECT id FROM rooms WHERE name = ?)`
+
+// timeLayouts — форматы, в которых schedule_time встречается в базе.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
+
+func parseScheduleTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("некорректное время занятия %q", s)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLesson(row scanner) (models.Schedule, error) {
+	var s models.Schedule
+	var ts, ruleDate string
+	if err := row.Scan(&s.ID, &s.CourseID, &s.GroupName, &s.TeacherRegCode, &ts,
+		&s.Description, &s.Auditory, &s.LessonType, &s.Duration, &s.RuleID, &ruleDate,
+		&s.SubgroupID, &s.SubgroupName); err != nil {
+		return s, err
+	}
+	if ruleDate != "" {
+		s.RuleDate, _ = time.Parse(dateLayout, ruleDate)
+	}
+	t, err := parseScheduleTime(ts)
+	if err != nil {
+		return s, err
+	}
+	s.ScheduleTime = t
+	return s, nil
+}
+
+// End возвращает время окончания занятия.
+func End(s models.Schedule) time.Time {
+	return s.ScheduleTime.Add(time.Duration(s.Duration) * time.Minute)
+}
+
+// GetLesson возвращает разовое занятие по id.
+func GetLesson(id int64) (models.Schedule, error) {
+	s, err := scanLesson(db.DB.QueryRow(lessonSelect+" WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, ErrLessonNotFound
+	}
+	return s, err
+}
+
+// CreateLesson проверяет пересечения и добавляет занятие. При force пересечения игнорируются.
+// by — кто вносит изменение (регистрационный код или ChangedByAdmin).
+func CreateLesson(s *models.Schedule, force bool, by string) error {
+	if err := validateLesson(*s); err != nil {
+		return err
+	}
+	if err := checkRoom(s.Auditory); err != nil {
+		return err
+	}
+	if !force {
+		if err := checkConflicts(*s); err != nil {
+			return err
+		}
+	}
+	res, err := db.DB.Exec(`
+		INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration,
+			room_id, subgroup_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, `+roomIDByName+`, NULLIF(?, 0))`,
+		s.CourseID, s.GroupName, s.TeacherRegCode, s.ScheduleTime.UTC().Format(time.RFC3339),
+		s.Description, s.Auditory, s.LessonType, s.Duration, s.Auditory, s.SubgroupID,
+	)
+	if err != nil {
+		return fmt.Errorf("CreateLesson: %w", err)
+	}
+	if s.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	after := *s
+	emit(Change{After: &after, By: by})
+	return nil
+}
+
+// UpdateLesson проверяет пересечения (кроме самого занятия) и сохраняет изменения.
+func UpdateLesson(s models.Schedule, force bool, by string) error {
+	if err := validateLesson(s); err != nil {
+		return err
+	}
+	if err := checkRoom(s.Auditory); err != nil {
+		return err
+	}
+	if !force {
+		if err := checkConflicts(s); err != nil {
+			return err
+		}
+	}
+	before, err := GetLesson(s.ID)
+	if err != nil {
+		return err
+	}
+	res, err := db.DB.Exec(`
+		UPDATE schedules SET course_id = ?, group_name = ?, teacher_reg_code = ?, schedule_time = ?,
+			description = ?, auditory = ?, lesson_type = ?, duration = ?, room_id = `+roomIDByName+`,
+			subgroup_id = NULLIF(?, 0)
+		WHERE id = ?`,
+		s.CourseID, s.GroupName, s.TeacherRegCode, s.ScheduleTime.UTC().Format(time.RFC3339),
+		s.Description, s.Auditory, s.LessonType, s.Duration, s.Auditory, s.SubgroupID, s.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("UpdateLesson: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrLessonNotFound
+	}
+	emit(Change{Before: &before, After: &s, By: by})
+	return nil
+}
+
+// DeleteLesson удаляет разовое занятие. Замена занятия по правилу не удаляется, а помечается
+// отменённой: иначе в этот день снова появилось бы исходное занятие по правилу.
+func DeleteLesson(id int64, by string) error {
+	before, err := GetLesson(id)
+	if err != nil {
+		return err
+	}
+	query := `DELETE FROM schedules WHERE id = ?`
+	if before.RuleID != 0 {
+		query = `UPDATE schedules SET cancelled = 1 WHERE id = ?`
+	}
+	if _, err := db.DB.Exec(query, id); err != nil {
+		return fmt.Errorf("DeleteLesson: %w", err)
+	}
+	emit(Change{Before: &before, By: by})
+	return nil
+}
+
+func validateLesson(s models.Schedule) error {
+	switch {
+	case s.CourseID == 0:
+		return errors.New("не указан курс")
+	case s.GroupName == "":
+		return errors.New("не указана группа")
+	case s.TeacherRegCode == "":
+		return errors.New("не указан преподаватель")
+	case s.ScheduleTime.IsZero():
+		return errors.New("не указано время")
+	case s.Duration <= 0:
+		return errors.New("продолжительность должна быть больше нуля")
+	}
+	return checkSubgroup(s.GroupName, s.SubgroupID)
+}
+
+// lessonFilter выбирает занятия, где совпадает хотя бы одно из непустых полей.
+// Пустой фильтр выбирает все занятия.
+type lessonFilter struct {
+	Teacher string
+	Group   string
+	Room    string
+}
+
+// where строит условие для таблицы с указанным префиксом колонок ("" или "r.").
+func (f lessonFilter) where(prefix string) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Teacher != "" {
+		conds = append(conds, prefix+"teacher_reg_code = ?")
+		args = append(args, f.Teacher)
+	}
+	if f.Group != "" {
+		conds = append(conds, prefix+"group_name = ?")
+		args = append(args, f.Group)
+	}
+	if f.Room != "" {
+		conds = append(conds, prefix+"auditory = ?")
+		args = append(args, f.Room)
+	}
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " OR "), args
+}
+
+// lessonsBetween возвращает все действующие занятия за диапазон дат: разовые
+// и развёрнутые из правил, за вычетом отменённых и заменённых дней.
+func lessonsBetween(f lessonFilter, start, end time.Time) ([]models.Schedule, error) {
+	from, to := start.Format(dateLayout), end.Format(dateLayout)
+
+	cond, args := f.where("")
+	rows, err := db.DB.Query(lessonSelect+" WHERE cancelled = 0 AND date(schedule_time) BETWEEN ? AND ? AND ("+cond+")",
+		append([]interface{}{from, to}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("lessonsBetween: %w", err)
+	}
+	defer rows.Close()
+	var lessons []models.Schedule
+	for rows.Next() {
+		s, err := scanLesson(rows)
+		if err != nil {
+			return nil, fmt.Errorf("lessonsBetween: %w", err)
+		}
+		lessons = append(lessons, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	overridden, err := overriddenDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, args = f.where("r.")
+	rules, err := queryRules(cond, args, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		times, err := Occurrences(r.ScheduleRule, start, end)
+		if err != nil {
+			continue
+		}
+		for _, t := range times {
+			if overridden[OccurrenceKey(r.ID, t)] {
+				continue
+			}
+			lessons = append(lessons, RuleLesson(r.ScheduleRule, t))
+		}
+	}
+	return lessons, nil
+}
+
+// OccurrenceKey — ключ занятия по правилу в конкретный день.
+func OccurrenceKey(ruleID int64, day time.Time) string {
+	return fmt.Sprintf("%d|%s", ruleID, day.Format(dateLayout))
+}
+
+// OverriddenDays возвращает ключи (OccurrenceKey) дней правил в [start, end], заменённых,
+// перенесённых или отменённых разовыми строками. Учитывается исходный день правила,
+// а не новое время занятия: перенесённое на другую неделю занятие тоже скрывается.
+func OverriddenDays(start, end time.Time) (map[string]bool, error) {
+	return overriddenDays(start.Format(dateLayout), end.Format(dateLayout))
+}
+
+// overriddenDays возвращает ключи дней правил, заменённых или отменённых разовыми строками.
+func overriddenDays(from, to string) (map[string]bool, error) {
+	rows, err := db.DB.Query(`SELECT rule_id, rule_date FROM schedules WHERE rule_id IS NOT NULL AND rule_date BETWEEN ? AND ?`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("overriddenDays: %w", err)
+	}
+	defer rows.Close()
+	overridden := make(map[string]bool)
+	for rows.Next() {
+		var id int64
+		var date string
+		if err := rows.Scan(&id, &date); err != nil {
+			return nil, err
+		}
+		overridden[fmt.Sprintf("%d|%s", id, date)] = true
+	}
+	return overridden, rows.Err()
+}
+
+// RuleLesson превращает занятие по правилу в обычную запись расписания (ID = 0).
+func RuleLesson(r models.ScheduleRule, start time.Time) models.Schedule {
+	return models.Schedule{
+		CourseID:       r.CourseID,
+		GroupName:      r.GroupName,
+		TeacherRegCode: r.TeacherRegCode,
+		ScheduleTime:   start,
+		Description:    r.Description,
+		Auditory:       r.Auditory,
+		LessonType:     r.LessonType,
+		Duration:       r.Duration,
+		RuleID:         r.ID,
+		RuleDate:       truncateDay(start),
+		SubgroupID:     r.SubgroupID,
+		SubgroupName:   r.SubgroupName,
+	}
+}
+
+// GetRuleOccurrence возвращает занятие по правилу в указанный день, если оно проводится
+// и ещё не заменено разовой строкой.
+func GetRuleOccurrence(ruleID int64, day time.Time) (models.Schedule, error) {
+	rules, err := queryRules("r.id = ?", []interface{}{ruleID}, day, day)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	if len(rules) == 0 {
+		return models.Schedule{}, ErrLessonNotFound
+	}
+	times, err := Occurrences(rules[0].ScheduleRule, day, day)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	overridden, err := overriddenDays(day.Format(dateLayout), day.Format(dateLayout))
+	if err != nil {
+		return models.Schedule{}, err
+	}
+	if len(times) == 0 || overridden[OccurrenceKey(ruleID, day)] {
+		return models.Schedule{}, ErrLessonNotFound
+	}
+	return RuleLesson(rules[0].ScheduleRule, times[0]), nil
+}
+
+// OverrideOccurrence сохраняет изменённое занятие по правилу как разовую замену на его день.
+// s.RuleID и s.RuleDate должны указывать на заменяемое занятие.
+func OverrideOccurrence(s *models.Schedule, force bool, by string) error {
+	if s.RuleID == 0 || s.RuleDate.IsZero() {
+		return errors.New("не указано заменяемое занятие по правилу")
+	}
+	if err := validateLesson(*s); err != nil {
+		return err
+	}
+	if err := checkRoom(s.Auditory); err != nil {
+		return err
+	}
+	if !force {
+		if err := checkConflicts(*s); err != nil {
+			return err
+		}
+	}
+	before, err := GetRuleOccurrence(s.RuleID, s.RuleDate)
+	if err != nil {
+		return err
+	}
+	if err := insertOverride(s, false); err != nil {
+		return err
+	}
+	after := *s
+	emit(Change{Before: &before, After: &after, By: by})
+	return nil
+}
+
+// CancelOccurrence отменяет занятие по правилу в его день.
+func CancelOccurrence(s models.Schedule, by string) error {
+	if s.RuleID == 0 || s.RuleDate.IsZero() {
+		return errors.New("не указано отменяемое занятие по правилу")
+	}
+	before, err := GetRuleOccurrence(s.RuleID, s.RuleDate)
+	if err != nil {
+		return err
+	}
+	if err := insertOverride(&s, true); err != nil {
+		return err
+	}
+	emit(Change{Before: &before, By: by})
+	return nil
+}
+
+func insertOverride(s *models.Schedule, cancelled bool) error {
+	res, err := db.DB.Exec(`
+		INSERT INTO schedules (course_id, group_name, teacher_reg_code, schedule_time, description, auditory, lesson_type, duration,
+			room_id, rule_id, rule_date, cancelled, subgroup_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, `+roomIDByName+`, ?, ?, ?, NULLIF(?, 0))`,
+		s.CourseID, s.GroupName, s.TeacherRegCode, s.ScheduleTime.UTC().Format(time.RFC3339),
+		s.Description, s.Auditory, s.LessonType, s.Duration, s.Auditory,
+		s.RuleID, s.RuleDate.Format(dateLayout), cancelled, s.SubgroupID,
+	)
+	if err != nil {
+		return fmt.Errorf("insertOverride: %w", err)
+	}
+	s.ID, err = res.LastInsertId()
+	return err
+}
@@ -0,0 +1,126 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// Revision — сохранённое изменение расписания.
+type Revision struct {
+	ID int64
+	Change
+}
+
+func marshalLesson(s *models.Schedule) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(s)
+	return string(data), err
+}
+
+func unmarshalLesson(data string) (*models.Schedule, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var s models.Schedule
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// revisionDays — первый и последний день, которых касается изменение.
+func revisionDays(c Change) (first, last time.Time) {
+	for _, s := range []*models.Schedule{c.Before, c.After} {
+		if s == nil {
+			continue
+		}
+		day := truncateDay(s.ScheduleTime)
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	return first, last
+}
+
+func recordRevision(c Change) error {
+	before, err := marshalLesson(c.Before)
+	if err != nil {
+		return fmt.Errorf("recordRevision: %w", err)
+	}
+	after, err := marshalLesson(c.After)
+	if err != nil {
+		return fmt.Errorf("recordRevision: %w", err)
+	}
+	first, last := revisionDays(c)
+	_, err = db.DB.Exec(`
+		INSERT INTO schedule_revisions (changed_at, changed_by, before_json, after_json, first_day, last_day)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		c.At.UTC().Format(time.RFC3339), c.By, before, after, first.Format(dateLayout), last.Format(dateLayout))
+	if err != nil {
+		return fmt.Errorf("recordRevision: %w", err)
+	}
+	return nil
+}
+
+// LatestRevision возвращает номер последнего изменения расписания (0 — изменений не было).
+func LatestRevision() (int64, error) {
+	var id int64
+	if err := db.DB.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM schedule_revisions`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("LatestRevision: %w", err)
+	}
+	return id, nil
+}
+
+// RevisionsSince возвращает изменения после afterID, в которых занятие до или после
+// изменения попадает в дни [start, end], в порядке внесения.
+func RevisionsSince(afterID int64, start, end time.Time) ([]Revision, error) {
+	from, to := truncateDay(start), truncateDay(end)
+	rows, err := db.DB.Query(`
+		SELECT id, changed_at, changed_by, before_json, after_json
+		FROM schedule_revisions
+		WHERE id > ? AND first_day <= ? AND last_day >= ?
+		ORDER BY id`, afterID, to.Format(dateLayout), from.Format(dateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("RevisionsSince: %w", err)
+	}
+	defer rows.Close()
+
+	inRange := func(s *models.Schedule) bool {
+		if s == nil {
+			return false
+		}
+		day := truncateDay(s.ScheduleTime)
+		return !day.Before(from) && !day.After(to)
+	}
+	var list []Revision
+	for rows.Next() {
+		var r Revision
+		var at, before, after string
+		if err := rows.Scan(&r.ID, &at, &r.By, &before, &after); err != nil {
+			return nil, fmt.Errorf("RevisionsSince: %w", err)
+		}
+		if r.At, err = time.Parse(time.RFC3339, at); err != nil {
+			return nil, fmt.Errorf("изменение %d: %w", r.ID, err)
+		}
+		if r.Before, err = unmarshalLesson(before); err != nil {
+			return nil, fmt.Errorf("изменение %d: %w", r.ID, err)
+		}
+		if r.After, err = unmarshalLesson(after); err != nil {
+			return nil, fmt.Errorf("изменение %d: %w", r.ID, err)
+		}
+		// Перенос через несколько недель не касается недель между ними
+		if inRange(r.Before) || inRange(r.After) {
+			list = append(list, r)
+		}
+	}
+	return list, rows.Err()
+}
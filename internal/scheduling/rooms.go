@@ -0,0 +1,113 @@
+package scheduling
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// ErrRoomNotFound возвращается, если аудитории нет в справочнике.
+var ErrRoomNotFound = errors.New("аудитория не найдена")
+
+const roomSelect = `SELECT id, name, building, floor, capacity, equipment FROM rooms`
+
+func scanRoom(row scanner) (models.Room, error) {
+	var r models.Room
+	err := row.Scan(&r.ID, &r.Name, &r.Building, &r.Floor, &r.Capacity, &r.Equipment)
+	return r, err
+}
+
+// GetRooms возвращает все аудитории справочника по корпусу, этажу и номеру.
+func GetRooms() ([]models.Room, error) {
+	rows, err := db.DB.Query(roomSelect + ` ORDER BY building, floor, name`)
+	if err != nil {
+		return nil, fmt.Errorf("GetRooms: %w", err)
+	}
+	defer rows.Close()
+	var rooms []models.Room
+	for rows.Next() {
+		r, err := scanRoom(rows)
+		if err != nil {
+			return nil, fmt.Errorf("GetRooms: %w", err)
+		}
+		rooms = append(rooms, r)
+	}
+	return rooms, rows.Err()
+}
+
+// GetRoom возвращает аудиторию по id.
+func GetRoom(id int64) (models.Room, error) {
+	r, err := scanRoom(db.DB.QueryRow(roomSelect+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, ErrRoomNotFound
+	}
+	return r, err
+}
+
+// checkRoom проверяет, что аудитория занятия есть в справочнике. Пока справочник пуст
+// (база до его появления), аудитория остаётся свободным текстом.
+func checkRoom(name string) error {
+	if name == "" {
+		return nil
+	}
+	var known, total int
+	err := db.DB.QueryRow(`SELECT COALESCE(SUM(name = ?), 0), COUNT(*) FROM rooms`, name).Scan(&known, &total)
+	if err != nil {
+		return fmt.Errorf("checkRoom: %w", err)
+	}
+	if total > 0 && known == 0 {
+		return fmt.Errorf("аудитория «%s» не найдена в справочнике", name)
+	}
+	return nil
+}
+
+// FreeRooms возвращает аудитории, в которых нет занятий в интервале [start, start+minutes).
+func FreeRooms(start time.Time, minutes int) ([]models.Room, error) {
+	rooms, err := GetRooms()
+	if err != nil {
+		return nil, err
+	}
+	slot := models.Schedule{ScheduleTime: start, Duration: minutes}
+	// Соседние дни захватываем на случай занятий, переходящих через полночь
+	lessons, err := lessonsBetween(lessonFilter{}, start.AddDate(0, 0, -1), End(slot).AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[string]bool)
+	for _, l := range lessons {
+		if l.Auditory != "" && overlaps(slot, l) {
+			busy[l.Auditory] = true
+		}
+	}
+	var free []models.Room
+	for _, r := range rooms {
+		if !busy[r.Name] {
+			free = append(free, r)
+		}
+	}
+	return free, nil
+}
+
+// RoomOccupancy возвращает занятия в аудитории за день в порядке начала.
+func RoomOccupancy(room string, day time.Time) ([]models.Schedule, error) {
+	day = truncateDay(day)
+	lessons, err := lessonsBetween(lessonFilter{Room: room}, day.AddDate(0, 0, -1), day)
+	if err != nil {
+		return nil, err
+	}
+	dayEnd := day.AddDate(0, 0, 1)
+	var result []models.Schedule
+	for _, l := range lessons {
+		// Занятие попадает в день, если пересекается с ним (в том числе начавшись накануне)
+		if l.Auditory == room && l.ScheduleTime.Before(dayEnd) && End(l).After(day) {
+			result = append(result, l)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ScheduleTime.Before(result[j].ScheduleTime) })
+	return result, nil
+}
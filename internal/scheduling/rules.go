@@ -0,0 +1,87 @@
+// Package scheduling содержит доменную логику расписания: повторяющиеся правила
+// и их развёртывание в конкретные занятия.
+package scheduling
+
+import (
+	"fmt"
+	"time"
+
+	"education/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// WeekNumber возвращает номер недели, по чётности которого определяется числитель/знаменатель.
+// Внутри семестра — номер учебной недели, вне его — номер недели ISO 8601.
+var WeekNumber = func(t time.Time) int {
+	if week, _, ok := CurrentCalendar().AcademicWeek(t); ok {
+		return week
+	}
+	_, week := t.ISOWeek()
+	return week
+}
+
+// ParityOf возвращает чётность недели, в которую попадает дата.
+func ParityOf(t time.Time) models.WeekParity {
+	if WeekNumber(t)%2 == 1 {
+		return models.ParityOdd
+	}
+	return models.ParityEven
+}
+
+// ParseStartTime разбирает время начала занятия в формате "15:04".
+func ParseStartTime(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некорректное время %q: %w", s, err)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Occurrences разворачивает правило в моменты начала занятий в диапазоне дат [start, end] включительно.
+func Occurrences(rule models.ScheduleRule, start, end time.Time) ([]time.Time, error) {
+	hour, minute, err := ParseStartTime(rule.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	from := truncateDay(start)
+	if vf := truncateDay(rule.ValidFrom); vf.After(from) {
+		from = vf
+	}
+	to := truncateDay(end)
+	if !rule.ValidTo.IsZero() {
+		if vt := truncateDay(rule.ValidTo); vt.Before(to) {
+			to = vt
+		}
+	}
+
+	skip := make(map[string]bool, len(rule.Exceptions))
+	for _, d := range rule.Exceptions {
+		skip[d.Format(dateLayout)] = true
+	}
+
+	// Переходим сразу к первому подходящему дню недели
+	shift := (int(rule.Weekday) - int(from.Weekday()) + 7) % 7
+	cal := CurrentCalendar()
+	var result []time.Time
+	for day := from.AddDate(0, 0, shift); !day.After(to); day = day.AddDate(0, 0, 7) {
+		if rule.Parity != models.ParityAny && ParityOf(day) != rule.Parity {
+			continue
+		}
+		if skip[day.Format(dateLayout)] {
+			continue
+		}
+		// В праздники и на каникулах занятия по правилам не проводятся
+		if _, holiday := cal.Holiday(day); holiday {
+			continue
+		}
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.UTC))
+	}
+	return result, nil
+}
+
+// truncateDay берёт календарную дату t (в её собственной зоне), как это делает date() в запросах.
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,90 @@
+package scheduling
+
+import (
+	"testing"
+	"time"
+
+	"education/internal/models"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func formatAll(times []time.Time) []string {
+	var out []string
+	for _, t := range times {
+		out = append(out, t.Format("2006-01-02 15:04"))
+	}
+	return out
+}
+
+func TestOccurrencesWeekly(t *testing.T) {
+	rule := models.ScheduleRule{
+		Weekday:    time.Wednesday,
+		StartTime:  "09:45",
+		ValidFrom:  date("2025-03-05"),
+		ValidTo:    date("2025-03-26"),
+		Exceptions: []time.Time{date("2025-03-19")},
+	}
+	got := formatAll(mustOccurrences(t, rule, date("2025-03-01"), date("2025-03-31")))
+	want := []string{"2025-03-05 09:45", "2025-03-12 09:45", "2025-03-26 09:45"}
+	if !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOccurrencesParity(t *testing.T) {
+	// ISO-недели: 17 марта 2025 — 12-я (чётная), 24 марта — 13-я (нечётная)
+	rule := models.ScheduleRule{
+		Weekday:   time.Monday,
+		StartTime: "08:00",
+		ValidFrom: date("2025-03-01"),
+		ValidTo:   date("2025-04-30"),
+		Parity:    models.ParityOdd,
+	}
+	got := formatAll(mustOccurrences(t, rule, date("2025-03-17"), date("2025-04-13")))
+	want := []string{"2025-03-24 08:00", "2025-04-07 08:00"}
+	if !equal(got, want) {
+		t.Errorf("нечётные: got %v, want %v", got, want)
+	}
+
+	rule.Parity = models.ParityEven
+	got = formatAll(mustOccurrences(t, rule, date("2025-03-17"), date("2025-04-13")))
+	want = []string{"2025-03-17 08:00", "2025-03-31 08:00"}
+	if !equal(got, want) {
+		t.Errorf("чётные: got %v, want %v", got, want)
+	}
+}
+
+func TestOccurrencesRejectsBadTime(t *testing.T) {
+	rule := models.ScheduleRule{StartTime: "25:00", ValidFrom: date("2025-03-01"), ValidTo: date("2025-03-31")}
+	if _, err := Occurrences(rule, date("2025-03-01"), date("2025-03-31")); err == nil {
+		t.Error("ожидалась ошибка для некорректного времени")
+	}
+}
+
+func mustOccurrences(t *testing.T, rule models.ScheduleRule, start, end time.Time) []time.Time {
+	t.Helper()
+	times, err := Occurrences(rule, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return times
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
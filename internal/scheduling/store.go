@@ -0,0 +1,156 @@
+package scheduling
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// RuleInfo — правило вместе с именами преподавателя и курса для отображения.
+type RuleInfo struct {
+	models.ScheduleRule
+	TeacherName string
+	CourseName  string
+}
+
+const ruleSelect = `
+	SELECT
+		r.id, r.course_id, r.group_name, r.teacher_reg_code, r.weekday, r.start_time,
+		r.duration, r.description, r.auditory, r.lesson_type, r.valid_from, r.valid_to, r.week_parity,
+		COALESCE((SELECT GROUP_CONCAT(e.exception_date) FROM schedule_rule_exceptions e WHERE e.rule_id = r.id), ''),
+		COALESCE(NULLIF(p.display_name, ''), u.name, r.teacher_reg_code),
+		COALESCE(c.name, 'Неизвестный курс'),
+		COALESCE(r.subgroup_id, 0), COALESCE(sg.name, '')
+	FROM schedule_rules r
+	LEFT JOIN users u ON r.teacher_reg_code = u.registration_code
+	LEFT JOIN teacher_profiles p ON r.teacher_reg_code = p.registration_code
+	LEFT JOIN courses c ON r.course_id = c.id
+	LEFT JOIN subgroups sg ON r.subgroup_id = sg.id
+`
+
+// RulesForGroup возвращает правила группы, действующие хотя бы в один день диапазона.
+func RulesForGroup(group string, start, end time.Time) ([]RuleInfo, error) {
+	return queryRules("r.group_name = ?", []interface{}{group}, start, end)
+}
+
+// RulesForTeacher возвращает правила преподавателя, действующие хотя бы в один день диапазона.
+func RulesForTeacher(teacherRegCode string, start, end time.Time) ([]RuleInfo, error) {
+	return queryRules("r.teacher_reg_code = ?", []interface{}{teacherRegCode}, start, end)
+}
+
+func queryRules(cond string, args []interface{}, start, end time.Time) ([]RuleInfo, error) {
+	args = append(args, end.Format(dateLayout), start.Format(dateLayout))
+	rows, err := db.DB.Query(ruleSelect+" WHERE ("+cond+") AND r.valid_from <= ? AND r.valid_to >= ? ORDER BY r.id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("queryRules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []RuleInfo
+	for rows.Next() {
+		var (
+			r                  RuleInfo
+			weekday, parity    int
+			validFrom, validTo string
+			exceptions         string
+		)
+		if err := rows.Scan(
+			&r.ID, &r.CourseID, &r.GroupName, &r.TeacherRegCode, &weekday, &r.StartTime,
+			&r.Duration, &r.Description, &r.Auditory, &r.LessonType, &validFrom, &validTo, &parity,
+			&exceptions, &r.TeacherName, &r.CourseName,
+			&r.SubgroupID, &r.SubgroupName,
+		); err != nil {
+			return nil, fmt.Errorf("queryRules: %w", err)
+		}
+		r.Weekday = time.Weekday(weekday)
+		r.Parity = models.WeekParity(parity)
+		if r.ValidFrom, err = time.Parse(dateLayout, validFrom); err != nil {
+			return nil, fmt.Errorf("правило %d: valid_from: %w", r.ID, err)
+		}
+		if r.ValidTo, err = time.Parse(dateLayout, validTo); err != nil {
+			return nil, fmt.Errorf("правило %d: valid_to: %w", r.ID, err)
+		}
+		for _, d := range strings.Split(exceptions, ",") {
+			if t, err := time.Parse(dateLayout, d); err == nil {
+				r.Exceptions = append(r.Exceptions, t)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// CreateRule проверяет пересечения всех занятий правила за период его действия и сохраняет
+// правило вместе с датами-исключениями, заполняя rule.ID. При force пересечения игнорируются.
+func CreateRule(rule *models.ScheduleRule, force bool) error {
+	if _, _, err := ParseStartTime(rule.StartTime); err != nil {
+		return err
+	}
+	if rule.ValidTo.Before(rule.ValidFrom) {
+		return fmt.Errorf("CreateRule: период действия заканчивается раньше, чем начинается")
+	}
+	if err := checkRoom(rule.Auditory); err != nil {
+		return err
+	}
+	if err := checkSubgroup(rule.GroupName, rule.SubgroupID); err != nil {
+		return err
+	}
+	if !force {
+		conflicts, err := FindRuleConflicts(*rule)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return &ConflictError{Conflicts: conflicts}
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("CreateRule: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO schedule_rules (course_id, group_name, teacher_reg_code, weekday, start_time, duration,
+			description, auditory, lesson_type, valid_from, valid_to, week_parity, subgroup_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, 0))`,
+		rule.CourseID, rule.GroupName, rule.TeacherRegCode, int(rule.Weekday), rule.StartTime, rule.Duration,
+		rule.Description, rule.Auditory, rule.LessonType,
+		rule.ValidFrom.Format(dateLayout), rule.ValidTo.Format(dateLayout), int(rule.Parity), rule.SubgroupID,
+	)
+	if err != nil {
+		return fmt.Errorf("CreateRule: %w", err)
+	}
+	if rule.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("CreateRule: %w", err)
+	}
+	for _, d := range rule.Exceptions {
+		if err := addException(tx, rule.ID, d); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AddRuleException отменяет занятие по правилу в указанную дату.
+func AddRuleException(ruleID int64, date time.Time) error {
+	return addException(db.DB, ruleID, date)
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func addException(e execer, ruleID int64, date time.Time) error {
+	_, err := e.Exec(`INSERT OR IGNORE INTO schedule_rule_exceptions (rule_id, exception_date) VALUES (?, ?)`,
+		ruleID, date.Format(dateLayout))
+	if err != nil {
+		return fmt.Errorf("addException: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package scheduling
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"education/internal/db"
+	"education/internal/models"
+)
+
+// ErrSubgroupNotFound возвращается, если подгруппы нет у группы.
+var ErrSubgroupNotFound = errors.New("подгруппа не найдена")
+
+// maxSubgroupNameLen — длина названия подгруппы в символах (оно попадает в callback_data).
+const maxSubgroupNameLen = 16
+
+const subgroupSelect = `SELECT id, group_name, name FROM subgroups`
+
+func scanSubgroup(row scanner) (models.Subgroup, error) {
+	var sg models.Subgroup
+	err := row.Scan(&sg.ID, &sg.GroupName, &sg.Name)
+	return sg, err
+}
+
+// ListSubgroups возвращает подгруппы группы по названию.
+func ListSubgroups(group string) ([]models.Subgroup, error) {
+	rows, err := db.DB.Query(subgroupSelect+` WHERE group_name = ? ORDER BY name`, group)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubgroups: %w", err)
+	}
+	defer rows.Close()
+	var list []models.Subgroup
+	for rows.Next() {
+		sg, err := scanSubgroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListSubgroups: %w", err)
+		}
+		list = append(list, sg)
+	}
+	return list, rows.Err()
+}
+
+// GetSubgroup возвращает подгруппу по id.
+func GetSubgroup(id int64) (models.Subgroup, error) {
+	sg, err := scanSubgroup(db.DB.QueryRow(subgroupSelect+` WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return sg, ErrSubgroupNotFound
+	}
+	return sg, err
+}
+
+// FindSubgroup ищет подгруппу группы по названию.
+func FindSubgroup(group, name string) (models.Subgroup, error) {
+	sg, err := scanSubgroup(db.DB.QueryRow(subgroupSelect+` WHERE group_name = ? AND name = ?`, group, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return sg, fmt.Errorf("у группы %s нет подгруппы «%s»", group, name)
+	}
+	return sg, err
+}
+
+// AddSubgroup создаёт подгруппу группы.
+func AddSubgroup(group, name string) (models.Subgroup, error) {
+	name = strings.TrimSpace(name)
+	sg := models.Subgroup{GroupName: group, Name: name}
+	switch {
+	case name == "":
+		return sg, errors.New("не указано название подгруппы")
+	case utf8.RuneCountInString(name) > maxSubgroupNameLen:
+		return sg, fmt.Errorf("название подгруппы длиннее %d символов", maxSubgroupNameLen)
+	}
+	var known int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM faculty_groups WHERE group_name = ?`, group).Scan(&known); err != nil {
+		return sg, fmt.Errorf("AddSubgroup: %w", err)
+	}
+	if known == 0 {
+		return sg, fmt.Errorf("группа «%s» не найдена", group)
+	}
+	if _, err := FindSubgroup(group, name); err == nil {
+		return sg, fmt.Errorf("подгруппа «%s» уже есть", name)
+	}
+	res, err := db.DB.Exec(`INSERT INTO subgroups (group_name, name) VALUES (?, ?)`, group, name)
+	if err != nil {
+		return sg, fmt.Errorf("AddSubgroup: %w", err)
+	}
+	sg.ID, err = res.LastInsertId()
+	return sg, err
+}
+
+// DeleteSubgroup удаляет подгруппу, если для неё нет занятий. Студенты подгруппы
+// остаются без подгруппы и снова видят все занятия группы.
+func DeleteSubgroup(id int64) error {
+	var used int
+	err := db.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM schedules WHERE subgroup_id = ?) + (SELECT COUNT(*) FROM schedule_rules WHERE subgroup_id = ?)`,
+		id, id).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("DeleteSubgroup: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("для подгруппы назначено занятий: %d, сначала перенесите их", used)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("DeleteSubgroup: %w", err)
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(`DELETE FROM subgroups WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteSubgroup: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrSubgroupNotFound
+	}
+	if _, err := tx.Exec(`UPDATE users SET subgroup_id = NULL WHERE subgroup_id = ?`, id); err != nil {
+		return fmt.Errorf("DeleteSubgroup: %w", err)
+	}
+	return tx.Commit()
+}
+
+// checkSubgroup проверяет, что подгруппа занятия принадлежит его группе. 0 — вся группа.
+func checkSubgroup(group string, subgroupID int64) error {
+	if subgroupID == 0 {
+		return nil
+	}
+	sg, err := GetSubgroup(subgroupID)
+	if err != nil {
+		return err
+	}
+	if sg.GroupName != group {
+		return fmt.Errorf("подгруппа «%s» относится к группе %s, а не %s", sg.Name, sg.GroupName, group)
+	}
+	return nil
+}
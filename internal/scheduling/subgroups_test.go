@@ -0,0 +1,73 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+
+	"education/internal/db"
+)
+
+func TestSubgroupLessonsConflictOnlyWithinSubgroup(t *testing.T) {
+	openTestDB(t)
+	if _, err := db.DB.Exec(`INSERT INTO faculty_groups (faculty, group_name) VALUES ('ФИТ', 'АА-23-01'), ('ФИТ', 'ББ-23-01')`); err != nil {
+		t.Fatal(err)
+	}
+	first, err := AddSubgroup("АА-23-01", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := AddSubgroup("АА-23-01", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AddSubgroup("АА-23-01", "1"); err == nil {
+		t.Error("повторная подгруппа должна отклоняться")
+	}
+	other, err := AddSubgroup("ББ-23-01", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lab1 := lesson("АА-23-01", "TH-0001", "101", "2025-03-17 08:00", 90)
+	lab1.SubgroupID = first.ID
+	if err := CreateLesson(lab1, false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Вторая подгруппа в то же время у другого преподавателя — не пересечение
+	lab2 := lesson("АА-23-01", "TH-0002", "102", "2025-03-17 08:00", 90)
+	lab2.SubgroupID = second.ID
+	if err := CreateLesson(lab2, false, ""); err != nil {
+		t.Errorf("занятия разных подгрупп не должны пересекаться: %v", err)
+	}
+
+	// Занятие всей группы пересекается с обеими подгруппами
+	lecture := lesson("АА-23-01", "TH-0003", "103", "2025-03-17 09:00", 90)
+	var ce *ConflictError
+	if err := CreateLesson(lecture, false, ""); !errors.As(err, &ce) || len(ce.Conflicts) != 2 {
+		t.Errorf("ожидались 2 пересечения по группе, получено %v", err)
+	}
+
+	// Подгруппа должна принадлежать группе занятия
+	foreign := lesson("АА-23-01", "TH-0003", "103", "2025-03-18 09:00", 90)
+	foreign.SubgroupID = other.ID
+	if err := CreateLesson(foreign, false, ""); err == nil {
+		t.Error("подгруппа другой группы должна отклоняться")
+	}
+
+	got, err := GetLesson(lab1.ID)
+	if err != nil || got.SubgroupName != "1" || got.Audience() != "АА-23-01 (подгр. 1)" {
+		t.Errorf("GetLesson: %+v %v", got, err)
+	}
+
+	// Подгруппу с занятиями удалить нельзя, пустую — можно
+	if err := DeleteSubgroup(first.ID); err == nil {
+		t.Error("подгруппа с занятиями не должна удаляться")
+	}
+	if err := DeleteSubgroup(other.ID); err != nil {
+		t.Errorf("DeleteSubgroup: %v", err)
+	}
+	if err := DeleteSubgroup(other.ID); !errors.Is(err, ErrSubgroupNotFound) {
+		t.Errorf("повторное удаление: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+// Package tgtest содержит фейковый сервер Telegram Bot API для тестов.
+// Сервер принимает запросы настоящего клиента tgbotapi, записывает их
+// и отвечает правдоподобными ответами, не обращаясь к Telegram.
+package tgtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Token — токен, с которым создаётся тестовый клиент.
+const Token = "test-token"
+
+// Call — один записанный вызов метода Bot API.
+type Call struct {
+	Method string
+	Params url.Values
+	Files  map[string][]byte // загруженные файлы (sendDocument и т.п.)
+}
+
+// ChatID возвращает chat_id вызова (0, если параметра нет).
+func (c Call) ChatID() int64 {
+	id, _ := strconv.ParseInt(c.Params.Get("chat_id"), 10, 64)
+	return id
+}
+
+// Text возвращает текст сообщения (text или caption).
+func (c Call) Text() string {
+	if t := c.Params.Get("text"); t != "" {
+		return t
+	}
+	return c.Params.Get("caption")
+}
+
+// InlineKeyboard разбирает reply_markup как inline-клавиатуру.
+// Возвращает nil, если клавиатуры нет или она другого типа.
+func (c Call) InlineKeyboard() [][]tgbotapi.InlineKeyboardButton {
+	raw := c.Params.Get("reply_markup")
+	if raw == "" {
+		return nil
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(raw), &markup); err != nil {
+		return nil
+	}
+	return markup.InlineKeyboard
+}
+
+// CallbackData возвращает все callback_data inline-клавиатуры по порядку.
+func (c Call) CallbackData() []string {
+	var data []string
+	for _, row := range c.InlineKeyboard() {
+		for _, b := range row {
+			if b.CallbackData != nil {
+				data = append(data, *b.CallbackData)
+			}
+		}
+	}
+	return data
+}
+
+// Server — фейковый Bot API поверх httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	calls     []Call
+	nextMsgID int
+	failures  map[string][]Failure
+	files     map[string][]byte // file_id → содержимое для getFile и скачивания
+}
+
+// Failure описывает ошибку, которую сервер вернёт на очередной вызов метода.
+type Failure struct {
+	Code        int    // error_code и HTTP-статус ответа
+	Description string // description
+	RetryAfter  int    // parameters.retry_after, если больше нуля
+}
+
+// NewServer запускает фейковый сервер. Не забудьте вызвать Close.
+func NewServer() *Server {
+	s := &Server{failures: make(map[string][]Failure), files: make(map[string][]byte)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Endpoint возвращает шаблон адреса API для tgbotapi.NewBotAPIWithAPIEndpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + "/bot%s/%s"
+}
+
+// NewBot создаёт настоящий клиент tgbotapi, направленный на фейковый сервер.
+func (s *Server) NewBot() (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithAPIEndpoint(Token, s.Endpoint())
+}
+
+// FileEndpoint возвращает шаблон адреса скачивания файлов (аналог tgbotapi.FileEndpoint).
+func (s *Server) FileEndpoint() string {
+	return s.URL + "/file/bot%s/%s"
+}
+
+// AddFile регистрирует файл, который бот сможет получить через getFile и скачать.
+func (s *Server) AddFile(fileID string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[fileID] = data
+}
+
+// Fail ставит в очередь ошибку для следующего вызова метода.
+func (s *Server) Fail(method string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], f)
+}
+
+// Calls возвращает копию всех записанных вызовов (кроме getMe).
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Call, len(s.calls))
+	copy(out, s.calls)
+	return out
+}
+
+// CallsTo возвращает вызовы конкретного метода.
+func (s *Server) CallsTo(method string) []Call {
+	var out []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset очищает журнал вызовов.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Путь имеет вид /bot<token>/<method>
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// Скачивание файла: /file/bot<token>/documents/<file_id>
+	if len(parts) == 4 && parts[0] == "file" && parts[2] == "documents" {
+		s.mu.Lock()
+		data, ok := s.files[parts[3]]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+		return
+	}
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		http.NotFound(w, r)
+		return
+	}
+	method := parts[1]
+
+	call := Call{Method: method, Files: make(map[string][]byte)}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for field, headers := range r.MultipartForm.File {
+			f, err := headers[0].Open()
+			if err != nil {
+				continue
+			}
+			data, _ := io.ReadAll(f)
+			f.Close()
+			call.Files[field] = data
+		}
+	} else if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	call.Params = r.Form
+
+	if method == "getMe" {
+		writeResult(w, tgbotapi.User{ID: 1, IsBot: true, FirstName: "Test", UserName: "test_bot"})
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	var failure *Failure
+	if queue := s.failures[method]; len(queue) > 0 {
+		failure = &queue[0]
+		s.failures[method] = queue[1:]
+	}
+	s.nextMsgID++
+	msgID := s.nextMsgID
+	s.mu.Unlock()
+
+	if failure != nil {
+		writeError(w, *failure)
+		return
+	}
+
+	switch method {
+	case "getFile":
+		fileID := call.Params.Get("file_id")
+		s.mu.Lock()
+		data, ok := s.files[fileID]
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, Failure{Code: 400, Description: "Bad Request: invalid file_id"})
+			return
+		}
+		writeResult(w, tgbotapi.File{FileID: fileID, FileSize: len(data), FilePath: "documents/" + fileID})
+	case "sendMessage", "sendDocument", "editMessageText":
+		if id, err := strconv.Atoi(call.Params.Get("message_id")); err == nil {
+			msgID = id
+		}
+		writeResult(w, tgbotapi.Message{
+			MessageID: msgID,
+			Date:      int(time.Now().Unix()),
+			Chat:      &tgbotapi.Chat{ID: call.ChatID(), Type: "private"},
+			Text:      call.Params.Get("text"),
+		})
+	default:
+		writeResult(w, true)
+	}
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	raw, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	resp := tgbotapi.APIResponse{Ok: false, ErrorCode: f.Code, Description: f.Description}
+	if f.RetryAfter > 0 {
+		resp.Parameters = &tgbotapi.ResponseParameters{RetryAfter: f.RetryAfter}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.Code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,256 @@
+package timetable
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDuration — продолжительность пары, если колонки «Длительность» нет или она пустая.
+const DefaultDuration = 90
+
+// Колонки таблицы импорта.
+const (
+	colGroup       = "group"
+	colSubgroup    = "subgroup"
+	colCourse      = "course"
+	colTeacher     = "teacher"
+	colDate        = "date"
+	colTime        = "time"
+	colDateTime    = "datetime"
+	colDuration    = "duration"
+	colRoom        = "room"
+	colLessonType  = "type"
+	colDescription = "description"
+)
+
+// headerAliases — допустимые названия колонок (без учёта регистра).
+var headerAliases = map[string]string{
+	"группа": colGroup, "group": colGroup,
+	"подгруппа": colSubgroup, "subgroup": colSubgroup,
+	"курс": colCourse, "предмет": colCourse, "дисциплина": colCourse, "course": colCourse,
+	"преподаватель": colTeacher, "код преподавателя": colTeacher, "teacher": colTeacher,
+	"дата": colDate, "date": colDate,
+	"время": colTime, "начало": colTime, "time": colTime,
+	"дата и время": colDateTime, "datetime": colDateTime,
+	"длительность": colDuration, "продолжительность": colDuration, "duration": colDuration,
+	"аудитория": colRoom, "ауд.": colRoom, "room": colRoom, "auditory": colRoom,
+	"тип": colLessonType, "тип занятия": colLessonType, "type": colLessonType,
+	"описание": colDescription, "комментарий": colDescription, "description": colDescription,
+}
+
+// Форматы дат и времени, которые встречаются в таблицах деканата.
+var (
+	dateLayouts     = []string{"02.01.2006", "2006-01-02", "02.01.06", "2.1.2006"}
+	timeLayouts     = []string{"15:04", "15.04", "15:04:05"}
+	dateTimeLayouts = []string{"02.01.2006 15:04", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02T15:04:05", "02.01.2006 15:04:05"}
+)
+
+// excelEpoch — нулевой день дат Excel (с учётом ошибки 1900 года).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Record — одна строка таблицы, уже разобранная. Группа, курс и преподаватель
+// проверяются по базе позже, при планировании импорта.
+type Record struct {
+	Line        int // номер строки в файле (с единицы, с учётом заголовка)
+	Group       string
+	Subgroup    string // название подгруппы; пусто — вся группа
+	Course      string // название или id курса
+	Teacher     string // регистрационный код преподавателя
+	Start       time.Time
+	Duration    int
+	Room        string
+	LessonType  string
+	Description string
+}
+
+// RowError — ошибка в конкретной строке файла.
+type RowError struct {
+	Line    int
+	Message string
+}
+
+func (e RowError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("строка %d: %s", e.Line, e.Message)
+}
+
+// Parse разбирает таблицу: первая непустая строка — заголовок, далее по занятию в строке.
+// Строки с ошибками не попадают в результат, а возвращаются списком errs.
+func Parse(rows [][]string) (records []Record, errs []RowError) {
+	headerLine := -1
+	for i, row := range rows {
+		if !blank(row) {
+			headerLine = i
+			break
+		}
+	}
+	if headerLine < 0 {
+		return nil, []RowError{{Message: "файл пустой"}}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[headerLine] {
+		key, ok := headerAliases[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[key]; dup {
+			return nil, []RowError{{Line: headerLine + 1, Message: fmt.Sprintf("колонка «%s» указана дважды", name)}}
+		}
+		columns[key] = i
+	}
+	var missing []string
+	for _, need := range []struct{ key, name string }{
+		{colGroup, "Группа"}, {colCourse, "Курс"}, {colTeacher, "Преподаватель"},
+	} {
+		if _, ok := columns[need.key]; !ok {
+			missing = append(missing, need.name)
+		}
+	}
+	_, hasDate := columns[colDate]
+	_, hasTime := columns[colTime]
+	if _, ok := columns[colDateTime]; !ok && !(hasDate && hasTime) {
+		missing = append(missing, "Дата и Время")
+	}
+	if len(missing) > 0 {
+		return nil, []RowError{{Line: headerLine + 1, Message: "нет колонок: " + strings.Join(missing, ", ")}}
+	}
+
+	for i := headerLine + 1; i < len(rows); i++ {
+		if blank(rows[i]) {
+			continue
+		}
+		rec, err := parseRow(rows[i], columns)
+		if err != nil {
+			errs = append(errs, RowError{Line: i + 1, Message: err.Error()})
+			continue
+		}
+		rec.Line = i + 1
+		records = append(records, rec)
+	}
+	return records, errs
+}
+
+func parseRow(row []string, columns map[string]int) (Record, error) {
+	cell := func(key string) string {
+		i, ok := columns[key]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	rec := Record{
+		Group:       cell(colGroup),
+		Subgroup:    cell(colSubgroup),
+		Course:      cell(colCourse),
+		Teacher:     cell(colTeacher),
+		Room:        cell(colRoom),
+		LessonType:  cell(colLessonType),
+		Description: cell(colDescription),
+		Duration:    DefaultDuration,
+	}
+	switch {
+	case rec.Group == "":
+		return rec, fmt.Errorf("не указана группа")
+	case rec.Course == "":
+		return rec, fmt.Errorf("не указан курс")
+	case rec.Teacher == "":
+		return rec, fmt.Errorf("не указан преподаватель")
+	}
+
+	var err error
+	if v := cell(colDateTime); v != "" {
+		rec.Start, err = parseDateTime(v)
+	} else {
+		rec.Start, err = parseDateAndTime(cell(colDate), cell(colTime))
+	}
+	if err != nil {
+		return rec, err
+	}
+
+	if v := cell(colDuration); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d <= 0 || d > 24*60 {
+			return rec, fmt.Errorf("некорректная длительность %q (нужно число минут)", v)
+		}
+		rec.Duration = d
+	}
+	return rec, nil
+}
+
+func parseDateAndTime(dateValue, timeValue string) (time.Time, error) {
+	if dateValue == "" {
+		return time.Time{}, fmt.Errorf("не указана дата")
+	}
+	if timeValue == "" {
+		return time.Time{}, fmt.Errorf("не указано время")
+	}
+	day, err := parseDate(dateValue)
+	if err != nil {
+		return time.Time{}, err
+	}
+	clock, err := parseClock(timeValue)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.Add(clock), nil
+}
+
+// parseDate принимает дату текстом или числом дней Excel.
+func parseDate(v string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(v, 64); err == nil && serial >= 1 {
+		return excelEpoch.AddDate(0, 0, int(serial)), nil
+	}
+	return time.Time{}, fmt.Errorf("некорректная дата %q (нужно ДД.ММ.ГГГГ)", v)
+}
+
+// parseClock принимает время текстом или долей суток Excel и возвращает смещение от полуночи.
+func parseClock(v string) (time.Duration, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+		}
+	}
+	if frac, err := strconv.ParseFloat(v, 64); err == nil && frac >= 0 && frac < 1 {
+		return excelFraction(frac), nil
+	}
+	return 0, fmt.Errorf("некорректное время %q (нужно ЧЧ:ММ)", v)
+}
+
+func parseDateTime(v string) (time.Time, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(v, 64); err == nil && serial >= 1 {
+		days := math.Floor(serial)
+		return excelEpoch.AddDate(0, 0, int(days)).Add(excelFraction(serial - days)), nil
+	}
+	return time.Time{}, fmt.Errorf("некорректные дата и время %q (нужно ДД.ММ.ГГГГ ЧЧ:ММ)", v)
+}
+
+// excelFraction переводит долю суток в время с точностью до минуты.
+func excelFraction(frac float64) time.Duration {
+	return time.Duration(math.Round(frac*24*60)) * time.Minute
+}
+
+func blank(row []string) bool {
+	for _, c := range row {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,230 @@
+// Package timetable читает расписание из таблиц (CSV, XLSX), которые ведёт деканат,
+// и превращает строки в записи о занятиях с построчными ошибками.
+package timetable
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat — файл не CSV и не XLSX.
+var ErrUnsupportedFormat = errors.New("поддерживаются только файлы .csv и .xlsx")
+
+// ReadFile читает таблицу из файла по расширению имени. Пустые строки отбрасываются не здесь,
+// чтобы номера строк в ошибках совпадали с номерами в редакторе таблиц.
+func ReadFile(name string, data []byte) ([][]string, error) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return ReadCSV(data)
+	case ".xlsx":
+		return ReadXLSX(data)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ReadCSV читает CSV с разделителем «;» (так сохраняет русский Excel) или «,».
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // BOM
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var rows [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+		}
+		// csv.Reader пропускает пустые строки — восстанавливаем их, чтобы номера совпадали с файлом
+		line, _ := r.FieldPos(0)
+		for len(rows) < line-1 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// Минимальные структуры SpreadsheetML, нужные для чтения значений первого листа.
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.T
+	}
+	var sb strings.Builder
+	for _, r := range rt.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX читает значения первого листа книги. Числа (в том числе даты Excel) возвращаются
+// как есть, в виде строки: их разбирает Parse в зависимости от колонки.
+func ReadXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("файл не похож на XLSX: %w", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("в книге нет листа %s", sheetPath)
+	}
+	var sheet xlsxSheet
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// Пропущенные строки (Excel не хранит пустые) восстанавливаем, чтобы номера совпадали
+		for row.R > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			var value string
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("ячейка %s ссылается на несуществующую строку", c.Ref)
+				}
+				value = shared.Items[idx].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			default:
+				value = c.Value
+			}
+			cells = append(cells, strings.TrimSpace(value))
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// firstSheetPath находит файл первого листа через workbook.xml и его связи.
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", errors.New("в файле нет xl/workbook.xml — это не книга Excel")
+	}
+	var wb xlsxWorkbook
+	if err := decodeZipXML(wbFile, &wb); err != nil {
+		return "", err
+	}
+	relFile, ok := files["xl/_rels/workbook.xml.rels"]
+	if len(wb.Sheets) == 0 || !ok {
+		return fallback, nil
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(relFile, &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[0].RID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("ошибка разбора %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex переводит адрес ячейки ("C12") в номер колонки с нуля.
+func columnIndex(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
@@ -0,0 +1,125 @@
+package timetable
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSVWithSemicolonsAndBOM(t *testing.T) {
+	data := "\xef\xbb\xbfГруппа;Курс;Преподаватель;Дата;Время;Длительность;Аудитория;Тип\n" +
+		"АА-23-01;Матем;TH-0001;17.03.2025;08:00;;101;Лекция\n" +
+		"\n" +
+		"АА-23-01;Прог;TH-0002;2025-03-17;9.45;60;201;Практика\n" +
+		"АА-23-01;;TH-0002;17.03.2025;11:00;;;\n" +
+		"АА-23-01;Прог;TH-0002;31.02.2025;11:00;;;\n" +
+		"АА-23-01;Прог;TH-0002;18.03.2025;11:00;полтора часа;;\n"
+	rows, err := ReadFile("schedule.CSV", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, errs := Parse(rows)
+
+	if len(records) != 2 {
+		t.Fatalf("ожидалось 2 записи, получено %d: %+v", len(records), records)
+	}
+	first := records[0]
+	if first.Line != 2 || first.Course != "Матем" || first.Duration != DefaultDuration ||
+		!first.Start.Equal(time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("первая запись разобрана неверно: %+v", first)
+	}
+	second := records[1]
+	if second.Line != 4 || second.Duration != 60 || second.Start.Format("15:04") != "09:45" {
+		t.Errorf("вторая запись разобрана неверно: %+v", second)
+	}
+
+	var lines []int
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	if len(errs) != 3 || lines[0] != 5 || lines[1] != 6 || lines[2] != 7 {
+		t.Fatalf("ожидались ошибки в строках 5, 6, 7, получено %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "строка 5: не указан курс") {
+		t.Errorf("неожиданный текст ошибки: %v", errs[0])
+	}
+}
+
+func TestParseReportsMissingColumns(t *testing.T) {
+	_, errs := Parse([][]string{{"Группа", "Дата", "Аудитория"}})
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "Курс, Преподаватель, Дата и Время") {
+		t.Fatalf("неожиданные ошибки: %v", errs)
+	}
+}
+
+func TestReadXLSXWithExcelDates(t *testing.T) {
+	// 45733 — 17.03.2025 в датах Excel, 0.34375 — 08:15, 45734.5 — 18.03.2025 12:00
+	sheet := `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+		<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="inlineStr"><is><t>Дата и время</t></is></c></row>
+		<row r="3"><c r="A3" t="s"><v>3</v></c><c r="B3"><v>1</v></c><c r="C3" t="inlineStr"><is><t>TH-0001</t></is></c><c r="D3"><v>45733.34375</v></c></row>
+		<row r="4"><c r="A4" t="s"><v>3</v></c><c r="B4"><v>2</v></c><c r="C4" t="inlineStr"><is><t>TH-0002</t></is></c><c r="D4"><v>45734.5</v></c><c r="F4" t="inlineStr"><is><t>лишнее</t></is></c></row>
+	</sheetData></worksheet>`
+	shared := `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+		<si><t>Группа</t></si><si><r><t>Кур</t></r><r><t>с</t></r></si><si><t>Преподаватель</t></si><si><t>АА-23-01</t></si>
+	</sst>`
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Лист1" sheetId="1" r:id="rId7"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId7" Target="worksheets/timetable.xml"/></Relationships>`,
+		"xl/worksheets/timetable.xml": sheet,
+		"xl/sharedStrings.xml":        shared,
+	})
+
+	rows, err := ReadFile("расписание.xlsx", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[1] != nil || rows[3][4] != "" || rows[3][5] != "лишнее" {
+		t.Fatalf("строки прочитаны неверно: %q", rows)
+	}
+	records, errs := Parse(rows)
+	if len(errs) != 0 {
+		t.Fatalf("неожиданные ошибки: %v", errs)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ожидалось 2 записи, получено %+v", records)
+	}
+	if got := records[0].Start; !got.Equal(time.Date(2025, 3, 17, 8, 15, 0, 0, time.UTC)) || records[0].Line != 3 {
+		t.Errorf("дата Excel разобрана неверно: %v (строка %d)", got, records[0].Line)
+	}
+	if got := records[1].Start; !got.Equal(time.Date(2025, 3, 18, 12, 0, 0, 0, time.UTC)) || records[1].Course != "2" {
+		t.Errorf("вторая запись разобрана неверно: %+v", records[1])
+	}
+}
+
+func TestReadFileRejectsUnknownFormat(t *testing.T) {
+	if _, err := ReadFile("schedule.xls", []byte("x")); err != ErrUnsupportedFormat {
+		t.Fatalf("ожидалась ErrUnsupportedFormat, получено %v", err)
+	}
+	if _, err := ReadFile("schedule.xlsx", []byte("not a zip")); err == nil {
+		t.Fatal("ожидалась ошибка для повреждённого XLSX")
+	}
+}
+
+func buildXLSX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}